@@ -0,0 +1,158 @@
+package expander
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AliasCodec translates between instance aliases and the path segment syntax
+// used to address them. Ecosystems differ in how they write aliases, so the
+// codec decides which registered segments are aliases and how expanded paths
+// refer to them.
+type AliasCodec interface {
+	// Decode returns the alias named by a path segment, if it is one
+	Decode(segment string) (string, bool)
+
+	// Encode renders an alias as a path segment
+	Encode(alias string) string
+}
+
+// Standard alias codecs
+var (
+	// BracketAliases handles the TR-069 Amendment 5 syntax, e.g. "[cpe-1]"
+	BracketAliases AliasCodec = bracketCodec{}
+
+	// QuotedAliases handles double-quoted aliases, e.g. "\"cpe-1\""
+	QuotedAliases AliasCodec = quotedCodec{}
+
+	// BareAliases treats every non-numeric instance segment as an alias
+	BareAliases AliasCodec = bareCodec{}
+)
+
+// bracketCodec implements AliasCodec for "[name]" segments
+type bracketCodec struct{}
+
+func (bracketCodec) Decode(segment string) (string, bool) {
+	if len(segment) < 3 || segment[0] != '[' || segment[len(segment)-1] != ']' {
+		return "", false
+	}
+	return segment[1 : len(segment)-1], true
+}
+
+func (bracketCodec) Encode(alias string) string {
+	return "[" + alias + "]"
+}
+
+// quotedCodec implements AliasCodec for double-quoted segments
+type quotedCodec struct{}
+
+func (quotedCodec) Decode(segment string) (string, bool) {
+	alias, err := strconv.Unquote(segment)
+	if err != nil || !strings.HasPrefix(segment, `"`) || alias == "" {
+		return "", false
+	}
+	return alias, true
+}
+
+func (quotedCodec) Encode(alias string) string {
+	return strconv.Quote(alias)
+}
+
+// bareCodec implements AliasCodec for unadorned segments
+type bareCodec struct{}
+
+func (bareCodec) Decode(segment string) (string, bool) {
+	if segment == "" {
+		return "", false
+	}
+	if _, err := strconv.Atoi(segment); err == nil {
+		return "", false
+	}
+	return segment, true
+}
+
+func (bareCodec) Encode(alias string) string {
+	return alias
+}
+
+// RegisterAliasValues records the values of instance Alias parameters, as
+// returned by GetParameterValues for e.g. "Device.WiFi.AccessPoint.*.Alias".
+// Keys are the full parameter names and must end in ".<index>.Alias".
+//
+// Known aliases let patterns address instances by alias, such as
+// "Device.WiFi.AccessPoint.[guest].Enable", which then expand to the numbered
+// instance, and let WithAliasOutput render numbered instances by alias.
+func (e *Expander) RegisterAliasValues(values map[string]string) error {
+	for name, value := range values {
+		table, index, err := splitAliasParameter(name)
+		if err != nil {
+			return err
+		}
+		if e.aliasValues[table] == nil {
+			e.aliasValues[table] = make(map[int]string)
+		}
+		e.aliasValues[table][index] = value
+	}
+
+	// Discoveries queued below alias patterns may now resolve differently
+	e.clearQueue()
+	e.settle()
+	return nil
+}
+
+// splitAliasParameter splits an Alias parameter name into the discovery path
+// of its table and the instance index
+func splitAliasParameter(name string) (string, int, error) {
+	object, found := strings.CutSuffix(name, ".Alias")
+	dot := strings.LastIndexByte(object, '.')
+	if !found || dot == -1 {
+		return "", 0, fmt.Errorf("%w: %s is not an Alias parameter", ErrInvalidPath, name)
+	}
+
+	index, err := strconv.Atoi(object[dot+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %s is not an Alias parameter", ErrInvalidPath, name)
+	}
+	return object[:dot+1], index, nil
+}
+
+// patternCodec returns the codec used for aliases written in patterns and
+// rendered in expanded paths
+func (e *Expander) patternCodec() AliasCodec {
+	if e.config.aliasCodec != nil {
+		return e.config.aliasCodec
+	}
+	return BracketAliases
+}
+
+// resolveLiteral resolves an alias segment of a pattern to the number of the
+// instance carrying that alias. Aliases whose instance is unknown are kept,
+// leaving the device to resolve them through alias-based addressing.
+func (e *Expander) resolveLiteral(parentPath, segment string) string {
+	values := e.aliasValues[parentPath+"."]
+	if len(values) == 0 || e.config.aliasOutput {
+		return segment
+	}
+	alias, ok := e.patternCodec().Decode(segment)
+	if !ok {
+		return segment
+	}
+	for index, value := range values {
+		if value == alias {
+			return strconv.Itoa(index)
+		}
+	}
+	return segment
+}
+
+// renderIndex renders an instance number of a table, by alias if
+// WithAliasOutput is set and the Alias value of the instance is known
+func (e *Expander) renderIndex(table string, index int) string {
+	if e.config.aliasOutput {
+		if alias, ok := e.aliasValues[table][index]; ok && alias != "" {
+			return e.patternCodec().Encode(alias)
+		}
+	}
+	return strconv.Itoa(index)
+}
@@ -0,0 +1,132 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Alias Codecs", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+		exp = nil
+	})
+
+	It("should ignore alias instances without a codec", func() {
+		exp = expander.Get()
+		err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.Register([]string{
+			"Device.WiFi.AccessPoint.1.",
+			"Device.WiFi.AccessPoint.[guest].",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(ConsistOf("Device.WiFi.AccessPoint.1.Enable"))
+	})
+
+	It("should expand and descend into bracketed aliases", func() {
+		exp = expander.Get(expander.WithAliasCodec(expander.BracketAliases))
+		err := exp.Add("Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.Register([]string{
+			"Device.WiFi.AccessPoint.[guest].",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.WiFi.AccessPoint.[guest].AssociatedDevice."))
+
+		err = exp.Register([]string{
+			"Device.WiFi.AccessPoint.[guest].AssociatedDevice.3.",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(ConsistOf("Device.WiFi.AccessPoint.[guest].AssociatedDevice.3.MACAddress"))
+	})
+
+	DescribeTable("standard codecs",
+		func(codec expander.AliasCodec, segment, alias string, ok bool) {
+			decoded, recognized := codec.Decode(segment)
+			Expect(recognized).To(Equal(ok))
+			if ok {
+				Expect(decoded).To(Equal(alias))
+				Expect(codec.Encode(alias)).To(Equal(segment))
+			}
+		},
+		Entry("bracket alias", expander.BracketAliases, "[cpe-1]", "cpe-1", true),
+		Entry("bracket non-alias", expander.BracketAliases, "cpe-1", "", false),
+		Entry("quoted alias", expander.QuotedAliases, `"cpe-1"`, "cpe-1", true),
+		Entry("quoted non-alias", expander.QuotedAliases, "[cpe-1]", "", false),
+		Entry("bare alias", expander.BareAliases, "cpe-1", "cpe-1", true),
+		Entry("bare number", expander.BareAliases, "7", "", false),
+	)
+})
+
+var _ = Describe("Alias Addressing", func() {
+	var exp *expander.Expander
+
+	aliasValues := map[string]string{
+		"Device.WiFi.AccessPoint.1.Alias": "main",
+		"Device.WiFi.AccessPoint.2.Alias": "guest",
+	}
+
+	AfterEach(func() {
+		expander.Release(exp)
+		exp = nil
+	})
+
+	It("should keep alias patterns for the device to resolve", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.AccessPoint.[guest].Enable")).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.AccessPoint.[guest].Enable"}))
+	})
+
+	It("should resolve alias patterns to numbered instances", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.AccessPoint.[guest].AssociatedDevice.*.MACAddress")).To(Succeed())
+		Expect(exp.RegisterAliasValues(aliasValues)).To(Succeed())
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.WiFi.AccessPoint.2.AssociatedDevice."))
+		Expect(exp.Register([]string{path + "4."})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.AccessPoint.2.AssociatedDevice.4.MACAddress"}))
+	})
+
+	It("should render numbered instances by alias", func() {
+		exp = expander.Get(expander.WithAliasOutput())
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable", "Device.WiFi.AccessPoint.[guest].Enable")).To(Succeed())
+		Expect(exp.RegisterAliasValues(aliasValues)).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "2.", path + "3."})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.3.Enable",
+			"Device.WiFi.AccessPoint.[guest].Enable",
+			"Device.WiFi.AccessPoint.[main].Enable",
+		}))
+	})
+
+	It("should reject names that are not Alias parameters", func() {
+		exp = expander.Get()
+		err := exp.RegisterAliasValues(map[string]string{"Device.WiFi.AccessPoint.1.SSID": "x"})
+		Expect(err).To(MatchError(expander.ErrInvalidPath))
+	})
+})
@@ -0,0 +1,63 @@
+package expander
+
+import (
+	"slices"
+	"strings"
+)
+
+// WithAllowedSubtrees restricts the expansion to the subtrees below
+// prefixes, so the job of one tenant of a multi-tenant ACS can't read
+// outside the object partition delegated to it. Only discoveries within or
+// above the prefixes are issued, and only paths within them are expanded.
+// Prefixes may contain wildcard segments. Without prefixes nothing is
+// allowed.
+func WithAllowedSubtrees(prefixes []string) Option {
+	return func(e *Expander) {
+		if e.config.allowed == nil {
+			e.config.allowed = []string{}
+		}
+		e.config.allowed = append(e.config.allowed, prefixes...)
+	}
+}
+
+// CollectWithin returns the expanded paths lying within the subtrees below
+// prefixes, as Collect does. Prefixes may contain wildcard segments.
+func (e *Expander) CollectWithin(prefixes []string) ([]string, error) {
+	paths, err := e.Collect()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(paths, func(path string) bool {
+		return !withinAny(prefixes, path)
+	}), nil
+}
+
+// isAllowed reports whether an expanded path lies within the allowed subtrees
+func (e *Expander) isAllowed(path string) bool {
+	return e.config.allowed == nil || withinAny(e.config.allowed, path)
+}
+
+// isAllowedDiscovery reports whether a discovery path lies within an
+// allowed subtree or leads to one
+func (e *Expander) isAllowedDiscovery(discoveryPath string) bool {
+	if e.isAllowed(discoveryPath) {
+		return true
+	}
+	path := strings.Split(strings.TrimSuffix(discoveryPath, "."), ".")
+	for _, prefix := range e.config.allowed {
+		segments := strings.Split(strings.TrimSuffix(normalizePattern(prefix), "."), ".")
+		// A "**" within reach leads to every path below the segments before it
+		deep := slices.Contains(segments[:min(len(segments), len(path))], "**")
+		if (deep || len(segments) > len(path)) && matchesPrefix(segments, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinAny reports whether a path lies within the subtree below any of prefixes
+func withinAny(prefixes []string, path string) bool {
+	return slices.ContainsFunc(prefixes, func(prefix string) bool {
+		return inSubtree(prefix, path)
+	})
+}
@@ -0,0 +1,50 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Allowed Subtrees", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should restrict collected paths to the given prefixes", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID", "Device.Hosts.Host.*.IPAddress")).To(Succeed())
+		drainDiscoveries(exp)
+
+		Expect(exp.CollectWithin([]string{"Device.WiFi."})).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+		Expect(exp.CollectWithin([]string{"Device.*.Host.1"})).To(Equal([]string{"Device.Hosts.Host.1.IPAddress"}))
+		Expect(exp.CollectWithin(nil)).To(BeEmpty())
+	})
+
+	It("should allow nothing without prefixes", func() {
+		exp = expander.Get(expander.WithAllowedSubtrees([]string{}))
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID", "Device.DeviceInfo.SoftwareVersion")).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(BeEmpty())
+		Expect(exp.Collect()).To(BeEmpty())
+	})
+
+	It("should keep discoveries and paths within the allowed subtrees", func() {
+		exp = expander.Get(expander.WithAllowedSubtrees([]string{"Device.WiFi.SSID.*.", "Device.DeviceInfo.SoftwareVersion"}))
+		Expect(exp.Add(
+			"Device.WiFi.SSID.*.SSID",
+			"Device.WiFi.Radio.*.Channel",
+			"Device.Hosts.Host.*.IPAddress",
+			"Device.DeviceInfo.SoftwareVersion",
+			"Device.DeviceInfo.HardwareVersion",
+		)).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.WiFi.SSID."}))
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.DeviceInfo.SoftwareVersion",
+			"Device.WiFi.SSID.1.SSID",
+		}))
+	})
+})
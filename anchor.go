@@ -0,0 +1,60 @@
+package expander
+
+import (
+	"context"
+	"strings"
+)
+
+// ExpandUnderAll expands the anchor objects matched by anchorPattern, e.g.
+// "Device.WiFi.SSID.*.", along with relativePatterns beneath each of them,
+// e.g. "Stats.BytesSent", and returns the expanded paths grouped by anchor
+// object path. Anchors without any matching relative path map to an empty
+// list. Everything is expanded by a single expander, so discoveries shared
+// by anchors and relative patterns are issued once.
+func ExpandUnderAll(ctx context.Context, anchorPattern string, relativePatterns []string, fetch DiscoveryFunc, opts ...Option) (map[string][]string, error) {
+	if anchorPattern == "" || len(relativePatterns) == 0 {
+		return nil, ErrEmptyPath
+	}
+	anchor := strings.TrimSuffix(anchorPattern, ".") + "."
+
+	exp := Get(opts...)
+	defer Release(exp)
+
+	patterns := []string{anchor}
+	for _, relative := range relativePatterns {
+		patterns = append(patterns, anchor+strings.TrimPrefix(relative, "."))
+	}
+	if err := exp.Add(patterns...); err != nil {
+		return nil, err
+	}
+	if err := exp.discoverAll(ctx, fetch); err != nil {
+		return nil, err
+	}
+
+	byPattern, err := exp.CollectByPattern()
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[string][]string, len(byPattern[anchor]))
+	for _, object := range byPattern[anchor] {
+		grouped[object] = []string{}
+	}
+
+	depth := strings.Count(anchor, ".")
+	seen := make(map[string]bool)
+	for _, pattern := range patterns[1:] {
+		for _, path := range byPattern[pattern] {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			object, _ := splitAfterSegments(path, depth)
+			grouped[object] = append(grouped[object], path)
+		}
+	}
+	for object, paths := range grouped {
+		grouped[object] = sortedCopy(paths)
+	}
+	return grouped, nil
+}
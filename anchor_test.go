@@ -0,0 +1,43 @@
+package expander_test
+
+import (
+	"context"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Anchored Expansion", func() {
+	device := fakeDevice(
+		"Device.WiFi.SSID.1.Stats.BytesSent",
+		"Device.WiFi.SSID.1.Stats.BytesReceived",
+		"Device.WiFi.SSID.2.Stats.BytesSent",
+		"Device.WiFi.SSID.1.X_ACME_Client.4.Rate",
+	)
+
+	It("should group the relative patterns by anchor", func() {
+		grouped, err := expander.ExpandUnderAll(context.Background(), "Device.WiFi.SSID.*",
+			[]string{"Stats.BytesSent", "X_ACME_Client.*.Rate"}, device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(grouped).To(Equal(map[string][]string{
+			"Device.WiFi.SSID.1.": {
+				"Device.WiFi.SSID.1.Stats.BytesSent",
+				"Device.WiFi.SSID.1.X_ACME_Client.4.Rate",
+			},
+			"Device.WiFi.SSID.2.": {"Device.WiFi.SSID.2.Stats.BytesSent"},
+		}))
+	})
+
+	It("should keep anchors without relative paths", func() {
+		grouped, err := expander.ExpandUnderAll(context.Background(), "Device.WiFi.SSID.*.",
+			[]string{"X_ACME_Client.*.Rate"}, device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(grouped).To(HaveKeyWithValue("Device.WiFi.SSID.2.", BeEmpty()))
+	})
+
+	It("should require relative patterns", func() {
+		_, err := expander.ExpandUnderAll(context.Background(), "Device.WiFi.SSID.*.", nil, device)
+		Expect(err).To(MatchError(expander.ErrEmptyPath))
+	})
+})
@@ -1,6 +1,8 @@
 package expander_test
 
 import (
+	"context"
+	"strconv"
 	"testing"
 
 	expander "github.com/metalgrid/tr069-path-expander/v2"
@@ -203,3 +205,35 @@ func BenchmarkDynamicAddition(b *testing.B) {
 		expander.Release(exp)
 	}
 }
+
+func BenchmarkWideTable(b *testing.B) {
+	// 2000 instances with 5 instances each, as on a busy host table
+	fetch := func(_ context.Context, path string) ([]string, error) {
+		n := 5
+		if path == "Device.A." {
+			n = 2000
+		}
+		names := make([]string, n)
+		for i := range names {
+			names[i] = path + strconv.Itoa(i+1) + "."
+		}
+		return names, nil
+	}
+
+	for range b.N {
+		exp := expander.Get()
+		if err := exp.Add("Device.A.*.B.*.C"); err != nil {
+			b.Fatal(err)
+		}
+
+		paths, err := exp.Expand(context.Background(), fetch)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(paths) != 10000 {
+			b.Fatalf("expected 10000 paths, got %d", len(paths))
+		}
+
+		expander.Release(exp)
+	}
+}
@@ -0,0 +1,49 @@
+package expander
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// WithBlockedSubtrees keeps the expansion out of the subtrees below
+// prefixes, such as Device.DeviceInfo.VendorLogFile. or vendor extensions
+// known to hang the device: their discoveries are never issued and their
+// paths never expanded. Prefixes may contain wildcard segments. Blocked
+// subtrees the patterns reach are listed by Blocked and reported as
+// BranchBlocked by CollectReport.
+func WithBlockedSubtrees(prefixes []string) Option {
+	return func(e *Expander) {
+		e.config.blocked = append(e.config.blocked, prefixes...)
+	}
+}
+
+// Blocked returns the blocked subtree prefixes the patterns reached, sorted.
+func (e *Expander) Blocked() []string {
+	return slices.Sorted(maps.Keys(e.blockedHits))
+}
+
+// isBlocked reports whether a discovery path or expanded path lies in a
+// blocked subtree, recording the prefix it hit
+func (e *Expander) isBlocked(path string) bool {
+	for _, prefix := range e.config.blocked {
+		if inSubtree(prefix, path) {
+			e.blockedHits[strings.TrimSuffix(prefix, ".")+"."] = true
+			return true
+		}
+	}
+	return false
+}
+
+// inSubtree reports whether a path, with or without its trailing dot, is
+// the object a prefix names or lies below it. The prefix may contain
+// wildcard segments.
+func inSubtree(prefix, path string) bool {
+	prefix = strings.TrimSuffix(prefix, ".")
+	path = strings.TrimSuffix(path, ".")
+	if _, at := matchPattern(prefix, path); at {
+		return true
+	}
+	_, below := matchPattern(prefix+".", path)
+	return below
+}
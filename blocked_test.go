@@ -0,0 +1,51 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Blocked Subtrees", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should never discover or expand below a blocked prefix", func() {
+		exp = expander.Get(expander.WithBlockedSubtrees([]string{
+			"Device.DeviceInfo.VendorLogFile.",
+			"Device.WiFi.SSID.*.X_VENDOR_Stats",
+		}))
+		Expect(exp.Add(
+			"Device.DeviceInfo.VendorLogFile.*.Name",
+			"Device.DeviceInfo.SoftwareVersion",
+			"Device.WiFi.SSID.*.X_VENDOR_Stats.*.",
+			"Device.WiFi.SSID.*.SSID",
+		)).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.WiFi.SSID."}))
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.DeviceInfo.SoftwareVersion",
+			"Device.WiFi.SSID.1.SSID",
+		}))
+		Expect(exp.Blocked()).To(Equal([]string{
+			"Device.DeviceInfo.VendorLogFile.",
+			"Device.WiFi.SSID.*.X_VENDOR_Stats.",
+		}))
+	})
+
+	It("should report blocked subtrees", func() {
+		exp = expander.Get(expander.WithBlockedSubtrees([]string{"Device.DeviceInfo.VendorLogFile"}))
+		Expect(exp.Add("Device.DeviceInfo.VendorLogFile.1.Name", "Device.DeviceInfo.SoftwareVersion")).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		report := exp.CollectReport()
+		Expect(report.Paths).To(Equal([]string{"Device.DeviceInfo.SoftwareVersion"}))
+		Expect(report.Errors).To(Equal([]*expander.BranchError{
+			{Kind: expander.BranchBlocked, Path: "Device.DeviceInfo.VendorLogFile."},
+		}))
+	})
+})
@@ -0,0 +1,146 @@
+package expander
+
+import (
+	"container/list"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachePolicy bounds how long and how many discoveries a BoundedCache keeps.
+// The zero value keeps everything forever.
+type CachePolicy struct {
+	// TTL is how long a discovery stays fresh; zero never expires
+	TTL time.Duration
+
+	// TTLFor overrides TTL for individual discovery paths, e.g. to expire
+	// Device.Hosts.Host. sooner than Device.WiFi.Radio.; zero never expires
+	TTLFor func(path string) time.Duration
+
+	// MaxEntries bounds the number of discovery paths kept, evicting the
+	// least recently used one first; zero is unbounded
+	MaxEntries int
+
+	// Clock returns the current time; nil uses time.Now
+	Clock func() time.Time
+}
+
+// BoundedCache is a Cache expiring entries after a TTL and evicting the least
+// recently used ones beyond a maximum number of entries. It is safe for
+// concurrent use.
+type BoundedCache struct {
+	mu      sync.Mutex
+	policy  CachePolicy
+	entries map[string]*list.Element
+
+	// recency orders the entries from most to least recently used
+	recency *list.List
+}
+
+// boundedEntry is a cached discovery along with its expiry time
+type boundedEntry struct {
+	path    string
+	indices []int
+	expires time.Time
+}
+
+// NewBoundedCache creates an empty cache enforcing policy.
+func NewBoundedCache(policy CachePolicy) *BoundedCache {
+	if policy.Clock == nil {
+		policy.Clock = time.Now
+	}
+	return &BoundedCache{
+		policy:  policy,
+		entries: make(map[string]*list.Element),
+		recency: list.New(),
+	}
+}
+
+// Get returns the indices cached for a discovery path unless they expired.
+func (c *BoundedCache) Get(path string) ([]int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[path]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*boundedEntry)
+	if !entry.expires.IsZero() && !c.policy.Clock().Before(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.recency.MoveToFront(elem)
+	return entry.indices, true
+}
+
+// Put stores the indices discovered for a discovery path, evicting the
+// least recently used entry if the cache is full.
+func (c *BoundedCache) Put(path string, indices []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &boundedEntry{path: path, indices: slices.Clone(indices)}
+	if ttl := c.ttl(path); ttl > 0 {
+		entry.expires = c.policy.Clock().Add(ttl)
+	}
+
+	if elem, ok := c.entries[path]; ok {
+		elem.Value = entry
+		c.recency.MoveToFront(elem)
+		return
+	}
+	c.entries[path] = c.recency.PushFront(entry)
+
+	if c.policy.MaxEntries > 0 && c.recency.Len() > c.policy.MaxEntries {
+		c.remove(c.recency.Back())
+	}
+}
+
+// Invalidate drops every entry whose path starts with prefix.
+func (c *BoundedCache) Invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path, elem := range c.entries {
+		if strings.HasPrefix(path, prefix) {
+			c.remove(elem)
+		}
+	}
+}
+
+// Len returns the number of entries held, expired ones included until they
+// are looked up again.
+func (c *BoundedCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.recency.Len()
+}
+
+// ttl returns the time to live of a discovery path
+func (c *BoundedCache) ttl(path string) time.Duration {
+	if c.policy.TTLFor != nil {
+		return c.policy.TTLFor(path)
+	}
+	return c.policy.TTL
+}
+
+// remove drops an entry
+func (c *BoundedCache) remove(elem *list.Element) {
+	c.recency.Remove(elem)
+	delete(c.entries, elem.Value.(*boundedEntry).path)
+}
+
+// WithCachePolicy replaces the private cache of the expander with a
+// BoundedCache enforcing policy, for expanders kept alive to reuse their
+// discoveries. Expired and evicted discoveries are requested again by the
+// next call to Next after the expansion completed; within an expansion they
+// are kept, as with WithCache, so a small MaxEntries cannot stall it.
+func WithCachePolicy(policy CachePolicy) Option {
+	return func(e *Expander) {
+		e.useCache(NewBoundedCache(policy))
+	}
+}
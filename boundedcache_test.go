@@ -0,0 +1,100 @@
+package expander_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bounded Cache", func() {
+	var now time.Time
+
+	clock := func() time.Time {
+		return now
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	})
+
+	It("should expire discoveries after their TTL", func() {
+		cache := expander.NewBoundedCache(expander.CachePolicy{
+			TTL: time.Minute,
+			TTLFor: func(path string) time.Duration {
+				if strings.HasPrefix(path, "Device.Hosts.") {
+					return time.Second
+				}
+				return 0
+			},
+			Clock: clock,
+		})
+
+		cache.Put("Device.Hosts.Host.", []int{1})
+		cache.Put("Device.WiFi.Radio.", []int{1})
+
+		now = now.Add(time.Hour)
+		_, ok := cache.Get("Device.Hosts.Host.")
+		Expect(ok).To(BeFalse())
+		indices, ok := cache.Get("Device.WiFi.Radio.")
+		Expect(ok).To(BeTrue())
+		Expect(indices).To(Equal([]int{1}))
+	})
+
+	It("should evict the least recently used discovery", func() {
+		cache := expander.NewBoundedCache(expander.CachePolicy{MaxEntries: 2})
+		cache.Put("Device.Hosts.Host.", []int{1})
+		cache.Put("Device.WiFi.Radio.", []int{1})
+		cache.Get("Device.Hosts.Host.")
+		cache.Put("Device.WiFi.SSID.", []int{1})
+
+		Expect(cache.Len()).To(Equal(2))
+		_, ok := cache.Get("Device.WiFi.Radio.")
+		Expect(ok).To(BeFalse())
+		_, ok = cache.Get("Device.Hosts.Host.")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should finish expansions needing more entries than the cache holds", func() {
+		cache := expander.NewBoundedCache(expander.CachePolicy{MaxEntries: 2})
+		exp := expander.Get(expander.WithCache(cache))
+		defer expander.Release(exp)
+		Expect(exp.Add("Device.A.*.B.*.C")).To(Succeed())
+
+		calls := 0
+		paths, err := exp.Expand(context.Background(), func(_ context.Context, path string) ([]string, error) {
+			calls++
+			return []string{path + "1.", path + "2.", path + "3."}, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(9))
+		Expect(calls).To(Equal(4))
+
+		// Entries were evicted in the middle of the expansion
+		Expect(cache.Len()).To(Equal(2))
+		_, ok := cache.Get("Device.A.")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should rediscover expired instances in a long-lived expander", func() {
+		exp := expander.Get(expander.WithCachePolicy(expander.CachePolicy{TTL: time.Minute, Clock: clock}))
+		defer expander.Release(exp)
+
+		Expect(exp.Add("Device.Hosts.Host.*.HostName")).To(Succeed())
+		Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.Hosts.Host."}))
+		Expect(exp.Collect()).To(Equal([]string{"Device.Hosts.Host.1.HostName"}))
+
+		now = now.Add(2 * time.Minute)
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.Hosts.Host."))
+		Expect(exp.Register([]string{"Device.Hosts.Host.2"})).To(Succeed())
+
+		_, hasMore = exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{"Device.Hosts.Host.2.HostName"}))
+	})
+})
@@ -0,0 +1,65 @@
+package expander
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParameterValue is a single parameter name and value pair, ready to be sent
+// in a SetParameterValues request.
+type ParameterValue struct {
+	Name  string
+	Value string
+}
+
+// BulkDataOptions controls how a pattern set is rendered into a
+// Device.BulkData profile configuration.
+type BulkDataOptions struct {
+	// Profile is the target profile object, e.g. "Device.BulkData.Profile.1."
+	Profile string
+
+	// Wildcards keeps the added patterns as References, for CPEs that accept
+	// wildcards in BulkData references. When false, the expanded paths are used
+	// and the expansion must be complete.
+	Wildcards bool
+
+	// Names optionally maps a reference to the name used in the report body.
+	// References without an entry get an empty Name, meaning the full path.
+	Names map[string]string
+}
+
+// BulkDataParameters converts the pattern set into the
+// Profile.{i}.Parameter.{i}.Name and .Reference values of a BulkData profile.
+// Parameter instances are numbered from 1 in pattern order; the caller is
+// responsible for creating them with AddObject beforehand.
+func (e *Expander) BulkDataParameters(opts BulkDataOptions) ([]ParameterValue, error) {
+	if opts.Profile == "" {
+		return nil, fmt.Errorf("bulk data profile: %w", ErrInvalidPath)
+	}
+
+	profile := opts.Profile
+	if !strings.HasSuffix(profile, ".") {
+		profile += "."
+	}
+
+	references := e.patterns
+	if !opts.Wildcards {
+		paths, err := e.Collect()
+		if err != nil {
+			return nil, err
+		}
+		references = paths
+	}
+
+	values := make([]ParameterValue, 0, 2*len(references))
+	for i, ref := range references {
+		param := profile + "Parameter." + strconv.Itoa(i+1) + "."
+		values = append(values,
+			ParameterValue{Name: param + "Name", Value: opts.Names[ref]},
+			ParameterValue{Name: param + "Reference", Value: ref},
+		)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,66 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BulkData Profile Generation", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.DeviceInfo.UpTime",
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should preserve wildcards when requested", func() {
+		values, err := exp.BulkDataParameters(expander.BulkDataOptions{
+			Profile:   "Device.BulkData.Profile.1",
+			Wildcards: true,
+			Names:     map[string]string{"Device.DeviceInfo.UpTime": "uptime"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal([]expander.ParameterValue{
+			{Name: "Device.BulkData.Profile.1.Parameter.1.Name", Value: ""},
+			{Name: "Device.BulkData.Profile.1.Parameter.1.Reference", Value: "Device.WiFi.AccessPoint.*.Enable"},
+			{Name: "Device.BulkData.Profile.1.Parameter.2.Name", Value: "uptime"},
+			{Name: "Device.BulkData.Profile.1.Parameter.2.Reference", Value: "Device.DeviceInfo.UpTime"},
+		}))
+	})
+
+	It("should use expanded paths otherwise", func() {
+		_, _ = exp.Next()
+		err := exp.Register([]string{"Device.WiFi.AccessPoint.3"})
+		Expect(err).NotTo(HaveOccurred())
+
+		values, err := exp.BulkDataParameters(expander.BulkDataOptions{
+			Profile: "Device.BulkData.Profile.2.",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(ContainElements(
+			expander.ParameterValue{Name: "Device.BulkData.Profile.2.Parameter.1.Reference", Value: "Device.DeviceInfo.UpTime"},
+			expander.ParameterValue{Name: "Device.BulkData.Profile.2.Parameter.2.Reference", Value: "Device.WiFi.AccessPoint.3.Enable"},
+		))
+	})
+
+	It("should fail when the expansion is incomplete", func() {
+		_, err := exp.BulkDataParameters(expander.BulkDataOptions{
+			Profile: "Device.BulkData.Profile.1.",
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should require a profile", func() {
+		_, err := exp.BulkDataParameters(expander.BulkDataOptions{Wildcards: true})
+		Expect(err).To(MatchError(expander.ErrInvalidPath))
+	})
+})
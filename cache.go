@@ -0,0 +1,151 @@
+package expander
+
+import "strings"
+
+// Cache stores the instance indices discovered for each discovery path.
+// Paths are partial paths with a trailing dot, e.g. "Device.WiFi.SSID.".
+// Implementations may evict entries at any time; evicted discoveries are
+// requested again the next time the expander looks for work.
+type Cache interface {
+	// Get returns the indices cached for a discovery path
+	Get(path string) ([]int, bool)
+
+	// Put stores the indices discovered for a discovery path
+	Put(path string, indices []int)
+
+	// Invalidate drops every entry whose path starts with prefix
+	Invalidate(prefix string)
+}
+
+// mapCache is the default Cache, private to a single expander
+type mapCache map[string][]int
+
+func (c mapCache) Get(path string) ([]int, bool) {
+	indices, ok := c[path]
+	return indices, ok
+}
+
+func (c mapCache) Put(path string, indices []int) {
+	c[path] = indices
+}
+
+func (c mapCache) Invalidate(prefix string) {
+	for path := range c {
+		if strings.HasPrefix(path, prefix) {
+			delete(c, path)
+		}
+	}
+}
+
+// pinnedCache layers the private map of an expander over an external cache.
+// The discoveries of the expansion in progress are pinned in the map, so a
+// cache evicting them, such as a BoundedCache holding fewer entries than the
+// expansion needs, cannot make the expander request them over and over.
+type pinnedCache struct {
+	Cache
+	pinned mapCache
+}
+
+func (c pinnedCache) Get(path string) ([]int, bool) {
+	if indices, ok := c.pinned[path]; ok {
+		return indices, true
+	}
+	indices, ok := c.Cache.Get(path)
+	if ok {
+		c.pinned[path] = indices
+	}
+	return indices, ok
+}
+
+func (c pinnedCache) Put(path string, indices []int) {
+	c.pinned[path] = indices
+	c.Cache.Put(path, indices)
+}
+
+func (c pinnedCache) Invalidate(prefix string) {
+	c.pinned.Invalidate(prefix)
+	c.Cache.Invalidate(prefix)
+}
+
+// useCache makes the expander read and store discovered indices in an
+// external cache, pinning those of the expansion in progress
+func (e *Expander) useCache(cache Cache) {
+	e.cache = pinnedCache{Cache: cache, pinned: e.localCache}
+}
+
+// unpin releases the discoveries pinned by the last expansion round, so the
+// ones the external cache expired or evicted meanwhile are requested again
+func (e *Expander) unpin() {
+	if _, pinned := e.cache.(pinnedCache); pinned {
+		clear(e.localCache)
+	}
+}
+
+// WithCache makes the expander read and store discovered indices in cache
+// instead of its private one. The cache is not cleared when the expander is
+// reset or released, so it can outlive the expander and be shared with the
+// next expansion for the same device. Aliases are not stored in the cache.
+// Discoveries are kept for the rest of the expansion they were made in, even
+// if the cache drops them; they are looked up in the cache again once a
+// completed expansion is resumed by Next.
+func WithCache(cache Cache) Option {
+	return func(e *Expander) {
+		e.useCache(cache)
+	}
+}
+
+// Invalidate drops the discoveries under prefix from the cache so they are
+// requested again, along with everything discovered below them. It reopens
+// a completed expansion if anything was dropped.
+func (e *Expander) Invalidate(prefix string) {
+	e.cache.Invalidate(prefix)
+	for path := range e.aliases {
+		if strings.HasPrefix(path, prefix) {
+			delete(e.aliases, path)
+		}
+	}
+	for path := range e.names {
+		if strings.HasPrefix(path, prefix) {
+			delete(e.names, path)
+		}
+	}
+
+	dropped := false
+	for path := range e.processedDiscoveries {
+		if strings.HasPrefix(path, prefix) {
+			delete(e.processedDiscoveries, path)
+			dropped = true
+		}
+	}
+	if !dropped {
+		return
+	}
+
+	e.isComplete = false
+	e.generateDiscoveryPaths("")
+}
+
+// RefreshSubtree forgets everything known below prefix, e.g. after AddObject
+// or DeleteObject on "Device.WiFi.AccessPoint.": the discoveries under it are
+// dropped from the cache and requested again, and the expanded paths under it
+// are withdrawn until they are expanded again. The rest of the expansion is
+// kept. A prefix without a trailing dot is treated as a partial path.
+func (e *Expander) RefreshSubtree(prefix string) {
+	if !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	kept := e.expandedPaths[:0]
+	for _, path := range e.expandedPaths {
+		if strings.HasPrefix(path, prefix) {
+			delete(e.expandedSet, path)
+			continue
+		}
+		kept = append(kept, path)
+	}
+	e.expandedPaths = kept
+
+	e.Invalidate(prefix)
+	e.isComplete = false
+	e.generateDiscoveryPaths("")
+}
@@ -0,0 +1,152 @@
+package expander_test
+
+import (
+	"strings"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// recordingCache is a map-backed Cache that can evict on demand
+type recordingCache map[string][]int
+
+func (c recordingCache) Get(path string) ([]int, bool) {
+	indices, ok := c[path]
+	return indices, ok
+}
+
+func (c recordingCache) Put(path string, indices []int) {
+	c[path] = indices
+}
+
+func (c recordingCache) Invalidate(prefix string) {
+	for path := range c {
+		if strings.HasPrefix(path, prefix) {
+			delete(c, path)
+		}
+	}
+}
+
+// drainDiscoveries answers every discovery with a single instance and
+// returns the discovery paths requested
+func drainDiscoveries(exp *expander.Expander) []string {
+	var requested []string
+	for {
+		path, hasMore := exp.Next()
+		if !hasMore {
+			return requested
+		}
+		requested = append(requested, path)
+		Expect(exp.Register([]string{path + "1"})).To(Succeed())
+	}
+}
+
+var _ = Describe("Pluggable Cache", func() {
+	const pattern = "Device.Hosts.Host.*.IPv4Address.*.IPAddress"
+
+	var (
+		cache recordingCache
+		exp   *expander.Expander
+	)
+
+	BeforeEach(func() {
+		cache = recordingCache{}
+		exp = expander.Get(expander.WithCache(cache))
+		Expect(exp.Add(pattern)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should store discoveries in the injected cache and reuse them", func() {
+		Expect(drainDiscoveries(exp)).To(HaveLen(2))
+		Expect(cache).To(HaveKeyWithValue("Device.Hosts.Host.", []int{1}))
+
+		expander.Release(exp)
+		Expect(cache).To(HaveLen(2))
+
+		exp = expander.Get(expander.WithCache(cache))
+		Expect(exp.Add(pattern)).To(Succeed())
+		Expect(drainDiscoveries(exp)).To(BeEmpty())
+		Expect(exp.Collect()).To(Equal([]string{"Device.Hosts.Host.1.IPv4Address.1.IPAddress"}))
+	})
+
+	It("should rediscover invalidated subtrees", func() {
+		Expect(drainDiscoveries(exp)).To(HaveLen(2))
+		_, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+
+		exp.Invalidate("Device.Hosts.Host.1.")
+		Expect(cache).To(HaveKey("Device.Hosts.Host."))
+		Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.Hosts.Host.1.IPv4Address."}))
+		Expect(exp.Check()).To(Succeed())
+	})
+
+	It("should keep entries evicted by the cache until the expansion completes", func() {
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1", path + "2"})).To(Succeed())
+
+		// The cache evicts the first level while the second is being discovered
+		cache.Invalidate("Device.Hosts.Host.")
+		Expect(drainDiscoveries(exp)).To(ConsistOf(
+			"Device.Hosts.Host.1.IPv4Address.",
+			"Device.Hosts.Host.2.IPv4Address.",
+		))
+		Expect(exp.Collect()).To(HaveLen(2))
+		Expect(exp.Check()).To(Succeed())
+	})
+
+	It("should rediscover entries evicted by the cache once the expansion is resumed", func() {
+		Expect(drainDiscoveries(exp)).To(HaveLen(2))
+		_, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+
+		cache.Invalidate("Device.Hosts.Host.")
+		Expect(drainDiscoveries(exp)).To(Equal([]string{
+			"Device.Hosts.Host.",
+			"Device.Hosts.Host.1.IPv4Address.",
+		}))
+		Expect(exp.Check()).To(Succeed())
+	})
+})
+
+var _ = Describe("Subtree Refresh", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		err := exp.Add("Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress", "Device.WiFi.SSID.*.SSID")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(drainDiscoveries(exp)).To(HaveLen(3))
+		Expect(exp.Collect()).To(HaveLen(2))
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should rediscover only the refreshed subtree", func() {
+		// An AddObject created a second access point
+		exp.RefreshSubtree("Device.WiFi.AccessPoint")
+
+		Expect(exp.Summary().Paths).To(Equal(1))
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+		Expect(exp.Register([]string{path + "1", path + "2"})).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(ConsistOf(
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.",
+			"Device.WiFi.AccessPoint.2.AssociatedDevice.",
+		))
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.1.MACAddress",
+			"Device.WiFi.AccessPoint.2.AssociatedDevice.1.MACAddress",
+			"Device.WiFi.SSID.1.SSID",
+		}))
+		Expect(exp.Check()).To(Succeed())
+	})
+})
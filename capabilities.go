@@ -0,0 +1,242 @@
+package expander
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// DriverCapabilities describes what the driver talking to the device can do,
+// so the expander can plan its requests accordingly. The zero value describes
+// a driver issuing one plain GetParameterNames request at a time.
+type DriverCapabilities struct {
+	// SupportsNextLevelFalse reports that the driver can issue
+	// GetParameterNames with NextLevel=false. A subtree response registered
+	// for a discovery also resolves the discoveries nested below it, and
+	// WantsSubtree tells which discoveries benefit from it.
+	SupportsNextLevelFalse bool
+
+	// MaxEnvelopeBytes bounds the size of a SOAP envelope. Value queries are
+	// cut to fit. Zero means no limit.
+	MaxEnvelopeBytes int
+
+	// SupportsAlias reports that the device accepts alias-based addressing.
+	// Alias-addressed instances are expanded with BracketAliases unless
+	// another codec is set, and expanded paths use aliases where known.
+	SupportsAlias bool
+
+	// ParallelRequests is the number of discoveries Expand and Run issue
+	// concurrently. Zero or one issues them one at a time.
+	ParallelRequests int
+}
+
+// Estimated sizes of a GetParameterValues envelope, in bytes
+const (
+	envelopeBaseBytes    = 512
+	envelopePerNameBytes = 40
+)
+
+// WithDriverCapabilities adapts the expander to the capabilities of the driver.
+func WithDriverCapabilities(caps DriverCapabilities) Option {
+	return func(e *Expander) {
+		e.config.capabilities = caps
+		if caps.SupportsAlias {
+			if e.config.aliasCodec == nil {
+				e.config.aliasCodec = BracketAliases
+			}
+			e.config.aliasOutput = true
+		}
+	}
+}
+
+// Capabilities returns the driver capabilities the expander adapts to.
+func (e *Expander) Capabilities() DriverCapabilities {
+	return e.config.capabilities
+}
+
+// WantsSubtree reports whether a discovery path is better queried with
+// NextLevel=false, i.e. the driver supports it and patterns need further
+// discoveries below the path, which the subtree response then resolves.
+func (e *Expander) WantsSubtree(discoveryPath string) bool {
+	if !e.config.capabilities.SupportsNextLevelFalse {
+		return false
+	}
+
+	path := strings.Split(strings.TrimSuffix(discoveryPath, "."), ".")
+	for _, pattern := range e.patterns {
+		segments := strings.Split(e.treePath(pattern), ".")
+		if len(segments) <= len(path)+1 || !matchesPrefix(segments, path) {
+			continue
+		}
+		if slices.ContainsFunc(segments[len(path)+1:], isDiscoverySegment) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPrefix reports whether the first segments of a pattern match path
+func matchesPrefix(pattern, path []string) bool {
+	for i, segment := range path {
+		switch selector, _ := parseIndexSelector(pattern[i]); {
+		case pattern[i] == "**":
+			return true
+		case pattern[i] == "*":
+		case selector != nil:
+			if !selector.matches(segment) {
+				return false
+			}
+		case isGlobSegment(pattern[i]):
+			if !matchGlob(pattern[i], segment) {
+				return false
+			}
+		case pattern[i] != segment:
+			return false
+		}
+	}
+	return true
+}
+
+// isDiscoverySegment reports whether a pattern segment needs a discovery
+func isDiscoverySegment(segment string) bool {
+	if segment == "*" || segment == "**" || isGlobSegment(segment) {
+		return true
+	}
+	selector, _ := parseIndexSelector(segment)
+	return selector != nil
+}
+
+// WithLenientSubtrees tolerates noncompliant devices returning the entire
+// subtree from GetParameterNames with NextLevel=true. The instances of the
+// discovery path are always extracted from the deep names; with this option
+// the deeper levels received for free resolve the discoveries below it too,
+// as a NextLevel=false response does. Unlike with SupportsNextLevelFalse,
+// discoveries the response lists nothing for are still issued, since a
+// device that only sometimes answers with subtrees may have cut them short.
+func WithLenientSubtrees() Option {
+	return func(e *Expander) {
+		e.config.lenientSubtrees = true
+	}
+}
+
+// absorbSubtree resolves the pending discoveries nested below a discovery
+// path from a subtree response registered for it
+func (e *Expander) absorbSubtree(discoveryPath string, results []string) {
+	if !isSubtreeResponse(discoveryPath, results) {
+		return
+	}
+	switch {
+	case e.config.capabilities.SupportsNextLevelFalse:
+		e.resolveSubtree(discoveryPath, results, true)
+	case e.config.lenientSubtrees:
+		e.resolveSubtree(discoveryPath, results, false)
+	}
+}
+
+// resolveSubtree registers the names of a subtree response for every
+// pending discovery below its discovery path, level by level, until the
+// response resolves no more of them. Unless the response is complete,
+// discoveries it lists nothing for are left pending.
+func (e *Expander) resolveSubtree(discoveryPath string, results []string, complete bool) {
+	for progress := true; progress; {
+		progress = false
+		for _, pending := range slices.Clone(e.pendingDiscoveries) {
+			if pending == discoveryPath || !strings.HasPrefix(pending, discoveryPath) {
+				continue
+			}
+			var names []string
+			for _, name := range results {
+				if e.hasPathPrefix(name, pending) {
+					names = append(names, name)
+				}
+			}
+			if len(names) == 0 && !complete {
+				continue
+			}
+			if e.RegisterFor(pending, names) == nil {
+				progress = true
+			}
+		}
+	}
+}
+
+// isSubtreeResponse reports whether results list names more than one level
+// below the discovery path, as a NextLevel=false response does
+func isSubtreeResponse(discoveryPath string, results []string) bool {
+	for _, name := range results {
+		remainder, ok := strings.CutPrefix(name, discoveryPath)
+		if ok && strings.Contains(strings.TrimSuffix(remainder, "."), ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// fitEnvelope cuts a list of parameter names to fit the envelope size of
+// the driver
+func (e *Expander) fitEnvelope(names []string) []string {
+	limit := e.config.capabilities.MaxEnvelopeBytes
+	if limit <= 0 {
+		return names
+	}
+
+	size := envelopeBaseBytes
+	for i, name := range names {
+		size += len(name) + envelopePerNameBytes
+		if size > limit {
+			// Always make progress, even if a single name doesn't fit
+			return names[:max(i, 1)]
+		}
+	}
+	return names
+}
+
+// discoverParallel runs the discovery loop with up to n discoveries in
+// flight, dispatching the next pending discovery as soon as one is answered.
+// Answers are registered in completion order by the calling goroutine. On
+// failure no more discoveries are dispatched; the ones in flight are still
+// registered, failed ones are put back in front of the queue and the first
+// failure is returned. An aborted expansion fails with its error. Progress,
+// if set, is called after every registered discovery until a failure.
+func (e *Expander) discoverParallel(ctx context.Context, src DiscoverySource, n int, progress func() error) error {
+	type answer struct {
+		path     string
+		names    []string
+		attempts int
+		err      error
+	}
+
+	answers := make(chan answer, n)
+	inFlight := 0
+	var failed error
+	for {
+		for failed == nil && inFlight < n {
+			if err := ctx.Err(); err != nil {
+				failed = err
+				break
+			}
+			batch := e.NextBatch(1)
+			if len(batch) == 0 {
+				break
+			}
+			inFlight++
+			go func(path string) {
+				names, attempts, err := e.discover(ctx, src, path)
+				answers <- answer{path: path, names: names, attempts: attempts, err: err}
+			}(batch[0])
+		}
+		if inFlight == 0 {
+			return failed
+		}
+
+		a := <-answers
+		inFlight--
+		err := e.registerAnswer(a.path, a.names, a.attempts, a.err)
+		if err == nil && failed == nil && progress != nil {
+			err = progress()
+		}
+		if err != nil && failed == nil {
+			failed = err
+		}
+	}
+}
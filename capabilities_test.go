@@ -0,0 +1,141 @@
+package expander_test
+
+import (
+	"context"
+	"sync/atomic"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Driver Capabilities", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+		exp = nil
+	})
+
+	It("should resolve nested discoveries from a subtree response", func() {
+		exp = expander.Get(expander.WithDriverCapabilities(expander.DriverCapabilities{SupportsNextLevelFalse: true}))
+		Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress", "Device.WiFi.SSID.*.SSID")).To(Succeed())
+
+		Expect(exp.WantsSubtree("Device.Hosts.Host.")).To(BeTrue())
+		Expect(exp.WantsSubtree("Device.WiFi.SSID.")).To(BeFalse())
+
+		Expect(exp.RegisterFor("Device.Hosts.Host.", []string{
+			"Device.Hosts.Host.1.",
+			"Device.Hosts.Host.1.IPv4Address.",
+			"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+			"Device.Hosts.Host.1.IPv4Address.2.IPAddress",
+			"Device.Hosts.Host.2.",
+			"Device.Hosts.Host.2.IPv4Address.",
+		})).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.WiFi.SSID."}))
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+			"Device.Hosts.Host.1.IPv4Address.2.IPAddress",
+			"Device.WiFi.SSID.1.SSID",
+		}))
+	})
+
+	It("should expand and render aliases when the device supports them", func() {
+		exp = expander.Get(expander.WithDriverCapabilities(expander.DriverCapabilities{SupportsAlias: true}))
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+		Expect(exp.RegisterAliasValues(map[string]string{"Device.WiFi.AccessPoint.1.Alias": "main"})).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "[guest]."})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.[guest].Enable",
+			"Device.WiFi.AccessPoint.[main].Enable",
+		}))
+	})
+
+	It("should cut value queries to the envelope size", func() {
+		exp = expander.Get(expander.WithDriverCapabilities(expander.DriverCapabilities{MaxEnvelopeBytes: 600}))
+		err := exp.AddFiltered("Device.WiFi.AccessPoint.*.Status", expander.Filter{Param: "Enable", Equals: "true"})
+		Expect(err).NotTo(HaveOccurred())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "2.", path + "3."})).To(Succeed())
+		_, _ = exp.Next()
+
+		Expect(exp.NextValueQuery()).To(HaveLen(1))
+	})
+
+	It("should issue discoveries in parallel", func() {
+		var inFlight, peak atomic.Int32
+		release := make(chan struct{})
+		device := fakeDevice("Device.WiFi.SSID.1.SSID", "Device.Hosts.Host.1.IPAddress", "Device.IP.Interface.1.Enable")
+		fetch := func(ctx context.Context, path string) ([]string, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			if n == 3 {
+				close(release)
+			}
+			<-release
+			return device(ctx, path)
+		}
+
+		exp = expander.Get(expander.WithDriverCapabilities(expander.DriverCapabilities{ParallelRequests: 3}))
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID", "Device.Hosts.Host.*.IPAddress", "Device.IP.Interface.*.Enable")).To(Succeed())
+
+		paths, err := exp.Expand(context.Background(), fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(3))
+		Expect(peak.Load()).To(Equal(int32(3)))
+	})
+
+	Context("with lenient subtrees", func() {
+		response := []string{
+			"Device.Hosts.Host.1.",
+			"Device.Hosts.Host.1.IPv4Address.",
+			"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+			"Device.Hosts.Host.1.IPv4Address.2.IPAddress",
+			"Device.Hosts.Host.3.IPv4Address.1.IPAddress",
+		}
+
+		It("should extract the instances from deep names by default", func() {
+			exp = expander.Get()
+			Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress")).To(Succeed())
+
+			exp.Next()
+			Expect(exp.Register(response)).To(Succeed())
+			Expect(drainDiscoveries(exp)).To(Equal([]string{
+				"Device.Hosts.Host.1.IPv4Address.",
+				"Device.Hosts.Host.3.IPv4Address.",
+			}))
+		})
+
+		It("should resolve the deeper levels a NextLevel=true response lists", func() {
+			exp = expander.Get(expander.WithLenientSubtrees())
+			Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress", "Device.Hosts.Host.*.PhysAddress")).To(Succeed())
+			Expect(exp.WantsSubtree("Device.Hosts.Host.")).To(BeFalse())
+
+			exp.Next()
+			Expect(exp.Register(append(response, "Device.Hosts.Host.2."))).To(Succeed())
+
+			// Host 2 is listed without anything below it, so it is still discovered
+			Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.Hosts.Host.2.IPv4Address."}))
+			Expect(exp.Collect()).To(Equal([]string{
+				"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+				"Device.Hosts.Host.1.IPv4Address.2.IPAddress",
+				"Device.Hosts.Host.1.PhysAddress",
+				"Device.Hosts.Host.2.IPv4Address.1.IPAddress",
+				"Device.Hosts.Host.2.PhysAddress",
+				"Device.Hosts.Host.3.IPv4Address.1.IPAddress",
+				"Device.Hosts.Host.3.PhysAddress",
+			}))
+		})
+	})
+})
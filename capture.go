@@ -0,0 +1,103 @@
+package expander
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Binding is an expanded path along with the instances captured by the
+// named wildcards of its pattern, e.g. {"ap": "2", "sta": "5"} for
+// "Device.WiFi.AccessPoint.2.AssociatedDevice.5.SignalStrength" expanded from
+// "Device.WiFi.AccessPoint.{ap}.AssociatedDevice.{sta}.SignalStrength".
+type Binding struct {
+	Pattern  string
+	Path     string
+	Captures map[string]string
+}
+
+// CollectBindings returns every expanded path with the captures of its
+// pattern, ordered by path, then by the order in which patterns were added.
+// A path matched by several patterns appears once per pattern; paths of
+// patterns without named wildcards have no captures.
+// This should be called after Next() returns false.
+func (e *Expander) CollectBindings() ([]Binding, error) {
+	if err := e.ensureComplete(); err != nil {
+		return nil, err
+	}
+
+	var bindings []Binding
+	for _, pattern := range e.patterns {
+		names := captureNames(pattern)
+		for _, path := range e.visiblePaths(e.patternPaths[pattern]) {
+			binding := Binding{Pattern: pattern, Path: path, Captures: map[string]string{}}
+			if values, ok := matchPattern(pattern, path); ok && len(values) == len(names) {
+				for i, name := range names {
+					if name != "" {
+						binding.Captures[name] = values[i]
+					}
+				}
+			}
+			bindings = append(bindings, binding)
+		}
+	}
+
+	sort.SliceStable(bindings, func(i, j int) bool {
+		return bindings[i].Path < bindings[j].Path
+	})
+	return bindings, nil
+}
+
+// captureName returns the name of a named wildcard segment such as "{ap}"
+func captureName(segment string) (string, bool) {
+	if len(segment) < 3 || segment[0] != '{' || segment[len(segment)-1] != '}' {
+		return "", false
+	}
+	return segment[1 : len(segment)-1], true
+}
+
+// captureNames returns the capture name of every wildcard of a pattern, in
+// order, with an empty name for anonymous wildcards
+func captureNames(pattern string) []string {
+	var names []string
+	for _, segment := range splitSegments(pattern) {
+		if name, ok := captureName(segment); ok {
+			names = append(names, name)
+		} else if isBindingSegment(segment) {
+			names = append(names, "")
+		}
+	}
+	return names
+}
+
+// isBindingSegment reports whether matchPattern binds a pattern segment
+func isBindingSegment(segment string) bool {
+	if segment == "*" || isSearchSegment(segment) {
+		return true
+	}
+	if _, ok := captureName(segment); ok {
+		return true
+	}
+	selector, _ := parseIndexSelector(segment)
+	return selector != nil
+}
+
+// checkCaptures rejects malformed and repeated capture names
+func checkCaptures(path string) error {
+	var seen []string
+	for _, segment := range splitSegments(path) {
+		if !strings.ContainsAny(segment, "{}") || isSearchSegment(segment) {
+			continue
+		}
+		name, ok := captureName(segment)
+		if !ok || strings.ContainsAny(name, "{}*[]") {
+			return fmt.Errorf("%w: malformed capture %q in %s", ErrInvalidPath, segment, path)
+		}
+		if slices.Contains(seen, name) {
+			return fmt.Errorf("%w: capture %q repeated in %s", ErrInvalidPath, name, path)
+		}
+		seen = append(seen, name)
+	}
+	return nil
+}
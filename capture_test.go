@@ -0,0 +1,114 @@
+package expander_test
+
+import (
+	"context"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Named Captures", func() {
+	const pattern = "Device.WiFi.AccessPoint.{ap}.AssociatedDevice.{sta}.SignalStrength"
+
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should expand named wildcards and report their captures", func() {
+		Expect(exp.Add(pattern, "Device.DeviceInfo.UpTime")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+		Expect(exp.Register([]string{path + "2."})).To(Succeed())
+		path, _ = exp.Next()
+		Expect(exp.Register([]string{path + "5.", path + "7."})).To(Succeed())
+
+		bindings, err := exp.CollectBindings()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bindings).To(Equal([]expander.Binding{
+			{Pattern: "Device.DeviceInfo.UpTime", Path: "Device.DeviceInfo.UpTime", Captures: map[string]string{}},
+			{
+				Pattern:  pattern,
+				Path:     "Device.WiFi.AccessPoint.2.AssociatedDevice.5.SignalStrength",
+				Captures: map[string]string{"ap": "2", "sta": "5"},
+			},
+			{
+				Pattern:  pattern,
+				Path:     "Device.WiFi.AccessPoint.2.AssociatedDevice.7.SignalStrength",
+				Captures: map[string]string{"ap": "2", "sta": "7"},
+			},
+		}))
+	})
+
+	It("should share discoveries with anonymous wildcards", func() {
+		Expect(exp.Add(pattern, "Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress")).To(Succeed())
+		Expect(drainDiscoveries(exp)).To(HaveLen(2))
+
+		bindings, err := exp.CollectBindings()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bindings).To(HaveLen(2))
+		Expect(bindings[0].Captures).To(BeEmpty())
+		Expect(bindings[1].Captures).To(Equal(map[string]string{"ap": "1", "sta": "1"}))
+	})
+
+	It("should correlate values of named wildcard patterns", func() {
+		Expect(exp.Add(pattern)).To(Succeed())
+		rows := exp.Correlate(map[string]string{"Device.WiFi.AccessPoint.3.AssociatedDevice.1.SignalStrength": "-60"})
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].Bindings).To(Equal([]string{"3", "1"}))
+	})
+
+	DescribeTable("malformed captures",
+		func(path string) {
+			Expect(exp.Add(path)).To(MatchError(expander.ErrInvalidPath))
+		},
+		Entry("empty name", "Device.WiFi.AccessPoint.{}.Enable"),
+		Entry("unterminated", "Device.WiFi.AccessPoint.{ap.Enable"),
+		Entry("repeated name", "Device.WiFi.AccessPoint.{x}.AssociatedDevice.{x}.Active"),
+	)
+
+	DescribeTable("captures of each pattern shape",
+		func(pattern string, captures map[string]map[string]string) {
+			exp := expander.Get(expander.WithGlobSegments())
+			defer expander.Release(exp)
+			Expect(exp.Add(pattern)).To(Succeed())
+			_, err := exp.Expand(context.Background(), nextLevelDevice(
+				"Device.WiFi.AccessPoint.1.Enable",
+				"Device.WiFi.AccessPoint.2.AssociatedDevice.5.MACAddress",
+				"Device.Hosts.Host.3.MACAddress",
+				"Device.Services.VoiceService.1.Enable",
+				"Device.Services.X_ACME_Service.4.Enable",
+			))
+			Expect(err).NotTo(HaveOccurred())
+
+			bindings, err := exp.CollectBindings()
+			Expect(err).NotTo(HaveOccurred())
+			collected := make(map[string]map[string]string)
+			for _, binding := range bindings {
+				collected[binding.Path] = binding.Captures
+			}
+			Expect(collected).To(Equal(captures))
+		},
+		Entry("trailing capture", "Device.WiFi.AccessPoint.{ap}", map[string]map[string]string{
+			"Device.WiFi.AccessPoint.1.": {"ap": "1"},
+			"Device.WiFi.AccessPoint.2.": {"ap": "2"},
+		}),
+		Entry("capture below **", "Device.**.Host.{h}.MACAddress", map[string]map[string]string{
+			"Device.Hosts.Host.3.MACAddress": {"h": "3"},
+		}),
+		Entry("capture above **", "Device.WiFi.AccessPoint.{ap}.**.MACAddress", map[string]map[string]string{
+			"Device.WiFi.AccessPoint.2.AssociatedDevice.5.MACAddress": {"ap": "2"},
+		}),
+		Entry("capture below a glob", "Device.Services.*Service.{svc}.Enable", map[string]map[string]string{
+			"Device.Services.VoiceService.1.Enable":   {"svc": "1"},
+			"Device.Services.X_ACME_Service.4.Enable": {"svc": "4"},
+		}),
+	)
+})
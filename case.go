@@ -0,0 +1,74 @@
+package expander
+
+import "strings"
+
+// WithCaseInsensitive makes registration tolerate devices answering with
+// names in another case than requested, such as device.wifi.accesspoint.1.
+// for Device.WiFi.AccessPoint., and discovery paths and value names
+// registered in another case than handed out. Registered names are
+// rewritten to the case of the pattern they were requested for, so expanded
+// paths keep the case of the input patterns; child names matched by glob
+// segments and "**" keep the case of the device.
+func WithCaseInsensitive() Option {
+	return func(e *Expander) {
+		e.config.caseInsensitive = true
+	}
+}
+
+// canonicalDiscovery returns the outstanding or pending discovery path
+// equal to path but for case, or path itself
+func (e *Expander) canonicalDiscovery(path string) string {
+	if !e.config.caseInsensitive || e.outstanding[path] {
+		return path
+	}
+	for candidate := range e.outstanding {
+		if strings.EqualFold(candidate, path) {
+			return candidate
+		}
+	}
+	for _, candidate := range e.pendingDiscoveries {
+		if strings.EqualFold(candidate, path) {
+			return candidate
+		}
+	}
+	return path
+}
+
+// canonicalNames rewrites the names registered for a discovery path to
+// start with the discovery path as requested
+func (e *Expander) canonicalNames(discoveryPath string, names []string) []string {
+	if !e.config.caseInsensitive {
+		return names
+	}
+	canonical := make([]string, len(names))
+	for i, name := range names {
+		if e.hasPathPrefix(name, discoveryPath) {
+			name = discoveryPath + name[len(discoveryPath):]
+		}
+		canonical[i] = name
+	}
+	return canonical
+}
+
+// canonicalValueName returns the queried value name equal to name but for
+// case, or name itself
+func (e *Expander) canonicalValueName(name string) string {
+	if !e.config.caseInsensitive || e.valueQueries[name] {
+		return name
+	}
+	for candidate := range e.valueQueries {
+		if strings.EqualFold(candidate, name) {
+			return candidate
+		}
+	}
+	return name
+}
+
+// hasPathPrefix reports whether name starts with prefix, ignoring case with
+// WithCaseInsensitive
+func (e *Expander) hasPathPrefix(name, prefix string) bool {
+	if !e.config.caseInsensitive {
+		return strings.HasPrefix(name, prefix)
+	}
+	return len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)
+}
@@ -0,0 +1,61 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Case-Insensitive Matching", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should reject names in another case by default", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{"device.wifi.accesspoint.1."})).To(MatchError(expander.ErrRootMismatch))
+	})
+
+	It("should expand names in another case to the case of the pattern", func() {
+		exp = expander.Get(expander.WithCaseInsensitive())
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+		Expect(exp.Register([]string{
+			"device.wifi.accesspoint.1.",
+			"DEVICE.WIFI.ACCESSPOINT.2.",
+		})).To(Succeed())
+
+		Expect(exp.RegisterFor("device.wifi.accesspoint.1.associateddevice", []string{
+			"device.wifi.accesspoint.1.associateddevice.3.",
+		})).To(Succeed())
+		Expect(exp.RegisterFor("Device.WiFi.AccessPoint.2.AssociatedDevice.", nil)).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.AccessPoint.1.AssociatedDevice.3.MACAddress"}))
+	})
+
+	It("should register filter values in another case", func() {
+		exp = expander.Get(expander.WithCaseInsensitive())
+		Expect(exp.AddFiltered("Device.WiFi.SSID.*.SSID", expander.Filter{Param: "Enable", Equals: "true"})).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{"device.wifi.ssid.1.", "device.wifi.ssid.2."})).To(Succeed())
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+
+		Expect(exp.NextValueQuery()).To(ConsistOf("Device.WiFi.SSID.1.Enable", "Device.WiFi.SSID.2.Enable"))
+		Expect(exp.RegisterValues(map[string]string{
+			"device.wifi.ssid.1.enable": "true",
+			"DEVICE.WIFI.SSID.2.ENABLE": "false",
+		})).To(Succeed())
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+	})
+})
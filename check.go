@@ -0,0 +1,89 @@
+package expander
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCorruptState is returned by Check when the internal state of the
+// expander violates one of its invariants
+var ErrCorruptState = errors.New("expander state is corrupt")
+
+// Check verifies the internal invariants of the expander: the discovery
+// queue, the in-flight and resolved discoveries, the cache and the expanded
+// paths must agree with each other and with the added patterns. It walks the
+// path tree once, so its cost grows with the expansion like that of Collect;
+// a health endpoint of a long-lived manager should call it at a modest rate.
+// Violations are reported wrapping ErrCorruptState.
+func (e *Expander) Check() error {
+	for path := range e.processedDiscoveries {
+		if !strings.HasSuffix(path, ".") {
+			return corrupt("resolved discovery %s has no trailing dot", path)
+		}
+		if e.outstanding[path] {
+			return corrupt("resolved discovery %s is still outstanding", path)
+		}
+	}
+
+	// Unresolved queue entries must be reachable from the added patterns
+	reachable := make(map[string]bool)
+	e.walk(treeVisitor{
+		discover: func(path string) {
+			reachable[path] = true
+		},
+	})
+
+	// The walk only asks for discoveries missing from the cache
+	for path := range e.processedDiscoveries {
+		if reachable[path] {
+			return corrupt("resolved discovery %s is missing from the cache", path)
+		}
+	}
+
+	queued := make(map[string]bool, len(e.pendingDiscoveries))
+	for _, path := range e.pendingDiscoveries {
+		if queued[path] {
+			return corrupt("discovery %s is queued twice", path)
+		}
+		queued[path] = true
+		if !e.queued[path] {
+			return corrupt("discovery %s is queued but not recorded as queued", path)
+		}
+
+		if e.outstanding[path] {
+			return corrupt("discovery %s is both queued and outstanding", path)
+		}
+		if !e.processedDiscoveries[path] && !reachable[path] {
+			return corrupt("queued discovery %s is not reachable from any pattern", path)
+		}
+	}
+
+	if len(e.queued) != len(queued) {
+		return corrupt("%d discoveries recorded as queued, %d queued", len(e.queued), len(queued))
+	}
+
+	if e.lastDiscoveryPath != "" && !e.outstanding[e.lastDiscoveryPath] {
+		return corrupt("last discovery %s is not outstanding", e.lastDiscoveryPath)
+	}
+
+	if e.isComplete && len(e.outstanding) > 0 {
+		return corrupt("expansion is complete with %d discoveries outstanding", len(e.outstanding))
+	}
+
+	if len(e.expandedSet) != len(e.expandedPaths) {
+		return corrupt("%d expanded paths recorded, %d distinct", len(e.expandedPaths), len(e.expandedSet))
+	}
+	for _, path := range e.expandedPaths {
+		if !e.expandedSet[path] {
+			return corrupt("expanded path %s is not recorded as distinct", path)
+		}
+	}
+
+	return nil
+}
+
+// corrupt reports a violated invariant
+func corrupt(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrCorruptState, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,114 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Self Check", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should hold at every stage of an expansion", func() {
+		Expect(exp.Check()).To(Succeed())
+
+		err := exp.Add(
+			"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.SSID",
+			"InternetGatewayDevice.LANDevice.*.Hosts.Host.*.IPAddress",
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exp.Check()).To(Succeed())
+
+		_, _ = exp.Next()
+		Expect(exp.Check()).To(Succeed())
+
+		err = exp.Register([]string{"InternetGatewayDevice.LANDevice.1", "InternetGatewayDevice.LANDevice.2"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exp.Check()).To(Succeed())
+
+		batch := exp.NextBatch(2)
+		Expect(batch).To(HaveLen(2))
+		Expect(exp.Check()).To(Succeed())
+
+		for _, path := range batch {
+			Expect(exp.RegisterFor(path, []string{path + "1"})).To(Succeed())
+		}
+		Expect(exp.Check()).To(Succeed())
+
+		for {
+			path, hasMore := exp.Next()
+			if !hasMore {
+				break
+			}
+			Expect(exp.Register([]string{path + "1"})).To(Succeed())
+		}
+		Expect(exp.Check()).To(Succeed())
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(4))
+		Expect(exp.Check()).To(Succeed())
+	})
+
+	It("should hold after preloading and adding patterns late", func() {
+		err := exp.Add("Device.WiFi.SSID.*.SSID")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = exp.Preload("", map[string][]int{"Device.WiFi.SSID.": {1, 2}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exp.Check()).To(Succeed())
+
+		_, err = exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = exp.Add("Device.WiFi.SSID.*.Stats.*.Value")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exp.Check()).To(Succeed())
+	})
+
+	It("should hold while a bounded cache evicts discoveries", func() {
+		expander.Release(exp)
+		exp = expander.Get(expander.WithCachePolicy(expander.CachePolicy{MaxEntries: 1}))
+		Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress")).To(Succeed())
+
+		expand := func() {
+			for {
+				path, hasMore := exp.Next()
+				if !hasMore {
+					break
+				}
+				Expect(exp.Register([]string{path + "1."})).To(Succeed())
+				Expect(exp.Check()).To(Succeed())
+			}
+		}
+		expand()
+		Expect(exp.Collect()).To(HaveLen(1))
+
+		Expect(exp.Add("Device.Hosts.Host.*.HostName")).To(Succeed())
+		Expect(exp.Check()).To(Succeed())
+		expand()
+		Expect(exp.Collect()).To(HaveLen(2))
+		Expect(exp.Check()).To(Succeed())
+	})
+
+	It("should report resolved discoveries missing from the cache", func() {
+		err := exp.UnmarshalState([]byte(`{
+			"version": 2,
+			"patterns": ["Device.WiFi.SSID.*.SSID"],
+			"cache": {},
+			"pending": [],
+			"processed": ["Device.WiFi.SSID."],
+			"expanded": []
+		}`))
+		Expect(err).To(MatchError(expander.ErrCorruptState))
+		Expect(err).To(MatchError(ContainSubstring("Device.WiFi.SSID. is missing from the cache")))
+	})
+})
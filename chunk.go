@@ -0,0 +1,36 @@
+package expander
+
+import "context"
+
+// ExpandChunk runs at most n discoveries against fetch, for devices whose
+// trees can't be expanded within one short CWMP session. If the expansion
+// completes, the expanded paths are returned with a nil checkpoint. Otherwise the returned
+// checkpoint holds the rest of the expansion, as produced by MarshalState,
+// to resume in a later session by calling UnmarshalState with it on an
+// expander with the same options, and ExpandChunk again. Errors are
+// reported as in Expand.
+func (e *Expander) ExpandChunk(ctx context.Context, fetch DiscoveryFunc, n int) ([]string, []byte, error) {
+	for i := 0; ; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		path, hasMore := e.Next()
+		if !hasMore {
+			paths, err := e.Collect()
+			return paths, nil, err
+		}
+		if i == n {
+			// Not issued in this chunk, so not counted against the budget
+			e.requeueFront(path)
+			e.dispatched--
+
+			checkpoint, err := e.MarshalState()
+			return nil, checkpoint, err
+		}
+
+		if err := e.discoverOne(ctx, fetch, path); err != nil {
+			return nil, nil, err
+		}
+	}
+}
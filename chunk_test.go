@@ -0,0 +1,79 @@
+package expander_test
+
+import (
+	"context"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chunked Expansion", func() {
+	device := fakeDevice(
+		"Device.Hosts.Host.1.",
+		"Device.Hosts.Host.1.IPv4Address.1.",
+		"Device.Hosts.Host.2.",
+		"Device.Hosts.Host.2.IPv4Address.1.",
+		"Device.Hosts.Host.2.IPv4Address.2.",
+		"Device.Hosts.Host.3.",
+	)
+	const pattern = "Device.Hosts.Host.*.IPv4Address.*.IPAddress"
+
+	It("should expand across sessions from checkpoints", func() {
+		var (
+			paths      []string
+			checkpoint []byte
+			sessions   int
+		)
+		for paths == nil {
+			sessions++
+			exp := expander.Get()
+			if checkpoint == nil {
+				Expect(exp.Add(pattern)).To(Succeed())
+			} else {
+				Expect(exp.UnmarshalState(checkpoint)).To(Succeed())
+			}
+
+			var err error
+			paths, checkpoint, err = exp.ExpandChunk(context.Background(), device, 2)
+			Expect(err).NotTo(HaveOccurred())
+			expander.Release(exp)
+		}
+
+		Expect(sessions).To(Equal(2))
+		Expect(checkpoint).To(BeNil())
+		Expect(paths).To(Equal([]string{
+			"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+			"Device.Hosts.Host.2.IPv4Address.1.IPAddress",
+			"Device.Hosts.Host.2.IPv4Address.2.IPAddress",
+		}))
+	})
+
+	It("should return the paths when the expansion fits in a chunk", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		paths, checkpoint, err := exp.ExpandChunk(context.Background(), device, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoint).To(BeNil())
+		Expect(paths).To(HaveLen(3))
+	})
+
+	It("should checkpoint without issuing discoveries for an empty chunk", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		paths, checkpoint, err := exp.ExpandChunk(context.Background(), func(context.Context, string) ([]string, error) {
+			Fail("no discovery expected")
+			return nil, nil
+		}, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(BeNil())
+		Expect(checkpoint).NotTo(BeEmpty())
+
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.Hosts.Host."))
+	})
+})
@@ -0,0 +1,183 @@
+// Command tr069-expand runs the path expander outside of an ACS, for
+// debugging field captures.
+//
+// Usage:
+//
+//	tr069-expand replay [-aliases bracket|quoted|bare] [-glob] [-key file] transcript.json [patterns.yaml]
+//
+// replay re-runs a captured discovery session through the current library
+// version and reports the paths that differ from the recorded expansion,
+// prefixed with "+" when they are new and "-" when they are gone. The
+// patterns file is a YAML list of patterns; without it, the recorded
+// patterns are used. The exit status is 0 without differences, 1 with
+// differences and 2 on errors. With -key, the transcript must be signed
+// with expander.Sign under the key stored in the given file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Exit statuses
+const (
+	exitSame  = 0
+	exitDiff  = 1
+	exitError = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "replay" {
+		fmt.Fprintln(stderr, "usage: tr069-expand replay [flags] transcript.json [patterns.yaml]")
+		return exitError
+	}
+
+	if err := replay(args[1:], stdout, stderr); err != nil {
+		if errors.Is(err, errDifferent) {
+			return exitDiff
+		}
+		fmt.Fprintln(stderr, "tr069-expand:", err)
+		return exitError
+	}
+	return exitSame
+}
+
+// errDifferent reports that the replay differs from the recording
+var errDifferent = errors.New("expansion differs from the recording")
+
+// codecs maps the -aliases flag values to alias codecs
+var codecs = map[string]expander.AliasCodec{
+	"bracket": expander.BracketAliases,
+	"quoted":  expander.QuotedAliases,
+	"bare":    expander.BareAliases,
+}
+
+// replay implements the replay subcommand
+func replay(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	aliases := flags.String("aliases", "", "alias syntax of instance segments: bracket, quoted or bare")
+	glob := flags.Bool("glob", false, "match segments with embedded wildcards against child names")
+	keyFile := flags.String("key", "", "file holding the key the transcript is signed with")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return errors.New("replay takes a transcript and an optional patterns file")
+	}
+
+	var opts []expander.Option
+	if *aliases != "" {
+		codec, ok := codecs[*aliases]
+		if !ok {
+			return fmt.Errorf("unknown alias syntax %q", *aliases)
+		}
+		opts = append(opts, expander.WithAliasCodec(codec))
+	}
+	if *glob {
+		opts = append(opts, expander.WithGlobSegments())
+	}
+
+	var key []byte
+	if *keyFile != "" {
+		var err error
+		if key, err = os.ReadFile(*keyFile); err != nil {
+			return err
+		}
+	}
+
+	transcript, err := readTranscript(flags.Arg(0), key)
+	if err != nil {
+		return err
+	}
+
+	var patterns []string
+	if flags.NArg() == 2 {
+		if patterns, err = readPatterns(flags.Arg(1)); err != nil {
+			return err
+		}
+	}
+
+	paths, err := transcript.Replay(context.Background(), patterns, opts...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "replayed %d discoveries: %d paths, %d recorded\n",
+		len(transcript.Discoveries), len(paths), len(transcript.Expanded))
+
+	different := false
+	for _, path := range paths {
+		if !slices.Contains(transcript.Expanded, path) {
+			fmt.Fprintln(stdout, "+", path)
+			different = true
+		}
+	}
+	for _, path := range transcript.Expanded {
+		if !slices.Contains(paths, path) {
+			fmt.Fprintln(stdout, "-", path)
+			different = true
+		}
+	}
+
+	if different {
+		return errDifferent
+	}
+	return nil
+}
+
+// readTranscript loads a transcript recorded as JSON, verifying its
+// signature if a key is given
+func readTranscript(name string, key []byte) (expander.Transcript, error) {
+	var transcript expander.Transcript
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return transcript, err
+	}
+	if key != nil {
+		if data, err = expander.Verify(key, data); err != nil {
+			return transcript, fmt.Errorf("transcript %s: %w", name, err)
+		}
+	}
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return transcript, fmt.Errorf("failed to decode transcript %s: %w", name, err)
+	}
+	return transcript, nil
+}
+
+// readPatterns loads a YAML list of patterns
+func readPatterns(name string) ([]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode patterns %s: %w", name, err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("patterns %s: not a YAML list", name)
+	}
+
+	var patterns []string
+	if err := document.Content[0].Decode(&patterns); err != nil {
+		return nil, fmt.Errorf("failed to decode patterns %s: %w", name, err)
+	}
+	return patterns, nil
+}
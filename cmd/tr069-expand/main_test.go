@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "tr069-expand Suite")
+}
+
+const transcript = `{
+	"patterns": ["Device.WiFi.SSID.*.SSID"],
+	"discoveries": [{"path": "Device.WiFi.SSID.", "names": ["Device.WiFi.SSID.1.", "Device.WiFi.SSID.2."]}],
+	"expanded": ["Device.WiFi.SSID.1.SSID", "Device.WiFi.SSID.3.SSID"]
+}`
+
+var _ = Describe("Replay", func() {
+	var (
+		dir            string
+		stdout, stderr *bytes.Buffer
+	)
+
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		Expect(os.WriteFile(file, []byte(content), 0o644)).To(Succeed())
+		return file
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	})
+
+	It("should report differences from the recorded expansion", func() {
+		status := run([]string{"replay", write("transcript.json", transcript)}, stdout, stderr)
+		Expect(status).To(Equal(exitDiff))
+		Expect(stdout.String()).To(Equal("replayed 1 discoveries: 2 paths, 2 recorded\n" +
+			"+ Device.WiFi.SSID.2.SSID\n" +
+			"- Device.WiFi.SSID.3.SSID\n"))
+	})
+
+	It("should replay with the patterns of a YAML file", func() {
+		patterns := write("patterns.yaml", "---\n# wifi\n- \"Device.WiFi.SSID.*.Enable\"\n")
+		status := run([]string{"replay", write("transcript.json", transcript), patterns}, stdout, stderr)
+		Expect(status).To(Equal(exitDiff))
+		Expect(stdout.String()).To(ContainSubstring("+ Device.WiFi.SSID.1.Enable\n"))
+	})
+
+	It("should decode YAML flow lists and reject anything but a list", func() {
+		flow := write("flow.yaml", "[Device.WiFi.SSID.*.Enable, 'Device.WiFi.SSID.*.Name']\n")
+		Expect(run([]string{"replay", write("transcript.json", transcript), flow}, stdout, stderr)).To(Equal(exitDiff))
+		Expect(stdout.String()).To(ContainSubstring("+ Device.WiFi.SSID.1.Name\n"))
+
+		mapping := write("mapping.yaml", "patterns:\n  - Device.WiFi.SSID.*.Enable\n")
+		Expect(run([]string{"replay", write("transcript.json", transcript), mapping}, stdout, stderr)).To(Equal(exitError))
+		Expect(stderr.String()).To(ContainSubstring("not a YAML list"))
+	})
+
+	It("should verify signed transcripts", func() {
+		key := write("key", "secret")
+		signed := write("signed.json", string(expander.Sign([]byte("secret"), []byte(transcript))))
+		Expect(run([]string{"replay", "-key", key, signed}, stdout, stderr)).To(Equal(exitDiff))
+
+		forged := write("forged.json", string(expander.Sign([]byte("other"), []byte(transcript))))
+		Expect(run([]string{"replay", "-key", key, forged}, stdout, stderr)).To(Equal(exitError))
+		Expect(stderr.String()).To(ContainSubstring("signature"))
+	})
+
+	It("should fail on usage and input errors", func() {
+		Expect(run(nil, stdout, stderr)).To(Equal(exitError))
+		Expect(run([]string{"replay", filepath.Join(dir, "missing.json")}, stdout, stderr)).To(Equal(exitError))
+		Expect(run([]string{"replay", "-aliases", "curly", write("t.json", transcript)}, stdout, stderr)).To(Equal(exitError))
+		Expect(stderr.String()).To(ContainSubstring(`unknown alias syntax "curly"`))
+	})
+})
@@ -0,0 +1,109 @@
+package expander
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Compact releases the memory an expander accumulated over a long life
+// without discarding its discoveries: maps and slices are reallocated to fit
+// their contents, and strings are copied and deduplicated so they no longer
+// pin the device responses they were cut from. It is meant for expanders
+// kept per device for weeks; the expansion state is unchanged.
+func (e *Expander) Compact() {
+	in := interner{}
+
+	compacted := make(mapCache, len(e.localCache))
+	for path, indices := range e.localCache {
+		compacted[in.intern(path)] = slices.Clip(slices.Clone(indices))
+	}
+	e.localCache = compacted
+	switch cache := e.cache.(type) {
+	case mapCache:
+		e.cache = compacted
+	case pinnedCache:
+		e.cache = pinnedCache{Cache: cache.Cache, pinned: compacted}
+	}
+
+	e.aliases = in.lists(e.aliases)
+	e.names = in.lists(e.names)
+	e.patternPaths = in.lists(e.patternPaths)
+	e.owners = in.lists(e.owners)
+
+	e.processedDiscoveries = internKeys(in, e.processedDiscoveries)
+	e.outstanding = internKeys(in, e.outstanding)
+	e.expandedSet = internKeys(in, e.expandedSet)
+	e.writable = internKeys(in, e.writable)
+	e.valueQueries = internKeys(in, e.valueQueries)
+	e.missingValues = internKeys(in, e.missingValues)
+	e.responseSizes = internKeys(in, e.responseSizes)
+	e.faults = internKeys(in, e.faults)
+	e.skipped = internKeys(in, e.skipped)
+	e.malformed = internKeys(in, e.malformed)
+	e.blockedHits = internKeys(in, e.blockedHits)
+	e.priorities = internKeys(in, e.priorities)
+	e.discoveryPriorities = internKeys(in, e.discoveryPriorities)
+	e.prioritized = nil
+
+	e.pendingDiscoveries = in.list(e.pendingDiscoveries)
+	e.queued = internKeys(in, e.queued)
+	e.expandedPaths = in.list(e.expandedPaths)
+	e.patterns = in.list(e.patterns)
+	e.warnings = slices.Clip(slices.Clone(e.warnings))
+	e.values = maps.Clone(e.values)
+	e.provenance = maps.Clone(e.provenance)
+	e.filters = maps.Clone(e.filters)
+	e.searches = maps.Clone(e.searches)
+	e.aliasValues = maps.Clone(e.aliasValues)
+}
+
+// ResetStats clears the response sizes and warnings gathered so far, keeping
+// the discoveries and the expansion state.
+func (e *Expander) ResetStats() {
+	clear(e.responseSizes)
+	e.warnings = nil
+}
+
+// interner copies strings once and hands out the same copy for equal strings
+type interner map[string]string
+
+func (in interner) intern(s string) string {
+	if interned, ok := in[s]; ok {
+		return interned
+	}
+	interned := strings.Clone(s)
+	in[interned] = interned
+	return interned
+}
+
+// list returns an interned copy of a slice of strings, sized to fit
+func (in interner) list(list []string) []string {
+	compacted := make([]string, len(list))
+	for i, s := range list {
+		compacted[i] = in.intern(s)
+	}
+	return compacted
+}
+
+// lists returns an interned copy of a map of string slices, sized to fit
+func (in interner) lists(m map[string][]string) map[string][]string {
+	compacted := make(map[string][]string, len(m))
+	for key, list := range m {
+		if list == nil {
+			compacted[in.intern(key)] = nil
+			continue
+		}
+		compacted[in.intern(key)] = in.list(list)
+	}
+	return compacted
+}
+
+// internKeys returns a copy of a map with interned keys, sized to fit
+func internKeys[V any](in interner, m map[string]V) map[string]V {
+	compacted := make(map[string]V, len(m))
+	for key, value := range m {
+		compacted[in.intern(key)] = value
+	}
+	return compacted
+}
@@ -0,0 +1,45 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compaction", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get(expander.WithResponseLimit(1, expander.ResponseFlag))
+		Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "2."})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should keep the expansion state intact", func() {
+		exp.Compact()
+		Expect(exp.Check()).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(HaveLen(2))
+		exp.Compact()
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+			"Device.Hosts.Host.2.IPv4Address.1.IPAddress",
+		}))
+		Expect(exp.Check()).To(Succeed())
+	})
+
+	It("should keep discoveries when resetting statistics", func() {
+		Expect(exp.Warnings()).To(HaveLen(1))
+
+		exp.ResetStats()
+		Expect(exp.Warnings()).To(BeEmpty())
+		Expect(exp.ResponseSizes()).To(BeEmpty())
+		Expect(exp.Summary().Discoveries).To(Equal(1))
+	})
+})
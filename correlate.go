@@ -0,0 +1,139 @@
+package expander
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BoundValue is a single parameter value joined back to the pattern that
+// matched it. Bindings holds the instance selected at each wildcard of the
+// pattern, in order.
+type BoundValue struct {
+	Pattern  string
+	Bindings []string
+	Path     string
+	Value    string
+}
+
+// Correlate joins a GetParameterValues response back to the added patterns.
+// Each value produces one row per pattern matching its path; values not
+// matched by any pattern are dropped. Rows are ordered by path, then by the
+// order in which patterns were added.
+func (e *Expander) Correlate(values map[string]string) []BoundValue {
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var rows []BoundValue
+	for _, path := range paths {
+		for _, pattern := range e.patterns {
+			bindings, ok := matchPattern(pattern, path)
+			if !ok {
+				continue
+			}
+			rows = append(rows, BoundValue{
+				Pattern:  pattern,
+				Bindings: bindings,
+				Path:     path,
+				Value:    values[path],
+			})
+		}
+	}
+	return rows
+}
+
+// Record is a single object instance with one field per leaf parameter,
+// keyed by the parameter name relative to the instance.
+type Record struct {
+	Object   string
+	Bindings []string
+	Fields   map[string]string
+}
+
+// Pivot correlates values and folds those under the object pattern group
+// (e.g. "Device.WiFi.AccessPoint.*.") into one record per matched instance.
+// The group may contain any segment patterns accept; where "**" lets it match
+// several objects along a path, the shallowest one holds the value. Records
+// are ordered by their bindings.
+func (e *Expander) Pivot(values map[string]string, group string) []Record {
+	byObject := make(map[string]*Record)
+	var records []*Record
+	for _, row := range e.Correlate(values) {
+		object, bindings, ok := groupObject(group, row.Path)
+		if !ok {
+			continue
+		}
+
+		record, exists := byObject[object]
+		if !exists {
+			record = &Record{
+				Object:   object,
+				Bindings: bindings,
+				Fields:   make(map[string]string),
+			}
+			byObject[object] = record
+			records = append(records, record)
+		}
+
+		// An instance object path matched by a trailing wildcard only
+		// yields its record
+		if field := strings.TrimPrefix(row.Path, object); field != "" {
+			record.Fields[field] = row.Value
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return compareBindings(records[i].Bindings, records[j].Bindings) < 0
+	})
+
+	result := make([]Record, len(records))
+	for i, record := range records {
+		result[i] = *record
+	}
+	return result
+}
+
+// groupObject returns the shallowest object above or at path that the group
+// pattern matches, along with the bindings of the group
+func groupObject(group, path string) (string, []string, bool) {
+	group = strings.TrimSuffix(group, ".")
+	for end := strings.IndexByte(path, '.'); end != -1; {
+		if bindings, ok := matchPattern(group, path[:end]); ok {
+			return path[:end+1], bindings, true
+		}
+		next := strings.IndexByte(path[end+1:], '.')
+		if next == -1 {
+			break
+		}
+		end += next + 1
+	}
+	return "", nil, false
+}
+
+// splitAfterSegments splits a path after its first n dot-terminated segments
+func splitAfterSegments(path string, n int) (string, string) {
+	offset := 0
+	for range n {
+		offset += strings.IndexByte(path[offset:], '.') + 1
+	}
+	return path[:offset], path[offset:]
+}
+
+// compareBindings orders bindings numerically where possible, lexically otherwise
+func compareBindings(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(a[i])
+		y, errY := strconv.Atoi(b[i])
+		if errX == nil && errY == nil {
+			return x - y
+		}
+		return strings.Compare(a[i], b[i])
+	}
+	return len(a) - len(b)
+}
@@ -0,0 +1,145 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Value Correlation", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should join values to patterns and bindings", func() {
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.*.AssociatedDevice.*.SignalStrength",
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.DeviceInfo.UpTime",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		rows := exp.Correlate(map[string]string{
+			"Device.WiFi.AccessPoint.2.AssociatedDevice.5.SignalStrength": "-61",
+			"Device.WiFi.AccessPoint.1.Enable":                            "true",
+			"Device.DeviceInfo.UpTime":                                    "3600",
+			"Device.DeviceInfo.SerialNumber":                              "ABC",
+		})
+		Expect(rows).To(Equal([]expander.BoundValue{
+			{Pattern: "Device.DeviceInfo.UpTime", Path: "Device.DeviceInfo.UpTime", Value: "3600"},
+			{Pattern: "Device.WiFi.AccessPoint.*.Enable", Bindings: []string{"1"}, Path: "Device.WiFi.AccessPoint.1.Enable", Value: "true"},
+			{
+				Pattern:  "Device.WiFi.AccessPoint.*.AssociatedDevice.*.SignalStrength",
+				Bindings: []string{"2", "5"},
+				Path:     "Device.WiFi.AccessPoint.2.AssociatedDevice.5.SignalStrength",
+				Value:    "-61",
+			},
+		}))
+	})
+
+	It("should match values below partial path patterns", func() {
+		err := exp.Add("Device.WiFi.SSID.*.")
+		Expect(err).NotTo(HaveOccurred())
+
+		rows := exp.Correlate(map[string]string{
+			"Device.WiFi.SSID.3.SSID":  "home",
+			"Device.WiFi.SSID.Enable":  "true",
+			"Device.WiFi.Radio.1.Name": "wlan0",
+		})
+		Expect(rows).To(Equal([]expander.BoundValue{
+			{Pattern: "Device.WiFi.SSID.*.", Bindings: []string{"3"}, Path: "Device.WiFi.SSID.3.SSID", Value: "home"},
+		}))
+	})
+
+	It("should join values of recursive and trailing wildcard patterns", func() {
+		Expect(exp.Add("Device.**.MACAddress", "Device.WiFi.AccessPoint.{ap}")).To(Succeed())
+
+		rows := exp.Correlate(map[string]string{
+			"Device.Hosts.Host.3.MACAddress":                          "00:11:22:33:44:55",
+			"Device.WiFi.AccessPoint.2.AssociatedDevice.5.MACAddress": "66:77:88:99:aa:bb",
+			"Device.WiFi.AccessPoint.2.":                              "",
+		})
+		Expect(rows).To(Equal([]expander.BoundValue{
+			{Pattern: "Device.**.MACAddress", Path: "Device.Hosts.Host.3.MACAddress", Value: "00:11:22:33:44:55"},
+			{Pattern: "Device.WiFi.AccessPoint.{ap}", Bindings: []string{"2"}, Path: "Device.WiFi.AccessPoint.2.", Value: ""},
+			{Pattern: "Device.**.MACAddress", Path: "Device.WiFi.AccessPoint.2.AssociatedDevice.5.MACAddress", Value: "66:77:88:99:aa:bb"},
+		}))
+	})
+})
+
+var _ = Describe("Row Pivoting", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.WiFi.AccessPoint.*.SSIDReference",
+			"Device.WiFi.AccessPoint.*.Security.ModeEnabled",
+			"Device.DeviceInfo.UpTime",
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should build one record per instance", func() {
+		records := exp.Pivot(map[string]string{
+			"Device.WiFi.AccessPoint.10.Enable":              "false",
+			"Device.WiFi.AccessPoint.2.Enable":               "true",
+			"Device.WiFi.AccessPoint.2.SSIDReference":        "Device.WiFi.SSID.2.",
+			"Device.WiFi.AccessPoint.2.Security.ModeEnabled": "WPA2-Personal",
+			"Device.WiFi.AccessPoint.2.Status":               "Enabled",
+			"Device.DeviceInfo.UpTime":                       "3600",
+		}, "Device.WiFi.AccessPoint.*")
+
+		Expect(records).To(Equal([]expander.Record{
+			{
+				Object:   "Device.WiFi.AccessPoint.2.",
+				Bindings: []string{"2"},
+				Fields: map[string]string{
+					"Enable":               "true",
+					"SSIDReference":        "Device.WiFi.SSID.2.",
+					"Security.ModeEnabled": "WPA2-Personal",
+				},
+			},
+			{
+				Object:   "Device.WiFi.AccessPoint.10.",
+				Bindings: []string{"10"},
+				Fields:   map[string]string{"Enable": "false"},
+			},
+		}))
+	})
+
+	It("should fold values of groups matching at varying depths", func() {
+		Expect(exp.Add("Device.**.MACAddress", "Device.**.Active")).To(Succeed())
+
+		records := exp.Pivot(map[string]string{
+			"Device.Hosts.Host.3.MACAddress":             "00:11:22:33:44:55",
+			"Device.Hosts.Host.3.Active":                 "true",
+			"Device.X_ACME_Mesh.Node.Host.7.MACAddress":  "66:77:88:99:aa:bb",
+			"Device.WiFi.AccessPoint.2.Stats.MACAddress": "cc:dd:ee:ff:00:11",
+		}, "Device.**.Host.*.")
+
+		Expect(records).To(Equal([]expander.Record{
+			{
+				Object:   "Device.Hosts.Host.3.",
+				Bindings: []string{"3"},
+				Fields:   map[string]string{"Active": "true", "MACAddress": "00:11:22:33:44:55"},
+			},
+			{
+				Object:   "Device.X_ACME_Mesh.Node.Host.7.",
+				Bindings: []string{"7"},
+				Fields:   map[string]string{"MACAddress": "66:77:88:99:aa:bb"},
+			},
+		}))
+	})
+})
@@ -0,0 +1,140 @@
+// Package cwmpsoap builds the CWMP SOAP messages of the discovery loop and
+// parses their responses: GetParameterNames requests for the discovery
+// paths returned by Next, and GetParameterNamesResponse messages into the
+// names given to Register or the infos given to RegisterInfo.
+package cwmpsoap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+)
+
+// Namespace is the CWMP namespace used in built messages
+const Namespace = "urn:dslforum-org:cwmp-1-0"
+
+// ErrNoResponse is returned when a message holds no GetParameterNamesResponse
+var ErrNoResponse = errors.New("no GetParameterNamesResponse in message")
+
+// Fault is a CWMP fault returned by the CPE instead of a response.
+type Fault struct {
+	// Code is the CWMP fault code, e.g. 9005 for an invalid parameter name
+	Code int
+
+	// String is the description of the fault given by the CPE
+	String string
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("cwmp fault %d: %s", f.Code, f.String)
+}
+
+// FaultCode returns the CWMP fault code, for expander.IsTransient.
+func (f *Fault) FaultCode() int {
+	return f.Code
+}
+
+// BuildGetParameterNames returns the SOAP body element of a
+// GetParameterNames request for a discovery path.
+func BuildGetParameterNames(path string, nextLevel bool) []byte {
+	var b bytes.Buffer
+	b.WriteString("<cwmp:GetParameterNames><ParameterPath>")
+	_ = xml.EscapeText(&b, []byte(path))
+	b.WriteString("</ParameterPath><NextLevel>")
+	b.WriteString(strconv.FormatBool(nextLevel))
+	b.WriteString("</NextLevel></cwmp:GetParameterNames>")
+	return b.Bytes()
+}
+
+// BuildEnvelope wraps a SOAP body element in an envelope carrying the
+// cwmp:ID header used to match the response to the request.
+func BuildEnvelope(id string, body []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"` +
+		` xmlns:soap-enc="http://schemas.xmlsoap.org/soap/encoding/"` +
+		` xmlns:xsd="http://www.w3.org/2001/XMLSchema"` +
+		` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"` +
+		` xmlns:cwmp="` + Namespace + `">`)
+	b.WriteString(`<soap-env:Header><cwmp:ID soap-env:mustUnderstand="1">`)
+	_ = xml.EscapeText(&b, []byte(id))
+	b.WriteString(`</cwmp:ID></soap-env:Header><soap-env:Body>`)
+	b.Write(body)
+	b.WriteString(`</soap-env:Body></soap-env:Envelope>`)
+	return b.Bytes()
+}
+
+// ParseGetParameterNamesResponse returns the parameter names of a
+// GetParameterNamesResponse envelope, to be registered with Register.
+// A fault returned by the CPE is reported as a *Fault.
+func ParseGetParameterNamesResponse(data []byte) ([]string, error) {
+	infos, err := ParseParameterInfoList(data)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	return names, nil
+}
+
+// ParseParameterInfoList returns the parameter names of a
+// GetParameterNamesResponse envelope along with their Writable flags, to be
+// registered with RegisterInfo. A fault returned by the CPE is reported as
+// a *Fault.
+func ParseParameterInfoList(data []byte) ([]expander.ParameterInfo, error) {
+	var envelope xmlEnvelope
+	if err := xml.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode GetParameterNamesResponse: %w", err)
+	}
+
+	body := envelope.Body
+	if body.Fault != nil {
+		detail := body.Fault.Detail
+		if detail.Code != 0 {
+			return nil, &Fault{Code: detail.Code, String: detail.String}
+		}
+		return nil, &Fault{String: body.Fault.String}
+	}
+	if body.Response == nil {
+		return nil, ErrNoResponse
+	}
+
+	structs := body.Response.Parameters
+	infos := make([]expander.ParameterInfo, len(structs))
+	for i, s := range structs {
+		name := strings.TrimSpace(s.Name)
+		writable, err := strconv.ParseBool(strings.TrimSpace(s.Writable))
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: malformed Writable %q", name, s.Writable)
+		}
+		infos[i] = expander.ParameterInfo{Name: name, Writable: writable}
+	}
+	return infos, nil
+}
+
+// xmlEnvelope is a SOAP envelope; elements are matched by local name so any
+// namespace prefix and CWMP version is accepted
+type xmlEnvelope struct {
+	Body struct {
+		Response *struct {
+			Parameters []struct {
+				Name     string `xml:"Name"`
+				Writable string `xml:"Writable"`
+			} `xml:"ParameterList>ParameterInfoStruct"`
+		} `xml:"GetParameterNamesResponse"`
+
+		Fault *struct {
+			String string `xml:"faultstring"`
+			Detail struct {
+				Code   int    `xml:"Fault>FaultCode"`
+				String string `xml:"Fault>FaultString"`
+			} `xml:"detail"`
+		} `xml:"Fault"`
+	} `xml:"Body"`
+}
@@ -0,0 +1,110 @@
+package cwmpsoap_test
+
+import (
+	"testing"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	"github.com/metalgrid/tr069-path-expander/v2/cwmpsoap"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCwmpsoap(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CWMP SOAP Suite")
+}
+
+const response = `<?xml version="1.0" encoding="UTF-8"?>
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://schemas.xmlsoap.org/soap/envelope/" xmlns:cwmp="urn:dslforum-org:cwmp-1-2">
+  <SOAP-ENV:Header><cwmp:ID SOAP-ENV:mustUnderstand="1">42</cwmp:ID></SOAP-ENV:Header>
+  <SOAP-ENV:Body>
+    <cwmp:GetParameterNamesResponse>
+      <ParameterList SOAP-ENC:arrayType="cwmp:ParameterInfoStruct[2]">
+        <ParameterInfoStruct>
+          <Name>Device.WiFi.SSID.1.</Name>
+          <Writable>1</Writable>
+        </ParameterInfoStruct>
+        <ParameterInfoStruct>
+          <Name> Device.WiFi.SSID.2. </Name>
+          <Writable>false</Writable>
+        </ParameterInfoStruct>
+      </ParameterList>
+    </cwmp:GetParameterNamesResponse>
+  </SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`
+
+const fault = `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:cwmp="urn:dslforum-org:cwmp-1-0">
+  <soap:Body>
+    <soap:Fault>
+      <faultcode>Client</faultcode>
+      <faultstring>CWMP fault</faultstring>
+      <detail>
+        <cwmp:Fault>
+          <FaultCode>9005</FaultCode>
+          <FaultString>Invalid parameter name</FaultString>
+        </cwmp:Fault>
+      </detail>
+    </soap:Fault>
+  </soap:Body>
+</soap:Envelope>`
+
+var _ = Describe("CWMP SOAP", func() {
+	It("should build GetParameterNames requests", func() {
+		Expect(string(cwmpsoap.BuildGetParameterNames("Device.WiFi.SSID.", true))).To(Equal(
+			"<cwmp:GetParameterNames><ParameterPath>Device.WiFi.SSID.</ParameterPath>" +
+				"<NextLevel>true</NextLevel></cwmp:GetParameterNames>"))
+		Expect(string(cwmpsoap.BuildGetParameterNames("Device.X_A&B.", false))).To(ContainSubstring(
+			"<ParameterPath>Device.X_A&amp;B.</ParameterPath><NextLevel>false</NextLevel>"))
+	})
+
+	It("should wrap bodies in an envelope", func() {
+		envelope := string(cwmpsoap.BuildEnvelope("7", cwmpsoap.BuildGetParameterNames("Device.", true)))
+		Expect(envelope).To(ContainSubstring(`xmlns:cwmp="urn:dslforum-org:cwmp-1-0"`))
+		Expect(envelope).To(ContainSubstring(`<cwmp:ID soap-env:mustUnderstand="1">7</cwmp:ID>`))
+		Expect(envelope).To(ContainSubstring(`<soap-env:Body><cwmp:GetParameterNames>`))
+	})
+
+	It("should parse responses", func() {
+		Expect(cwmpsoap.ParseGetParameterNamesResponse([]byte(response))).To(Equal([]string{
+			"Device.WiFi.SSID.1.",
+			"Device.WiFi.SSID.2.",
+		}))
+		Expect(cwmpsoap.ParseParameterInfoList([]byte(response))).To(Equal([]expander.ParameterInfo{
+			{Name: "Device.WiFi.SSID.1.", Writable: true},
+			{Name: "Device.WiFi.SSID.2.", Writable: false},
+		}))
+	})
+
+	It("should feed the discovery loop", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(string(cwmpsoap.BuildGetParameterNames(path, true))).To(ContainSubstring(path))
+		names, err := cwmpsoap.ParseGetParameterNamesResponse([]byte(response))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exp.Register(names)).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.SSID.1.SSID", "Device.WiFi.SSID.2.SSID"}))
+	})
+
+	It("should report faults and malformed messages", func() {
+		_, err := cwmpsoap.ParseGetParameterNamesResponse([]byte(fault))
+		var f *cwmpsoap.Fault
+		Expect(err).To(BeAssignableToTypeOf(f))
+		Expect(err).To(MatchError("cwmp fault 9005: Invalid parameter name"))
+		Expect(expander.IsTransient(err)).To(BeFalse())
+
+		_, err = cwmpsoap.ParseGetParameterNamesResponse([]byte(`<Envelope><Body><InformResponse/></Body></Envelope>`))
+		Expect(err).To(MatchError(cwmpsoap.ErrNoResponse))
+
+		_, err = cwmpsoap.ParseGetParameterNamesResponse([]byte(`<Envelope><Body>`))
+		Expect(err).To(HaveOccurred())
+
+		_, err = cwmpsoap.ParseParameterInfoList([]byte(`<Envelope><Body><GetParameterNamesResponse><ParameterList>` +
+			`<ParameterInfoStruct><Name>Device.</Name><Writable>yes</Writable></ParameterInfoStruct>` +
+			`</ParameterList></GetParameterNamesResponse></Body></Envelope>`))
+		Expect(err).To(MatchError(ContainSubstring("malformed Writable")))
+	})
+})
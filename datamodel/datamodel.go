@@ -0,0 +1,410 @@
+// Package datamodel loads Broadband Forum cwmp-datamodel XML documents, such
+// as tr-181-2-x-cwmp-full.xml, into an in-memory schema: which objects exist,
+// which of them are multi-instance tables and which parameters they hold.
+// The expander consults it to validate patterns and to spare discoveries the
+// data model already answers.
+//
+// Objects and parameters are read from the model of the document, including
+// those of the components it references. Objects declared with a base
+// attribute extend the object of that name. Imported documents are not
+// followed; use the "full" flavour of the Broadband Forum files.
+package datamodel
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Instance is the placeholder of the instance number in table object names
+const Instance = "{i}"
+
+// Unbounded is the MaxEntries of tables without an upper bound
+const Unbounded = -1
+
+var (
+	// ErrNoModel is returned for documents without a model
+	ErrNoModel = errors.New("data model document has no model")
+
+	// ErrUnknownComponent is returned when a model references an undefined component
+	ErrUnknownComponent = errors.New("unknown data model component")
+)
+
+// Schema is the data model described by a document.
+type Schema struct {
+	// Model is the name of the model, e.g. "Device:2.15"
+	Model string
+
+	objects map[string]*Object
+
+	// children maps object names to the names of their children
+	children map[string][]string
+}
+
+// Object is an object of the data model.
+type Object struct {
+	// Name is the full name of the object, with Instance in place of the
+	// instance numbers, e.g. "Device.WiFi.SSID.{i}."
+	Name string
+
+	// Writable reports whether instances can be added and deleted
+	Writable bool
+
+	// MinEntries and MaxEntries bound the number of instances; MaxEntries is
+	// Unbounded for tables without an upper bound
+	MinEntries int
+	MaxEntries int
+
+	// Parameters are the parameters of the object, in document order
+	Parameters []Parameter
+}
+
+// Parameter is a parameter of a data model object.
+type Parameter struct {
+	// Name is the name of the parameter relative to its object, e.g. "SSID"
+	Name string
+
+	// Type is the syntax of the parameter, e.g. "string" or "unsignedInt",
+	// or the name of the data type it refers to
+	Type string
+
+	// Writable reports whether the parameter can be set
+	Writable bool
+}
+
+// MultiInstance reports whether the object is a table, with instance numbers
+// below its name.
+func (o *Object) MultiInstance() bool {
+	return strings.HasSuffix(o.Name, "."+Instance+".")
+}
+
+// Fixed reports whether the table has a fixed number of instances.
+func (o *Object) Fixed() bool {
+	return o.MultiInstance() && o.MaxEntries != Unbounded && o.MinEntries == o.MaxEntries
+}
+
+// Parameter returns the parameter of the object with the given name.
+func (o *Object) Parameter(name string) (Parameter, bool) {
+	for _, p := range o.Parameters {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Parameter{}, false
+}
+
+// Load reads a cwmp-datamodel document.
+func Load(r io.Reader) (*Schema, error) {
+	var doc xmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode data model: %w", err)
+	}
+	if len(doc.Models) == 0 {
+		return nil, ErrNoModel
+	}
+
+	components := make(map[string]xmlComponent, len(doc.Components))
+	for _, c := range doc.Components {
+		components[c.Name] = c
+	}
+
+	model := doc.Models[0]
+	s := &Schema{Model: model.Name, objects: make(map[string]*Object)}
+	if err := s.addComponents(components, model.Components, "", 0); err != nil {
+		return nil, err
+	}
+	s.addObjects(model.Objects, "", nil)
+	s.index()
+	return s, nil
+}
+
+// LoadFile reads a cwmp-datamodel document from a file.
+func LoadFile(name string) (*Schema, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Object returns the object of a path. Instance numbers, wildcards, named
+// wildcards and index selectors all stand for the instance placeholder, so
+// "Device.WiFi.SSID.2.", "Device.WiFi.SSID.*." and "Device.WiFi.SSID.{i}."
+// name the same object. The trailing dot is optional.
+func (s *Schema) Object(path string) (*Object, bool) {
+	o, ok := s.objects[Canonical(strings.TrimSuffix(path, ".")+".")]
+	return o, ok
+}
+
+// Parameter returns a parameter by its full path along with its object.
+func (s *Schema) Parameter(path string) (*Object, Parameter, bool) {
+	i := strings.LastIndex(path, ".")
+	if i == -1 {
+		return nil, Parameter{}, false
+	}
+	o, ok := s.Object(path[:i+1])
+	if !ok {
+		return nil, Parameter{}, false
+	}
+	p, ok := o.Parameter(path[i+1:])
+	return o, p, ok
+}
+
+// Objects returns every object of the model, ordered by name.
+func (s *Schema) Objects() []*Object {
+	objects := make([]*Object, 0, len(s.objects))
+	for _, o := range s.objects {
+		objects = append(objects, o)
+	}
+	slices.SortFunc(objects, func(a, b *Object) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return objects
+}
+
+// Children returns the names of the children of an object, sorted:
+// parameter names, object names with a trailing dot and, for tables,
+// Instance followed by a dot. The root has path "". Objects that are only
+// implied by the names of their descendants have children too.
+func (s *Schema) Children(path string) []string {
+	if path != "" {
+		path = Canonical(strings.TrimSuffix(path, ".") + ".")
+	}
+	return slices.Clone(s.children[path])
+}
+
+// Suggest completes a path typed so far with the names the data model
+// allows next, e.g. "Device.WiFi.S" gives "Device.WiFi.SSID." and
+// "Device.WiFi.SSID.1." gives the parameters and objects of an SSID, as
+// full paths, sorted. Tables are suggested with a wildcard, e.g.
+// "Device.WiFi.SSID.*.", or with the instance typed so far. Instance
+// numbers, wildcards and the rest of the prefix are kept as typed.
+func (s *Schema) Suggest(prefix string) []string {
+	cut := strings.LastIndex(prefix, ".") + 1
+	parent, partial := prefix[:cut], prefix[cut:]
+
+	var suggestions []string
+	for _, child := range s.Children(parent) {
+		switch {
+		case child == Instance+"." && partial == "":
+			suggestions = append(suggestions, parent+"*.")
+		case child == Instance+".":
+			if isInstanceSegment(partial) {
+				suggestions = append(suggestions, prefix+".")
+			}
+		case strings.HasPrefix(child, partial):
+			suggestions = append(suggestions, parent+child)
+		}
+	}
+	return suggestions
+}
+
+// Canonical returns a path with the instance placeholder in place of every
+// instance number, wildcard, named wildcard and index selector.
+func Canonical(path string) string {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		if isInstanceSegment(segment) {
+			segments[i] = Instance
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// index records the children of every object and of the objects implied
+// by their names
+func (s *Schema) index() {
+	s.children = make(map[string][]string)
+	add := func(parent, child string) {
+		if !slices.Contains(s.children[parent], child) {
+			s.children[parent] = append(s.children[parent], child)
+		}
+	}
+
+	for name, o := range s.objects {
+		for _, p := range o.Parameters {
+			add(name, p.Name)
+		}
+		parent := ""
+		for _, segment := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+			add(parent, segment+".")
+			parent += segment + "."
+		}
+	}
+	for _, children := range s.children {
+		slices.Sort(children)
+	}
+}
+
+// isInstanceSegment reports whether a path segment addresses table instances
+func isInstanceSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if segment == "*" || segment[0] == '{' || segment[0] == '[' {
+		return true
+	}
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
+
+// maxComponentDepth bounds the nesting of component references
+const maxComponentDepth = 32
+
+// addComponents adds the objects of the referenced components below prefix
+func (s *Schema) addComponents(components map[string]xmlComponent, refs []xmlComponentRef, prefix string, depth int) error {
+	if depth > maxComponentDepth {
+		return fmt.Errorf("%w: components nested deeper than %d", ErrUnknownComponent, maxComponentDepth)
+	}
+	for _, ref := range refs {
+		c, ok := components[ref.Ref]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownComponent, ref.Ref)
+		}
+		path := prefix + ref.Path
+		if err := s.addComponents(components, c.Components, path, depth+1); err != nil {
+			return err
+		}
+		s.addObjects(c.Objects, path, nil)
+		if len(c.Parameters) > 0 {
+			s.addObjects([]xmlObject{{Base: ref.Path}}, prefix, c.Parameters)
+		}
+	}
+	return nil
+}
+
+// addObjects adds objects, and extends those named by a base attribute,
+// with extra parameters appended to each
+func (s *Schema) addObjects(objects []xmlObject, prefix string, extra []xmlParameter) {
+	for _, xo := range objects {
+		name := xo.Name
+		if name == "" {
+			name = xo.Base
+		}
+		name = Canonical(prefix + name)
+
+		o, ok := s.objects[name]
+		if !ok {
+			o = &Object{Name: name, MinEntries: 1, MaxEntries: 1}
+			s.objects[name] = o
+		}
+		if xo.Access != "" {
+			o.Writable = xo.Access != "readOnly"
+		}
+		if xo.MinEntries != "" {
+			o.MinEntries, _ = strconv.Atoi(xo.MinEntries)
+		}
+		switch xo.MaxEntries {
+		case "":
+		case "unbounded":
+			o.MaxEntries = Unbounded
+		default:
+			o.MaxEntries, _ = strconv.Atoi(xo.MaxEntries)
+		}
+
+		for _, xp := range slices.Concat(xo.Parameters, extra) {
+			o.addParameter(xp)
+		}
+	}
+}
+
+// addParameter adds a parameter to the object, or updates the one it is based on
+func (o *Object) addParameter(xp xmlParameter) {
+	name := xp.Name
+	if name == "" {
+		name = xp.Base
+	}
+
+	i := slices.IndexFunc(o.Parameters, func(p Parameter) bool { return p.Name == name })
+	if i == -1 {
+		o.Parameters = append(o.Parameters, Parameter{Name: name})
+		i = len(o.Parameters) - 1
+	}
+
+	p := &o.Parameters[i]
+	if xp.Access != "" {
+		p.Writable = xp.Access != "readOnly"
+	}
+	if syntax := xp.Syntax.name(); syntax != "" {
+		p.Type = syntax
+	}
+}
+
+// xmlDocument is the dm:document element
+type xmlDocument struct {
+	Components []xmlComponent `xml:"component"`
+	Models     []xmlModel     `xml:"model"`
+}
+
+// xmlComponent is a component definition
+type xmlComponent struct {
+	Name       string            `xml:"name,attr"`
+	Components []xmlComponentRef `xml:"component"`
+	Objects    []xmlObject       `xml:"object"`
+	Parameters []xmlParameter    `xml:"parameter"`
+}
+
+// xmlComponentRef is a reference to a component, placed below path
+type xmlComponentRef struct {
+	Ref  string `xml:"ref,attr"`
+	Path string `xml:"path,attr"`
+}
+
+// xmlModel is the model element
+type xmlModel struct {
+	Name       string            `xml:"name,attr"`
+	Components []xmlComponentRef `xml:"component"`
+	Objects    []xmlObject       `xml:"object"`
+}
+
+// xmlObject is an object definition, or an extension of the object named by Base
+type xmlObject struct {
+	Name       string         `xml:"name,attr"`
+	Base       string         `xml:"base,attr"`
+	Access     string         `xml:"access,attr"`
+	MinEntries string         `xml:"minEntries,attr"`
+	MaxEntries string         `xml:"maxEntries,attr"`
+	Parameters []xmlParameter `xml:"parameter"`
+}
+
+// xmlParameter is a parameter definition
+type xmlParameter struct {
+	Name   string    `xml:"name,attr"`
+	Base   string    `xml:"base,attr"`
+	Access string    `xml:"access,attr"`
+	Syntax xmlSyntax `xml:"syntax"`
+}
+
+// xmlSyntax is the syntax of a parameter: a type element, possibly
+// preceded by list and followed by default
+type xmlSyntax struct {
+	Elements []struct {
+		XMLName xml.Name
+		Ref     string `xml:"ref,attr"`
+		Base    string `xml:"base,attr"`
+	} `xml:",any"`
+}
+
+// name returns the type of the syntax, or the data type it refers to
+func (x xmlSyntax) name() string {
+	for _, element := range x.Elements {
+		switch element.XMLName.Local {
+		case "list", "default":
+			continue
+		case "dataType":
+			if element.Ref != "" {
+				return element.Ref
+			}
+			return element.Base
+		}
+		return element.XMLName.Local
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package datamodel_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-path-expander/v2/datamodel"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDatamodel(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Datamodel Suite")
+}
+
+const document = `<?xml version="1.0" encoding="UTF-8"?>
+<dm:document xmlns:dm="urn:broadband-forum-org:cwmp:datamodel-1-8" spec="urn:broadband-forum-org:tr-181-2-15-0-cwmp">
+  <component name="Stats">
+    <parameter name="BytesSent" access="readOnly"><syntax><unsignedLong/></syntax></parameter>
+  </component>
+  <component name="WiFi">
+    <object name="WiFi." access="readOnly" minEntries="1" maxEntries="1">
+      <parameter name="SSIDNumberOfEntries" access="readOnly"><syntax><unsignedInt/></syntax></parameter>
+    </object>
+    <object name="WiFi.SSID.{i}." access="readWrite" minEntries="0" maxEntries="unbounded">
+      <description>A table of SSIDs.</description>
+      <parameter name="Enable" access="readWrite"><syntax><boolean/><default type="object" value="false"/></syntax></parameter>
+      <parameter name="SSID" access="readWrite"><syntax><string><size maxLength="32"/></string></syntax></parameter>
+      <parameter name="MACAddress" access="readOnly"><syntax><dataType ref="MACAddress"/></syntax></parameter>
+    </object>
+    <object name="WiFi.SSID.{i}.Stats." access="readOnly" minEntries="1" maxEntries="1"/>
+    <component ref="Stats" path="WiFi.SSID.{i}.Stats."/>
+  </component>
+  <model name="Device:2.15">
+    <object name="Device." access="readOnly" minEntries="1" maxEntries="1">
+      <parameter name="RootDataModelVersion" access="readOnly"><syntax><string/></syntax></parameter>
+    </object>
+    <component ref="WiFi" path="Device."/>
+    <object name="Device.DeviceInfo.ProcessStatus.Process.{i}." access="readOnly" minEntries="4" maxEntries="4">
+      <parameter name="CPUTime" access="readOnly"><syntax><list/><unsignedInt/></syntax></parameter>
+    </object>
+    <object base="Device.WiFi.SSID.{i}." access="readWrite" minEntries="0" maxEntries="16">
+      <parameter base="MACAddress" access="readWrite"/>
+    </object>
+  </model>
+</dm:document>`
+
+var _ = Describe("Data Model", func() {
+	var schema *datamodel.Schema
+
+	BeforeEach(func() {
+		var err error
+		schema, err = datamodel.Load(strings.NewReader(document))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should load the objects of the model and its components", func() {
+		Expect(schema.Model).To(Equal("Device:2.15"))
+
+		var names []string
+		for _, o := range schema.Objects() {
+			names = append(names, o.Name)
+		}
+		Expect(names).To(Equal([]string{
+			"Device.",
+			"Device.DeviceInfo.ProcessStatus.Process.{i}.",
+			"Device.WiFi.",
+			"Device.WiFi.SSID.{i}.",
+			"Device.WiFi.SSID.{i}.Stats.",
+		}))
+	})
+
+	It("should describe tables", func() {
+		ssid, ok := schema.Object("Device.WiFi.SSID.*")
+		Expect(ok).To(BeTrue())
+		Expect(ssid.MultiInstance()).To(BeTrue())
+		Expect(ssid.Fixed()).To(BeFalse())
+		Expect(ssid.Writable).To(BeTrue())
+		Expect(ssid.MaxEntries).To(Equal(16))
+
+		process, _ := schema.Object("Device.DeviceInfo.ProcessStatus.Process.{i}.")
+		Expect(process.Fixed()).To(BeTrue())
+
+		wifi, _ := schema.Object("Device.WiFi.")
+		Expect(wifi.MultiInstance()).To(BeFalse())
+	})
+
+	It("should describe parameters", func() {
+		o, p, ok := schema.Parameter("Device.WiFi.SSID.2.SSID")
+		Expect(ok).To(BeTrue())
+		Expect(o.Name).To(Equal("Device.WiFi.SSID.{i}."))
+		Expect(p).To(Equal(datamodel.Parameter{Name: "SSID", Type: "string", Writable: true}))
+
+		_, p, _ = schema.Parameter("Device.WiFi.SSID.{ap}.MACAddress")
+		Expect(p).To(Equal(datamodel.Parameter{Name: "MACAddress", Type: "MACAddress", Writable: true}))
+
+		_, p, _ = schema.Parameter("Device.WiFi.SSID.1.Stats.BytesSent")
+		Expect(p.Type).To(Equal("unsignedLong"))
+
+		_, p, _ = schema.Parameter("Device.DeviceInfo.ProcessStatus.Process.1.CPUTime")
+		Expect(p.Type).To(Equal("unsignedInt"))
+
+		_, _, ok = schema.Parameter("Device.WiFi.SSID.1.SSIDName")
+		Expect(ok).To(BeFalse())
+		_, _, ok = schema.Parameter("Device.Hosts.HostNumberOfEntries")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should list the children of objects", func() {
+		Expect(schema.Children("")).To(Equal([]string{"Device."}))
+		Expect(schema.Children("Device.")).To(Equal([]string{"DeviceInfo.", "RootDataModelVersion", "WiFi."}))
+		Expect(schema.Children("Device.WiFi.SSID")).To(Equal([]string{"{i}."}))
+		Expect(schema.Children("Device.WiFi.SSID.3.")).To(Equal([]string{"Enable", "MACAddress", "SSID", "Stats."}))
+		Expect(schema.Children("Device.Hosts.")).To(BeEmpty())
+	})
+
+	It("should suggest completions", func() {
+		Expect(schema.Suggest("")).To(Equal([]string{"Device."}))
+		Expect(schema.Suggest("Dev")).To(Equal([]string{"Device."}))
+		Expect(schema.Suggest("Device.")).To(Equal([]string{
+			"Device.DeviceInfo.",
+			"Device.RootDataModelVersion",
+			"Device.WiFi.",
+		}))
+		Expect(schema.Suggest("Device.WiFi.SSID.")).To(Equal([]string{"Device.WiFi.SSID.*."}))
+		Expect(schema.Suggest("Device.WiFi.SSID.12")).To(Equal([]string{"Device.WiFi.SSID.12."}))
+		Expect(schema.Suggest("Device.WiFi.SSID.12.S")).To(Equal([]string{
+			"Device.WiFi.SSID.12.SSID",
+			"Device.WiFi.SSID.12.Stats.",
+		}))
+		Expect(schema.Suggest("Device.WiFi.SSID.{ap}.Stats.")).To(Equal([]string{"Device.WiFi.SSID.{ap}.Stats.BytesSent"}))
+		Expect(schema.Suggest("Device.WLAN")).To(BeEmpty())
+		Expect(schema.Suggest("Device.WiFi.SSIDs.")).To(BeEmpty())
+	})
+
+	It("should canonicalize instance segments", func() {
+		Expect(datamodel.Canonical("Device.WiFi.AccessPoint.[1-4].AssociatedDevice.*.")).
+			To(Equal("Device.WiFi.AccessPoint.{i}.AssociatedDevice.{i}."))
+	})
+
+	It("should load files", func() {
+		name := filepath.Join(GinkgoT().TempDir(), "tr-181.xml")
+		Expect(os.WriteFile(name, []byte(document), 0o644)).To(Succeed())
+
+		loaded, err := datamodel.LoadFile(name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.Objects()).To(HaveLen(5))
+	})
+
+	It("should reject documents without a model or with unknown components", func() {
+		_, err := datamodel.Load(strings.NewReader(`<dm:document xmlns:dm="urn:x"/>`))
+		Expect(err).To(MatchError(datamodel.ErrNoModel))
+
+		_, err = datamodel.Load(strings.NewReader(`<document><model name="Device:2.15"><component ref="Missing" path="Device."/></model></document>`))
+		Expect(err).To(MatchError(datamodel.ErrUnknownComponent))
+
+		_, err = datamodel.Load(strings.NewReader(`<document><model`))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,63 @@
+package expander
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Bind tags the expander with the identity of the device its cache belongs
+// to. Once bound, registrations and preloads tagged with another device are
+// refused with ErrDeviceMismatch. Binding again to the same device is a no-op.
+func (e *Expander) Bind(deviceID string) error {
+	if err := e.checkDevice(deviceID); err != nil {
+		return err
+	}
+	e.deviceID = deviceID
+	return nil
+}
+
+// DeviceID returns the device the expander is bound to, or "" if unbound.
+func (e *Expander) DeviceID() string {
+	return e.deviceID
+}
+
+// RegisterFrom behaves like Register but refuses results tagged with a device
+// other than the one the expander is bound to.
+func (e *Expander) RegisterFrom(deviceID string, results []string) error {
+	if err := e.checkDevice(deviceID); err != nil {
+		return err
+	}
+	return e.Register(results)
+}
+
+// Preload seeds the cache with previously discovered indices, keyed by
+// discovery path, so the corresponding discoveries are skipped. It is refused
+// if the expander is bound to a different device.
+func (e *Expander) Preload(deviceID string, discoveries map[string][]int) error {
+	if err := e.checkDevice(deviceID); err != nil {
+		return err
+	}
+
+	for path, indices := range discoveries {
+		if !strings.HasSuffix(path, ".") {
+			path += "."
+		}
+		cached := slices.Clone(indices)
+		slices.Sort(cached)
+		e.cache.Put(path, slices.Compact(cached))
+		e.processedDiscoveries[path] = true
+	}
+
+	e.isComplete = false
+	e.generateDiscoveryPaths("")
+	return nil
+}
+
+// checkDevice verifies that deviceID matches the bound device, if any
+func (e *Expander) checkDevice(deviceID string) error {
+	if e.deviceID != "" && e.deviceID != deviceID {
+		return fmt.Errorf("%w: bound to %q, got %q", ErrDeviceMismatch, e.deviceID, deviceID)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Device Binding", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		Expect(exp.Bind("00D09E-CPE-0001")).To(Succeed())
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should refuse rebinding to another device", func() {
+		Expect(exp.Bind("00D09E-CPE-0001")).To(Succeed())
+		Expect(exp.Bind("00D09E-CPE-0002")).To(MatchError(expander.ErrDeviceMismatch))
+		Expect(exp.DeviceID()).To(Equal("00D09E-CPE-0001"))
+	})
+
+	It("should refuse registrations from another device", func() {
+		_, _ = exp.Next()
+		err := exp.RegisterFrom("00D09E-CPE-0002", []string{"Device.WiFi.AccessPoint.1"})
+		Expect(err).To(MatchError(expander.ErrDeviceMismatch))
+
+		err = exp.RegisterFrom("00D09E-CPE-0001", []string{"Device.WiFi.AccessPoint.1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(ConsistOf("Device.WiFi.AccessPoint.1.Enable"))
+	})
+
+	It("should preload the cache for the bound device only", func() {
+		err := exp.Preload("00D09E-CPE-0002", map[string][]int{"Device.WiFi.AccessPoint.": {1}})
+		Expect(err).To(MatchError(expander.ErrDeviceMismatch))
+
+		err = exp.Preload("00D09E-CPE-0001", map[string][]int{"Device.WiFi.AccessPoint": {2, 1, 2}})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.2.Enable",
+		}))
+	})
+
+	It("should be unbound after a reset", func() {
+		exp.Reset()
+		Expect(exp.DeviceID()).To(BeEmpty())
+		Expect(exp.Bind("00D09E-CPE-0002")).To(Succeed())
+	})
+})
@@ -0,0 +1,81 @@
+package expander
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DeviceCache is a Cache holding the discoveries made against a single
+// device, safe for concurrent use. It can be shared by several expanders so
+// discoveries made by one job, e.g. monitoring, are reused by a later job,
+// e.g. provisioning, against the same CPE.
+type DeviceCache struct {
+	mu       sync.RWMutex
+	deviceID string
+	entries  map[string][]int
+}
+
+// NewDeviceCache creates an empty cache for the given device.
+func NewDeviceCache(deviceID string) *DeviceCache {
+	return &DeviceCache{
+		deviceID: deviceID,
+		entries:  make(map[string][]int),
+	}
+}
+
+// DeviceID returns the device the cache belongs to.
+func (c *DeviceCache) DeviceID() string {
+	return c.deviceID
+}
+
+// Get returns the indices cached for a discovery path.
+func (c *DeviceCache) Get(path string) ([]int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	indices, ok := c.entries[path]
+	return indices, ok
+}
+
+// Put stores the indices discovered for a discovery path.
+func (c *DeviceCache) Put(path string, indices []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[path] = slices.Clone(indices)
+}
+
+// Invalidate drops every entry whose path starts with prefix.
+func (c *DeviceCache) Invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		if strings.HasPrefix(path, prefix) {
+			delete(c.entries, path)
+		}
+	}
+}
+
+// Len returns the number of cached discovery paths.
+func (c *DeviceCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.entries)
+}
+
+// GetWithCache retrieves an expander from the default pool that stores its
+// discoveries in cache and is bound to the cache's device, then applies opts.
+func GetWithCache(cache *DeviceCache, opts ...Option) *Expander {
+	return defaultPool.Get(append([]Option{withDeviceCache(cache)}, opts...)...)
+}
+
+// withDeviceCache uses cache and binds the expander to its device
+func withDeviceCache(cache *DeviceCache) Option {
+	return func(e *Expander) {
+		e.useCache(cache)
+		e.deviceID = cache.deviceID
+	}
+}
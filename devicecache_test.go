@@ -0,0 +1,51 @@
+package expander_test
+
+import (
+	"sync"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Device Cache", func() {
+	var cache *expander.DeviceCache
+
+	BeforeEach(func() {
+		cache = expander.NewDeviceCache("CPE-0001")
+	})
+
+	It("should share discoveries between jobs against the same device", func() {
+		monitoring := expander.GetWithCache(cache)
+		Expect(monitoring.DeviceID()).To(Equal("CPE-0001"))
+		Expect(monitoring.Add("Device.WiFi.SSID.*.Stats.BytesSent")).To(Succeed())
+		Expect(drainDiscoveries(monitoring)).To(Equal([]string{"Device.WiFi.SSID."}))
+		expander.Release(monitoring)
+
+		provisioning := expander.GetWithCache(cache)
+		defer expander.Release(provisioning)
+		Expect(provisioning.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+		Expect(drainDiscoveries(provisioning)).To(BeEmpty())
+		Expect(provisioning.Collect()).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+
+		Expect(provisioning.RegisterFrom("CPE-0002", nil)).To(MatchError(expander.ErrDeviceMismatch))
+	})
+
+	It("should be safe for concurrent use", func() {
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cache.Put("Device.Hosts.Host.", []int{i})
+				cache.Get("Device.Hosts.Host.")
+				cache.Invalidate("Device.Ethernet.")
+			}()
+		}
+		wg.Wait()
+		Expect(cache.Len()).To(Equal(1))
+
+		cache.Invalidate("Device.")
+		Expect(cache.Len()).To(BeZero())
+	})
+})
@@ -0,0 +1,209 @@
+package expander
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DiscoverySource supplies the parameter names found under a discovery path,
+// typically by issuing a GetParameterNames call (NextLevel=true).
+type DiscoverySource interface {
+	Discover(ctx context.Context, path string) ([]string, error)
+}
+
+// ResultSink receives expanded paths as they are produced.
+type ResultSink interface {
+	Put(path string) error
+}
+
+// DiscoveryFunc is a function usable as a DiscoverySource.
+type DiscoveryFunc func(ctx context.Context, path string) ([]string, error)
+
+// Discover calls f(ctx, path).
+func (f DiscoveryFunc) Discover(ctx context.Context, path string) ([]string, error) {
+	return f(ctx, path)
+}
+
+// SinkFunc is a function usable as a ResultSink.
+type SinkFunc func(path string) error
+
+// Put calls f(path).
+func (f SinkFunc) Put(path string) error {
+	return f(path)
+}
+
+// SliceSink is a ResultSink collecting paths into a slice.
+type SliceSink struct {
+	Paths []string
+}
+
+// Put appends path to s.Paths.
+func (s *SliceSink) Put(path string) error {
+	s.Paths = append(s.Paths, path)
+	return nil
+}
+
+// DiscoveryError reports a discovery that failed, along with its path.
+type DiscoveryError struct {
+	Path string
+	Err  error
+
+	// Attempts is the number of times the discovery was issued, more than
+	// one if it was retried as configured with WithRetry
+	Attempts int
+}
+
+func (e *DiscoveryError) Error() string {
+	return fmt.Sprintf("discovery %s: %v", e.Path, e.Err)
+}
+
+func (e *DiscoveryError) Unwrap() error {
+	return e.Err
+}
+
+// Expand runs the Next/Register loop, calling fetch for each discovery path,
+// until the expansion completes, and returns the expanded paths.
+// Fetch and registration errors are returned as a *DiscoveryError; the failed
+// discovery is put back in front of the queue so Expand can be called again
+// to resume, except for a CWMP fault 9005, which prunes the branch as
+// RegisterFault does. An expansion aborted as set with WithMaxExpandedPaths
+// fails with ErrTooManyPaths instead. Context cancellation is checked before
+// every discovery.
+func (e *Expander) Expand(ctx context.Context, fetch DiscoveryFunc) ([]string, error) {
+	if err := e.discoverAll(ctx, fetch); err != nil {
+		return nil, err
+	}
+	return e.Collect()
+}
+
+// ExpandParallel runs the discovery loop against d with up to concurrency
+// discoveries in flight, dispatching independent pending discoveries, such
+// as the branches below each WANDevice instance, as soon as a slot frees
+// up, and returns the expanded paths. The expander is only touched by the
+// calling goroutine; d must be safe for concurrent use. Errors are reported
+// as in Expand.
+func (e *Expander) ExpandParallel(ctx context.Context, d Discoverer, concurrency int) ([]string, error) {
+	if err := e.discoverParallel(ctx, discoveryFunc(d), max(concurrency, 1), nil); err != nil {
+		return nil, err
+	}
+	return e.Collect()
+}
+
+// ExpandAll expands patterns in a single call for the common case of one
+// device session and a static pattern list: it takes an expander from the
+// default pool configured with opts, runs Expand with fetch and releases it.
+func ExpandAll(ctx context.Context, patterns []string, fetch DiscoveryFunc, opts ...Option) ([]string, error) {
+	exp := Get(opts...)
+	defer Release(exp)
+
+	if err := exp.Add(patterns...); err != nil {
+		return nil, err
+	}
+	return exp.Expand(ctx, fetch)
+}
+
+// ExpandAgainst expands the added patterns against a complete list of the
+// parameter names of a device, such as a stored full walk, instead of a
+// device session. Every discovery is answered from the dump, so the device
+// is reproduced as it was when the dump was taken. Patterns with filters or
+// search expressions need values a name dump lacks and fail as in Collect.
+func (e *Expander) ExpandAgainst(dump []string) ([]string, error) {
+	if !slices.IsSorted(dump) {
+		dump = slices.Sorted(slices.Values(dump))
+	}
+	return e.Expand(context.Background(), func(_ context.Context, path string) ([]string, error) {
+		start, _ := slices.BinarySearch(dump, path)
+		end := start
+		for end < len(dump) && strings.HasPrefix(dump[end], path) {
+			end++
+		}
+		return dump[start:end], nil
+	})
+}
+
+// Run pulls parameter names from src until the expansion completes, then
+// pushes every expanded path to sink. Errors are reported as in Expand; a
+// sink error stops the delivery and is returned as is.
+func (e *Expander) Run(ctx context.Context, src DiscoverySource, sink ResultSink) error {
+	if err := e.discoverAll(ctx, src); err != nil {
+		return err
+	}
+
+	paths, err := e.Collect()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := sink.Put(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discoverAll runs the Next/Register loop against src until no discovery is left
+func (e *Expander) discoverAll(ctx context.Context, src DiscoverySource) error {
+	return e.discoverEach(ctx, src, nil)
+}
+
+// discoverEach runs the Next/Register loop as discoverAll does, calling
+// progress, if set, after every registered discovery
+func (e *Expander) discoverEach(ctx context.Context, src DiscoverySource, progress func() error) error {
+	if n := e.config.capabilities.ParallelRequests; n > 1 {
+		return e.discoverParallel(ctx, src, n, progress)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		path, hasMore := e.Next()
+		if !hasMore {
+			return nil
+		}
+		if err := e.discoverOne(ctx, src, path); err != nil {
+			return err
+		}
+		if progress != nil {
+			if err := progress(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// discoverOne answers a discovery handed out by Next from src and registers
+// the answer.
+func (e *Expander) discoverOne(ctx context.Context, src DiscoverySource, path string) error {
+	names, attempts, err := e.discover(ctx, src, path)
+	return e.registerAnswer(path, names, attempts, err)
+}
+
+// registerAnswer registers the answer to a discovery, pruning the branch on
+// a CWMP fault 9005. On failure the discovery is put back in front of the
+// queue, unless the expansion was aborted, whose error is returned as is.
+func (e *Expander) registerAnswer(path string, names []string, attempts int, err error) error {
+	if code, ok := branchFault(err); ok {
+		err = e.RegisterFault(path, code)
+	} else if err == nil {
+		err = e.RegisterFor(path, names)
+	}
+	switch {
+	case err == nil:
+		return nil
+	case e.aborted != nil:
+		return e.aborted
+	}
+	e.requeueFront(path)
+	return &DiscoveryError{Path: path, Err: err, Attempts: attempts}
+}
+
+// requeueFront puts an unanswered discovery path back in front of the queue
+func (e *Expander) requeueFront(path string) {
+	e.enqueue(true, path)
+	delete(e.outstanding, path)
+	e.lastDiscoveryPath = ""
+}
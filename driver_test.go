@@ -0,0 +1,252 @@
+package expander_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeDevice answers discoveries from a fixed list of parameter names
+func fakeDevice(names ...string) expander.DiscoveryFunc {
+	return func(_ context.Context, path string) ([]string, error) {
+		var result []string
+		for _, name := range names {
+			if strings.HasPrefix(name, path) {
+				result = append(result, name)
+			}
+		}
+		return result, nil
+	}
+}
+
+var _ = Describe("Expand Driver", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		err := exp.Add("InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.Enable")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should run the discovery loop to completion", func() {
+		paths, err := exp.Expand(context.Background(), fakeDevice(
+			"InternetGatewayDevice.LANDevice.1.",
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.",
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.2.",
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.Enable",
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.2.Enable",
+		}))
+	})
+
+	It("should report the failing discovery path and allow resuming", func() {
+		timeout := errors.New("session timeout")
+		calls := 0
+		flaky := func(ctx context.Context, path string) ([]string, error) {
+			calls++
+			if calls == 2 {
+				return nil, timeout
+			}
+			return fakeDevice(
+				"InternetGatewayDevice.LANDevice.1.",
+				"InternetGatewayDevice.LANDevice.1.WLANConfiguration.3.",
+			)(ctx, path)
+		}
+
+		_, err := exp.Expand(context.Background(), flaky)
+		var discoveryErr *expander.DiscoveryError
+		Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+		Expect(discoveryErr.Path).To(Equal("InternetGatewayDevice.LANDevice.1.WLANConfiguration."))
+		Expect(err).To(MatchError(timeout))
+
+		paths, err := exp.Expand(context.Background(), flaky)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(ConsistOf("InternetGatewayDevice.LANDevice.1.WLANConfiguration.3.Enable"))
+	})
+
+	It("should honor context cancellation", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := exp.Expand(ctx, fakeDevice())
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
+
+var _ = Describe("One-Shot Expansion", func() {
+	It("should expand a pattern list in a single call", func() {
+		paths, err := expander.ExpandAll(context.Background(), []string{
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.DeviceInfo.UpTime",
+		}, fakeDevice("Device.WiFi.AccessPoint.1.", "Device.WiFi.AccessPoint.[guest]."),
+			expander.WithAliasCodec(expander.BracketAliases))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.DeviceInfo.UpTime",
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.[guest].Enable",
+		}))
+	})
+
+	It("should report invalid patterns", func() {
+		_, err := expander.ExpandAll(context.Background(), []string{""}, fakeDevice())
+		Expect(err).To(MatchError(expander.ErrInvalidPath))
+	})
+})
+
+var _ = Describe("Dump Expansion", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should expand against a full parameter dump", func() {
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress", "Device.Hosts.Host.*.HostName")).To(Succeed())
+
+		dump := []string{
+			"Device.WiFi.AccessPoint.2.AssociatedDevice.7.MACAddress",
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.10.AssociatedDevice.1.MACAddress",
+			"Device.DeviceInfo.UpTime",
+		}
+		Expect(exp.ExpandAgainst(dump)).To(Equal([]string{
+			"Device.WiFi.AccessPoint.10.AssociatedDevice.1.MACAddress",
+			"Device.WiFi.AccessPoint.2.AssociatedDevice.7.MACAddress",
+		}))
+		Expect(dump[0]).To(Equal("Device.WiFi.AccessPoint.2.AssociatedDevice.7.MACAddress"))
+	})
+
+	It("should fail when filter values are needed", func() {
+		Expect(exp.Add("Device.Hosts.Host.[Active==true].HostName")).To(Succeed())
+		_, err := exp.ExpandAgainst([]string{"Device.Hosts.Host.1.HostName"})
+		Expect(err).To(MatchError(ContainSubstring("filter values are needed")))
+	})
+})
+
+// discovererFunc is a function usable as a Discoverer
+type discovererFunc func(ctx context.Context, path string) ([]string, error)
+
+func (f discovererFunc) GetParameterNames(ctx context.Context, path string) ([]string, error) {
+	return f(ctx, path)
+}
+
+var _ = Describe("Parallel Expansion", func() {
+	var (
+		exp    *expander.Expander
+		device []string
+	)
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		Expect(exp.Add("InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.ExternalIPAddress")).To(Succeed())
+
+		device = nil
+		for _, wan := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+			device = append(device, "InternetGatewayDevice.WANDevice."+wan+".WANConnectionDevice.1.WANIPConnection.1.ExternalIPAddress")
+		}
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should keep up to the given number of discoveries in flight", func() {
+		var inFlight, peak atomic.Int32
+		d := discovererFunc(func(ctx context.Context, path string) ([]string, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for old := peak.Load(); n > old && !peak.CompareAndSwap(old, n); old = peak.Load() {
+			}
+			time.Sleep(5 * time.Millisecond)
+			return fakeDevice(device...)(ctx, path)
+		})
+
+		paths, err := exp.ExpandParallel(context.Background(), d, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(8))
+		Expect(peak.Load()).To(Equal(int32(4)))
+	})
+
+	It("should report a failed branch and resume", func() {
+		fail := errors.New("timeout")
+		d := discovererFunc(func(ctx context.Context, path string) ([]string, error) {
+			if path == "InternetGatewayDevice.WANDevice.5.WANConnectionDevice." && fail != nil {
+				return nil, fail
+			}
+			return fakeDevice(device...)(ctx, path)
+		})
+
+		_, err := exp.ExpandParallel(context.Background(), d, 3)
+		var discoveryErr *expander.DiscoveryError
+		Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+		Expect(discoveryErr.Path).To(Equal("InternetGatewayDevice.WANDevice.5.WANConnectionDevice."))
+
+		fail = nil
+		Expect(exp.ExpandParallel(context.Background(), d, 3)).To(HaveLen(8))
+	})
+})
+
+// staticSource is a DiscoverySource backed by a fixed list of parameter names
+type staticSource []string
+
+func (s staticSource) Discover(ctx context.Context, path string) ([]string, error) {
+	return fakeDevice(s...)(ctx, path)
+}
+
+var _ = Describe("Discovery Sources and Result Sinks", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should pull from a source and push to a sink", func() {
+		sink := &expander.SliceSink{}
+		err := exp.Run(context.Background(), staticSource{
+			"Device.WiFi.AccessPoint.1.",
+			"Device.WiFi.AccessPoint.2.",
+		}, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sink.Paths).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.2.Enable",
+		}))
+	})
+
+	It("should stop on sink errors", func() {
+		full := errors.New("sink full")
+		delivered := 0
+		err := exp.Run(context.Background(), staticSource{
+			"Device.WiFi.AccessPoint.1.",
+			"Device.WiFi.AccessPoint.2.",
+		}, expander.SinkFunc(func(string) error {
+			delivered++
+			return full
+		}))
+		Expect(err).To(MatchError(full))
+		Expect(delivered).To(Equal(1))
+	})
+})
@@ -0,0 +1,122 @@
+package expander
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NotifyInstanceAdded patches the cache with an instance created by
+// AddObject or reported by an autonomous notification, e.g.
+// "Device.WiFi.SSID.3.", instead of rediscovering its table. Discoveries
+// below the new instance are queued, and the expanded paths are updated
+// right away if nothing needs to be discovered. Tables that have not been
+// discovered yet are left alone.
+func (e *Expander) NotifyInstanceAdded(objectPath string) error {
+	table, index, alias, err := e.parseInstance(objectPath)
+	if err != nil {
+		return err
+	}
+
+	indices, cached := e.cache.Get(table)
+	if !cached {
+		return nil
+	}
+	if alias != "" {
+		if !slices.Contains(e.aliases[table], alias) {
+			e.aliases[table] = append(slices.Clone(e.aliases[table]), alias)
+			slices.Sort(e.aliases[table])
+		}
+	} else if !slices.Contains(indices, index) {
+		updated := append(slices.Clone(indices), index)
+		slices.Sort(updated)
+		e.cache.Put(table, updated)
+	}
+
+	e.settle()
+	return nil
+}
+
+// NotifyInstanceDeleted patches the cache for an instance removed by
+// DeleteObject or reported by an autonomous notification: the instance is
+// removed from its table and everything known below it is forgotten,
+// including its expanded paths.
+func (e *Expander) NotifyInstanceDeleted(objectPath string) error {
+	if !strings.HasSuffix(objectPath, ".") {
+		objectPath += "."
+	}
+	table, index, alias, err := e.parseInstance(objectPath)
+	if err != nil {
+		return err
+	}
+
+	if indices, cached := e.cache.Get(table); cached {
+		if alias != "" {
+			e.aliases[table] = slices.DeleteFunc(slices.Clone(e.aliases[table]), func(a string) bool {
+				return a == alias
+			})
+		} else {
+			e.cache.Put(table, slices.DeleteFunc(slices.Clone(indices), func(i int) bool {
+				return i == index
+			}))
+		}
+	}
+
+	// Forget everything discovered below the instance
+	e.cache.Invalidate(objectPath)
+	for path := range e.processedDiscoveries {
+		if strings.HasPrefix(path, objectPath) {
+			delete(e.processedDiscoveries, path)
+			delete(e.aliases, path)
+			delete(e.names, path)
+		}
+	}
+	e.pendingDiscoveries = slices.DeleteFunc(e.pendingDiscoveries, func(path string) bool {
+		if !strings.HasPrefix(path, objectPath) {
+			return false
+		}
+		delete(e.queued, path)
+		return true
+	})
+
+	e.settle()
+	return nil
+}
+
+// settle queues the discoveries reachable after a cache patch and, if there
+// are none, brings the expanded paths up to date
+func (e *Expander) settle() {
+	e.generateDiscoveryPaths("")
+	if len(e.pendingDiscoveries) > 0 || len(e.outstanding) > 0 {
+		e.isComplete = false
+		return
+	}
+	if e.isComplete {
+		e.generateExpandedPaths()
+	}
+}
+
+// parseInstance splits an object instance path into its table discovery
+// path and its index or alias
+func (e *Expander) parseInstance(objectPath string) (table string, index int, alias string, err error) {
+	if !strings.HasSuffix(objectPath, ".") {
+		objectPath += "."
+	}
+	trimmed := strings.TrimSuffix(objectPath, ".")
+	dot := strings.LastIndexByte(trimmed, '.')
+	if dot == -1 {
+		return "", 0, "", fmt.Errorf("%w: %s is not an object instance", ErrInvalidPath, objectPath)
+	}
+
+	table, segment := trimmed[:dot+1], trimmed[dot+1:]
+	if index, err := strconv.Atoi(segment); err == nil {
+		return table, index, "", nil
+	}
+	if e.config.aliasCodec != nil {
+		if alias, ok := e.config.aliasCodec.Decode(segment); ok {
+			return table, 0, alias, nil
+		}
+	}
+	return "", 0, "", fmt.Errorf("%w: %s is not an object instance", ErrInvalidPath, objectPath)
+}
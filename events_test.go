@@ -0,0 +1,61 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Instance Events", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get(expander.WithAliasCodec(expander.BracketAliases))
+		err := exp.Add("Device.WiFi.SSID.*.SSID", "Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(drainDiscoveries(exp)).To(HaveLen(3))
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.1.MACAddress",
+			"Device.WiFi.SSID.1.SSID",
+		}))
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should add instances without rediscovering their table", func() {
+		Expect(exp.NotifyInstanceAdded("Device.WiFi.SSID.3.")).To(Succeed())
+		Expect(exp.NotifyInstanceAdded("Device.WiFi.SSID.[guest]")).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.1.MACAddress",
+			"Device.WiFi.SSID.1.SSID",
+			"Device.WiFi.SSID.3.SSID",
+			"Device.WiFi.SSID.[guest].SSID",
+		}))
+	})
+
+	It("should queue discoveries below added instances", func() {
+		Expect(exp.NotifyInstanceAdded("Device.WiFi.AccessPoint.2.")).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.WiFi.AccessPoint.2.AssociatedDevice."}))
+		Expect(exp.Collect()).To(ContainElement("Device.WiFi.AccessPoint.2.AssociatedDevice.1.MACAddress"))
+	})
+
+	It("should forget deleted instances and everything below them", func() {
+		Expect(exp.NotifyInstanceDeleted("Device.WiFi.AccessPoint.1")).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+		Expect(exp.Check()).To(Succeed())
+
+		// Re-creating the instance requires rediscovering below it
+		Expect(exp.NotifyInstanceAdded("Device.WiFi.AccessPoint.1.")).To(Succeed())
+		Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.WiFi.AccessPoint.1.AssociatedDevice."}))
+	})
+
+	It("should reject paths that are not object instances", func() {
+		Expect(exp.NotifyInstanceAdded("Device.WiFi.SSID.")).To(MatchError(expander.ErrInvalidPath))
+		Expect(exp.NotifyInstanceDeleted("Device")).To(MatchError(expander.ErrInvalidPath))
+	})
+})
@@ -5,6 +5,7 @@ package expander
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,14 +19,34 @@ type Expander struct {
 	paths pathTree
 
 	// cache stores discovered indices for each discovery path to avoid redundant requests
-	cache map[string][]int
+	cache Cache
+
+	// localCache is the private cache used unless WithCache is given
+	localCache mapCache
+
+	// aliases stores alias-addressed instances for each discovery path
+	aliases map[string][]string
+
+	// aliasValues maps the instance indices of each table to the values of
+	// their Alias parameter, as registered with RegisterAliasValues
+	aliasValues map[string]map[int]string
+
+	// names stores the child names registered for each discovery path when
+	// glob segments are enabled
+	names map[string][]string
 
 	// pendingDiscoveries is a queue of discovery paths that need to be processed
 	pendingDiscoveries []string
 
+	// queued holds the paths in pendingDiscoveries, for membership tests
+	queued map[string]bool
+
 	// processedDiscoveries tracks which discovery paths have been processed
 	processedDiscoveries map[string]bool
 
+	// outstanding tracks discovery paths handed out but not registered yet
+	outstanding map[string]bool
+
 	// expandedPaths stores the final fully expanded parameter paths
 	expandedPaths []string
 
@@ -37,6 +58,86 @@ type Expander struct {
 
 	// lastDiscoveryPath tracks the last discovery path returned by Next()
 	lastDiscoveryPath string
+
+	// patterns stores the distinct patterns in the order they were added
+	patterns []string
+
+	// patternPaths maps each pattern to the paths it produced on the last expansion
+	patternPaths map[string][]string
+
+	// config holds the optional behavior set through Options
+	config config
+
+	// deviceID identifies the device the cache belongs to, if bound
+	deviceID string
+
+	// pool is the pool the expander is returned to on Release
+	pool *Pool
+
+	// provenance records which callers added each pattern
+	provenance map[string][]Attribution
+
+	// writable records the Writable flag of names registered with RegisterInfo
+	writable map[string]bool
+
+	// owners maps each owner label to the patterns it added
+	owners map[string][]string
+
+	// responseSizes records the number of names registered per discovery path
+	responseSizes map[string]int
+
+	// warnings records the data dropped or altered while expanding
+	warnings []Warning
+
+	// filters maps each pattern added only with AddFiltered to its filters,
+	// any of which admits an instance
+	filters map[string][]Filter
+
+	// values stores the parameter values registered to evaluate filters
+	values map[string]string
+
+	// valueQueries tracks the values handed out by NextValueQuery but not
+	// registered yet
+	valueQueries map[string]bool
+
+	// missingValues tracks the values queried but missing from the response
+	missingValues map[string]bool
+
+	// searches maps each pattern with USP search expressions to their conditions
+	searches map[string][]condition
+
+	// faults maps the discovery paths pruned with RegisterFault to their
+	// fault code
+	faults map[string]int
+
+	// skipped tracks the discovery paths abandoned with Skip
+	skipped map[string]bool
+
+	// malformed records the error the results of a discovery were last
+	// rejected with, until results are accepted
+	malformed map[string]error
+
+	// aborted is the error the expansion was aborted with, if any
+	aborted error
+
+	// dispatched counts the discoveries handed out, for the discovery budget
+	dispatched int
+
+	// projected is the running projection of the expanded paths checked
+	// against WithMaxExpandedPaths, or -1 until it is computed again
+	projected int
+
+	// blockedHits records the blocked subtree prefixes the patterns reached
+	blockedHits map[string]bool
+
+	// priorities maps each pattern added with AddPrioritized to its priority
+	priorities map[string]int
+
+	// prioritized lists the split tree paths of the patterns along with
+	// their priority, and discoveryPriorities the priority of each discovery
+	// path, both computed on demand until patterns or priorities change
+	prioritized         []prioritizedPattern
+	discoveryPriorities map[string]int
 }
 
 // pathNode represents a node in the path tree structure
@@ -44,82 +145,304 @@ type pathNode struct {
 	segment    string
 	children   map[string]*pathNode
 	isWildcard bool
+	isGlob     bool
 	isLeaf     bool
+
+	// order lists the segments of the children in the order they were
+	// added, so walks visit them in a deterministic order
+	order []string
+
+	// isRecursive marks "**", standing for any number of levels
+	isRecursive bool
+
+	// selector constrains the instances of a wildcard position, e.g. "[1-8]"
+	selector *indexSelector
+
+	// pattern is the original pattern terminating at this node (leaves only)
+	pattern string
 }
 
 // pathTree represents the tree structure of all paths to be expanded
 type pathTree struct {
 	root *pathNode
+
+	// matchesNames records whether a pattern matches child names, with "**"
+	// or a trailing "*"
+	matchesNames bool
 }
 
 // Common errors returned by the expander
 var (
-	ErrEmptyPath       = errors.New("empty path")
-	ErrInvalidPath     = errors.New("invalid path format")
-	ErrEmptyResults    = errors.New("results cannot be empty")
-	ErrNoDiscovery     = errors.New("no discovery path available")
-	ErrAlreadyComplete = errors.New("expansion is already complete")
+	ErrEmptyPath        = errors.New("empty path")
+	ErrInvalidPath      = errors.New("invalid path format")
+	ErrEmptyResults     = errors.New("results cannot be empty")
+	ErrNoDiscovery      = errors.New("no discovery path available")
+	ErrAlreadyComplete  = errors.New("expansion is already complete")
+	ErrDeviceMismatch   = errors.New("expander is bound to another device")
+	ErrNotOutstanding   = errors.New("discovery path is not outstanding")
+	ErrRootMismatch     = errors.New("registered name belongs to another data model root")
+	ErrEmbeddedWildcard = errors.New("wildcard must span a whole segment")
 )
 
+// RootMismatchError reports registered parameter names rooted under a
+// different data model than the discovery path, which usually means results
+// from the wrong device or session were wired in.
+type RootMismatchError struct {
+	DiscoveryPath string
+	Name          string
+}
+
+func (e *RootMismatchError) Error() string {
+	return fmt.Sprintf("%v: %s registered for %s", ErrRootMismatch, e.Name, e.DiscoveryPath)
+}
+
+func (e *RootMismatchError) Unwrap() error {
+	return ErrRootMismatch
+}
+
 // Add adds one or more paths for expansion. Paths can be added at any time,
 // and the expander will reuse its cache for common ancestors.
 // Duplicate paths are automatically handled and won't appear twice in the output.
 func (e *Expander) Add(paths ...string) error {
+	return e.add(e.attribute(""), paths)
+}
+
+// add adds paths on behalf of the attributed caller
+func (e *Expander) add(attribution Attribution, paths []string) error {
 	if len(paths) == 0 {
 		return ErrEmptyPath
 	}
 
+	if err := e.checkQuota(attribution.Label, paths); err != nil {
+		return err
+	}
+
 	// Mark as not complete since we're adding new paths
 	e.isComplete = false
+	e.projected = -1
 
 	for _, path := range paths {
 		if path == "" {
 			return ErrInvalidPath
 		}
+		// Only path segments count towards the limits, not the search
+		// expressions and capture names standing for instances
+		normalized := normalizePattern(path)
+		if len(normalized) > MaxPathLen || strings.Count(strings.TrimSuffix(normalized, "."), ".") >= MaxPathDepth {
+			return fmt.Errorf("path %s exceeds protocol limits: %w", path, ErrInvalidPath)
+		}
+
+		if err := checkWildcards(normalized, e.config.globSegments); err != nil {
+			return err
+		}
+		if err := e.checkWildcardDepth(path, normalized); err != nil {
+			return err
+		}
+		if err := checkCaptures(path); err != nil {
+			return err
+		}
+		if err := e.addSearches(path); err != nil {
+			return err
+		}
 
 		// Add path to the tree structure
-		if err := e.paths.addPath(path); err != nil {
+		if err := e.paths.addPathAs(e.treePath(path), path); err != nil {
 			return fmt.Errorf("failed to add path %s: %w", path, err)
 		}
+
+		if _, known := e.patternPaths[path]; !known {
+			e.patterns = append(e.patterns, path)
+			e.patternPaths[path] = nil
+			e.forgetPriorities()
+		}
+		// Added without a filter, the pattern expands to every instance
+		delete(e.filters, path)
+		e.recordProvenance(path, attribution)
+		e.recordOwner(attribution.Label, path)
 	}
 
 	// Generate discovery paths for newly added paths
-	e.generateDiscoveryPaths()
+	e.generateDiscoveryPaths("")
 
 	return nil
 }
 
 // Next returns the next discovery path that needs to be queried via GetParameterNames.
-// Returns (path, true) if there's a path to discover, ("", false) if complete.
-// The returned path includes a trailing dot for partial path discovery.
+// Returns (path, true) if there's a path to discover, ("", false) if there is
+// nothing left to dispatch. The returned path includes a trailing dot for
+// partial path discovery. The expansion completes once nothing is left to
+// dispatch and every dispatched discovery has been registered.
+//
+// The order of the discovery paths is deterministic: the same patterns added
+// in the same order, the same options and the same registrations always
+// yield the same sequence. Discoveries are handed out breadth-first, as
+// configured with WithTraversalOrder, following the order in which the
+// patterns were added and ascending instance numbers, unless reordered by
+// pattern priorities or WithShuffledDiscoveries with a fixed seed.
 func (e *Expander) Next() (string, bool) {
+	if e.aborted != nil {
+		return "", false
+	}
+	if path, ok := e.popPending(); ok {
+		// Store last discovery path and return it
+		e.lastDiscoveryPath = path
+		return path, true
+	}
+
+	e.finish()
+	return "", false
+}
+
+// NextBatch returns up to max discovery paths that can be queried
+// concurrently. Results may be registered in any order with RegisterFor.
+// It returns nil if there is nothing left to dispatch.
+func (e *Expander) NextBatch(max int) []string {
+	if e.aborted != nil {
+		return nil
+	}
+	var batch []string
+	for len(batch) < max {
+		path, ok := e.popPending()
+		if !ok {
+			break
+		}
+		batch = append(batch, path)
+	}
+
+	if len(batch) == 0 {
+		e.finish()
+	}
+	return batch
+}
+
+// popPending takes the next discovery path off the queue and marks it as
+// outstanding. Once the queue is drained, the tree is walked again to pick up
+// discoveries evicted from the cache since the last expansion completed.
+func (e *Expander) popPending() (string, bool) {
+	if e.budgetSpent() {
+		return "", false
+	}
+	if e.isComplete {
+		e.unpin()
+	}
+	if path, ok := e.popQueued(); ok {
+		return path, true
+	}
+
+	e.generateDiscoveryPaths("")
+	return e.popQueued()
+}
+
+// popQueued takes the next discovery path off the queue and marks it as
+// outstanding, skipping discoveries that have been resolved meanwhile
+func (e *Expander) popQueued() (string, bool) {
 	// Check if we have any pending discoveries
 	for len(e.pendingDiscoveries) > 0 {
+		if e.config.shuffle != nil {
+			i := e.config.shuffle.IntN(len(e.pendingDiscoveries))
+			e.pendingDiscoveries[0], e.pendingDiscoveries[i] = e.pendingDiscoveries[i], e.pendingDiscoveries[0]
+		}
+		e.promotePriority()
 		path := e.pendingDiscoveries[0]
 		e.pendingDiscoveries = e.pendingDiscoveries[1:]
+		delete(e.queued, path)
+		delete(e.discoveryPriorities, path)
 
-		// Skip if already processed (might happen with dynamic additions)
-		if e.processedDiscoveries[path] {
+		// Skip if already processed or dispatched (might happen with dynamic additions)
+		if e.processedDiscoveries[path] || e.outstanding[path] {
 			continue
 		}
 
 		// Check if we have this in cache
-		if _, cached := e.cache[path]; cached {
+		if _, cached := e.cache.Get(path); cached {
 			// Mark as processed and continue to next
 			e.processedDiscoveries[path] = true
-			e.processNextLevel(path, e.cache[path])
+			e.generateDiscoveryPaths(path)
 			continue
 		}
 
-		// Store last discovery path and return it
-		e.lastDiscoveryPath = path
+		e.outstanding[path] = true
+		e.isComplete = false
+		e.dispatched++
 		return path, true
 	}
 
+	return "", false
+}
+
+// dequeue removes a pending discovery path from the queue, reporting whether it was queued
+func (e *Expander) dequeue(path string) bool {
+	if e.processedDiscoveries[path] || !e.queued[path] {
+		return false
+	}
+	e.pendingDiscoveries = slices.DeleteFunc(e.pendingDiscoveries, func(pending string) bool {
+		return pending == path
+	})
+	delete(e.queued, path)
+	return true
+}
+
+// enqueue queues discovery paths that are not queued yet, at the back of the
+// queue or, with front, in front of it in the given order
+func (e *Expander) enqueue(front bool, paths ...string) {
+	added := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !e.queued[path] {
+			e.queued[path] = true
+			added = append(added, path)
+		}
+	}
+	if front {
+		e.pendingDiscoveries = append(added, e.pendingDiscoveries...)
+	} else {
+		e.pendingDiscoveries = append(e.pendingDiscoveries, added...)
+	}
+}
+
+// clearQueue drops every pending discovery
+func (e *Expander) clearQueue() {
+	e.pendingDiscoveries = e.pendingDiscoveries[:0]
+	clear(e.queued)
+}
+
+// Requeue puts a discovery path handed out by Next or NextBatch back at the
+// end of the pending queue, so a discovery that failed on a transport error
+// is returned again by a later Next. A path abandoned with Skip is queued
+// again too. Requeueing any other path fails with ErrNotOutstanding.
+func (e *Expander) Requeue(discoveryPath string) error {
+	if !strings.HasSuffix(discoveryPath, ".") {
+		discoveryPath += "."
+	}
+	if !e.outstanding[discoveryPath] && !e.skipped[discoveryPath] {
+		return fmt.Errorf("%w: %s", ErrNotOutstanding, discoveryPath)
+	}
+
+	delete(e.outstanding, discoveryPath)
+	delete(e.skipped, discoveryPath)
+	if e.lastDiscoveryPath == discoveryPath {
+		e.lastDiscoveryPath = ""
+	}
+	e.enqueue(false, discoveryPath)
+	e.isComplete = false
+	return nil
+}
+
+// finish completes the expansion once no discovery is outstanding and the
+// values needed by filters are known
+func (e *Expander) finish() {
+	if len(e.outstanding) > 0 || e.valuesPending() {
+		return
+	}
+
 	// No more discoveries needed
 	e.isComplete = true
 	e.generateExpandedPaths()
-	return "", false
+
+	for _, pattern := range e.patterns {
+		if len(e.patternPaths[pattern]) == 0 {
+			e.warnOnce(WarningDeadPattern, pattern, "no paths expanded")
+		}
+	}
 }
 
 // Register registers the discovered parameter names from a GetParameterNames call.
@@ -132,138 +455,365 @@ func (e *Expander) Register(results []string) error {
 	// Use the last discovery path from Next()
 	discoveryPath := e.lastDiscoveryPath
 	if discoveryPath == "" {
-		return fmt.Errorf("no discovery path available - call Next() first")
+		return fmt.Errorf("%w - call Next() first", ErrNoDiscovery)
 	}
 
-	// Extract indices from the results
-	indices := extractIndices(discoveryPath, results)
+	return e.RegisterFor(discoveryPath, results)
+}
+
+// RegisterTree registers a full-subtree GetParameterNames response
+// (NextLevel=false) for the last discovery path returned by Next. Besides
+// the instances of the discovery path, every wildcard level below it is
+// resolved from the response at once, whether or not the driver
+// capabilities declare NextLevel=false support, so deep patterns need a
+// single round trip.
+func (e *Expander) RegisterTree(results []string) error {
+	discoveryPath := e.lastDiscoveryPath
+	if err := e.Register(results); err != nil {
+		return err
+	}
+	e.resolveSubtree(discoveryPath, results, true)
+	return nil
+}
+
+// RegisterFor registers the parameter names discovered for a specific
+// discovery path, independent of the order in which paths were handed out.
+// The path must be outstanding, i.e. returned by Next or NextBatch, or still
+// pending; registering a pending path removes it from the queue. Registering
+// any other path, including one registered before, fails with ErrNotOutstanding.
+func (e *Expander) RegisterFor(discoveryPath string, results []string) error {
+	if e.aborted != nil {
+		return e.aborted
+	}
+	if e.isComplete {
+		return ErrAlreadyComplete
+	}
+
+	if !strings.HasSuffix(discoveryPath, ".") {
+		discoveryPath += "."
+	}
+	discoveryPath = e.canonicalDiscovery(discoveryPath)
+	if !e.outstanding[discoveryPath] && !e.dequeue(discoveryPath) {
+		return fmt.Errorf("%w: %s", ErrNotOutstanding, discoveryPath)
+	}
+	projected, before := e.projected, e.projectBelow(discoveryPath)
+	results = e.canonicalNames(discoveryPath, results)
+	results = e.repairPrefixes(discoveryPath, results)
+	if err := checkRoots(discoveryPath, results); err != nil {
+		// Keep the discovery outstanding so the right results can still be registered
+		e.outstanding[discoveryPath] = true
+		e.malformed[discoveryPath] = err
+		return err
+	}
+	results = e.guardResponse(discoveryPath, results)
+	e.auditResponse(discoveryPath, results)
+
+	// Extract instances from the results
+	indices, aliases := extractInstances(discoveryPath, results, e.config.aliasCodec)
+	e.checkInstanceCap(discoveryPath, indices, aliases)
 
 	// Cache the results
-	e.cache[discoveryPath] = indices
+	e.cache.Put(discoveryPath, indices)
+	if len(aliases) > 0 {
+		e.aliases[discoveryPath] = aliases
+	}
+	if e.config.globSegments || e.paths.matchesNames {
+		e.names[discoveryPath] = extractChildNames(discoveryPath, results)
+	}
 	e.processedDiscoveries[discoveryPath] = true
+	delete(e.outstanding, discoveryPath)
+	delete(e.faults, discoveryPath)
+	delete(e.malformed, discoveryPath)
 
-	// Process next level of discoveries based on these indices
-	e.processNextLevel(discoveryPath, indices)
+	// Process next level of discoveries based on these instances
+	e.generateDiscoveryPaths(discoveryPath)
+	e.absorbSubtree(discoveryPath, results)
 
 	// Clear last discovery path
-	e.lastDiscoveryPath = ""
+	if e.lastDiscoveryPath == discoveryPath {
+		e.lastDiscoveryPath = ""
+	}
 
-	return nil
+	return e.checkPathLimit(discoveryPath, projected, before)
 }
 
 // Collect returns all fully expanded parameter paths.
 // This should be called after Next() returns false.
 func (e *Expander) Collect() ([]string, error) {
-	// Trigger final generation if not yet complete
-	if !e.isComplete {
-		// Check if there are truly pending discoveries
-		path, hasMore := e.Next()
-		if hasMore {
-			return nil, fmt.Errorf("expansion not complete, next discovery path: %s", path)
-		}
+	if err := e.ensureComplete(); err != nil {
+		return nil, err
 	}
 
 	// Return a copy to prevent external modification
-	result := make([]string, len(e.expandedPaths))
-	copy(result, e.expandedPaths)
-	return result, nil
+	return e.visiblePaths(e.expandedPaths), nil
+}
+
+// ensureComplete triggers final generation if not yet complete, and fails if
+// discoveries are still pending or outstanding
+func (e *Expander) ensureComplete() error {
+	if e.aborted != nil {
+		return e.aborted
+	}
+	if e.isComplete {
+		if max := e.config.maxPaths; max > 0 && len(e.expandedPaths) > max {
+			e.aborted = fmt.Errorf("%w: %d expanded, limit is %d", ErrTooManyPaths, len(e.expandedPaths), max)
+			return e.aborted
+		}
+		return nil
+	}
+
+	// Check if there are truly pending discoveries
+	path, hasMore := e.Next()
+	if hasMore {
+		return fmt.Errorf("expansion not complete, next discovery path: %s", path)
+	}
+	if !e.isComplete && len(e.outstanding) == 0 {
+		return errors.New("expansion not complete, filter values are needed")
+	}
+	if !e.isComplete {
+		return fmt.Errorf("expansion not complete, %d discoveries outstanding", len(e.outstanding))
+	}
+	return nil
 }
 
-// Reset clears all state in the expander, preparing it for reuse.
+// Reset clears all state in the expander, including options, preparing it for reuse.
 // This is automatically called when an expander is returned to the pool.
 func (e *Expander) Reset() {
 	// Clear the path tree
 	e.paths.root = &pathNode{
 		children: make(map[string]*pathNode),
 	}
+	e.paths.matchesNames = false
 
 	// Clear all maps
-	for k := range e.cache {
-		delete(e.cache, k)
+	for k := range e.localCache {
+		delete(e.localCache, k)
+	}
+	e.cache = e.localCache
+	for k := range e.aliases {
+		delete(e.aliases, k)
+	}
+	for k := range e.names {
+		delete(e.names, k)
+	}
+	for k := range e.aliasValues {
+		delete(e.aliasValues, k)
 	}
 	for k := range e.processedDiscoveries {
 		delete(e.processedDiscoveries, k)
 	}
+	for k := range e.outstanding {
+		delete(e.outstanding, k)
+	}
 	for k := range e.expandedSet {
 		delete(e.expandedSet, k)
 	}
+	for k := range e.patternPaths {
+		delete(e.patternPaths, k)
+	}
+	for k := range e.provenance {
+		delete(e.provenance, k)
+	}
+	for k := range e.writable {
+		delete(e.writable, k)
+	}
+	for k := range e.owners {
+		delete(e.owners, k)
+	}
+	for k := range e.responseSizes {
+		delete(e.responseSizes, k)
+	}
+	clear(e.filters)
+	clear(e.values)
+	clear(e.valueQueries)
+	clear(e.missingValues)
+	clear(e.searches)
+	clear(e.faults)
+	clear(e.skipped)
+	clear(e.malformed)
+	clear(e.blockedHits)
+	clear(e.priorities)
+	e.forgetPriorities()
 
 	// Clear slices
-	e.pendingDiscoveries = e.pendingDiscoveries[:0]
+	e.clearQueue()
 	e.expandedPaths = e.expandedPaths[:0]
+	e.patterns = e.patterns[:0]
+	e.warnings = e.warnings[:0]
 
 	e.isComplete = false
 	e.lastDiscoveryPath = ""
+	e.aborted = nil
+	e.dispatched = 0
+	e.projected = 0
+	e.config = config{}
+	e.deviceID = ""
 }
 
-// generateDiscoveryPaths analyzes the path tree and generates discovery paths
-// for all wildcard positions that haven't been processed yet
-func (e *Expander) generateDiscoveryPaths() {
-	discoveries := e.paths.getDiscoveryPaths()
-
-	for _, disc := range discoveries {
-		// Only add if not already processed or pending
-		if !e.processedDiscoveries[disc] {
-			// Check if already in pending
-			found := false
-			for _, pending := range e.pendingDiscoveries {
-				if pending == disc {
-					found = true
-					break
-				}
+// generateDiscoveryPaths walks the path tree and queues every reachable
+// discovery path that hasn't been processed yet, in the traversal order.
+// A registration only changes what lies below its discovery path, so it
+// passes the path as within to walk just the branches leading there; an
+// empty within walks the whole tree.
+func (e *Expander) generateDiscoveryPaths(within string) {
+	if within == "" {
+		// Whatever changed may change the projection anywhere
+		e.projected = -1
+	}
+
+	var found []string
+	e.walk(treeVisitor{
+		within: within,
+		discover: func(disc string) {
+			// A resolved discovery reported here was evicted from the cache
+			delete(e.processedDiscoveries, disc)
+
+			// Only add if not already dispatched or pending
+			if e.outstanding[disc] || e.skipped[disc] || e.queued[disc] {
+				return
 			}
-			if !found {
-				e.pendingDiscoveries = append(e.pendingDiscoveries, disc)
+			if e.isUnreadable(disc) || e.isBlocked(disc) || !e.isAllowedDiscovery(disc) {
+				return
 			}
-		}
+			e.queued[disc] = true
+			found = append(found, disc)
+		},
+	})
+
+	if e.config.traversal == DepthFirst {
+		e.pendingDiscoveries = append(found, e.pendingDiscoveries...)
+	} else {
+		e.pendingDiscoveries = append(e.pendingDiscoveries, found...)
 	}
 }
 
-// processNextLevel generates new discovery paths based on discovered indices
-func (e *Expander) processNextLevel(discoveryPath string, indices []int) {
-	// Build paths for the next wildcard level based on these indices
-	nextPaths := e.paths.getNextLevelPaths(discoveryPath, indices)
+// walk walks the path tree against the discoveries resolved so far
+func (e *Expander) walk(visit treeVisitor) {
+	visit.validateLiterals = e.config.literalPolicy == LiteralValidate
+	e.paths.walk(treeSource{
+		instances: e.instances,
+		instance:  e.hasInstance,
+		names:     e.childNames,
+		literal:   e.resolveLiteral,
+	}, visit)
+}
 
-	for _, nextPath := range nextPaths {
-		// Only add if not already processed
-		if !e.processedDiscoveries[nextPath] {
-			// Check if already in pending
-			found := false
-			for _, pending := range e.pendingDiscoveries {
-				if pending == nextPath {
-					found = true
-					break
-				}
-			}
-			if !found {
-				e.pendingDiscoveries = append(e.pendingDiscoveries, nextPath)
-			}
+// instances returns the instance segments resolved for a discovery path,
+// numeric indices first, followed by aliases rendered by the alias codec.
+// Tables of a fixed size in the schema resolve without discovery.
+// With WithAliasOutput, indices whose Alias value is known are rendered as
+// aliases too. Discovered instances are sampled and capped as configured.
+func (e *Expander) instances(discoveryPath string) ([]string, bool) {
+	indices, cached := e.cache.Get(discoveryPath)
+	if !cached {
+		return e.fixedInstances(discoveryPath)
+	}
+
+	indices, aliases := e.sampleInstances(discoveryPath, indices, e.aliases[discoveryPath])
+	indices, aliases = e.capInstances(indices, aliases)
+	segments := make([]string, 0, len(indices)+len(aliases))
+	for _, idx := range indices {
+		segments = append(segments, e.renderIndex(discoveryPath, idx))
+	}
+	for _, alias := range aliases {
+		segments = append(segments, e.config.aliasCodec.Encode(alias))
+	}
+	return segments, true
+}
+
+// hasInstance reports whether an instance segment is among the instances
+// resolved for a discovery path, and whether the path is resolved. Indices
+// used as they are, neither sampled, capped nor rendered as aliases, are
+// looked up without rendering the others.
+func (e *Expander) hasInstance(discoveryPath, segment string) (bool, bool) {
+	indices, cached := e.cache.Get(discoveryPath)
+	if cached && !e.config.aliasOutput && e.config.sampleSize <= 0 && e.config.instanceCap <= 0 {
+		if idx, err := strconv.Atoi(segment); err == nil && strconv.Itoa(idx) == segment {
+			return slices.Contains(indices, idx), true
 		}
 	}
+
+	instances, resolved := e.instances(discoveryPath)
+	return slices.Contains(instances, segment), resolved
 }
 
 // generateExpandedPaths creates the final fully expanded paths from the tree and cache
 func (e *Expander) generateExpandedPaths() {
-	// Don't clear existing paths - we might be adding dynamically
+	// Per-pattern results only reflect the latest expansion
+	for pattern := range e.patternPaths {
+		e.patternPaths[pattern] = e.patternPaths[pattern][:0]
+	}
+
+	// Rebuild from scratch: rediscovered instances may have disappeared.
+	// Paths of dynamically added patterns are regenerated along with the rest.
+	e.expandedPaths = e.expandedPaths[:0]
+	clear(e.expandedSet)
+
 	// Generate all possible expanded paths from the tree using the cache
-	paths := e.paths.generateExpandedPaths(e.cache)
+	e.walk(treeVisitor{
+		leaf: func(path, pattern string) {
+			if !e.keepsPath(path, pattern) {
+				return
+			}
+			e.patternPaths[pattern] = append(e.patternPaths[pattern], path)
 
-	// Add unique paths only
-	for _, path := range paths {
-		if !e.expandedSet[path] {
-			e.expandedPaths = append(e.expandedPaths, path)
-			e.expandedSet[path] = true
+			// Add unique paths only
+			if !e.expandedSet[path] {
+				e.expandedPaths = append(e.expandedPaths, path)
+				e.expandedSet[path] = true
+			}
+		},
+	})
+
+	// Sort for consistent output
+	e.sortPaths(e.expandedPaths)
+	for _, paths := range e.patternPaths {
+		e.sortPaths(paths)
+	}
+}
+
+// keepsPath reports whether a path expanded from a pattern belongs in the
+// results: it is readable, lies within the allowed subtrees and outside the
+// blocked ones, and its instances satisfy the filters of the pattern
+func (e *Expander) keepsPath(path, pattern string) bool {
+	return !e.isUnreadable(path) && e.isAllowed(path) && !e.isBlocked(path) && e.matchesFilter(path, pattern)
+}
+
+// checkWildcards rejects wildcards embedded in a segment, such as
+// "Access*Point", which would otherwise be taken literally and never match,
+// unless glob segments are enabled
+func checkWildcards(path string, globs bool) error {
+	if globs {
+		return nil
+	}
+	for segment := range strings.SplitSeq(path, ".") {
+		if isGlobSegment(segment) {
+			return fmt.Errorf("%w: segment %q of %s", ErrEmbeddedWildcard, segment, path)
 		}
 	}
+	return nil
+}
 
-	// Sort for consistent output
-	sort.Strings(e.expandedPaths)
+// checkRoots verifies that every name shares the data model root of the
+// discovery path. The root object may be listed without its trailing dot.
+func checkRoots(discoveryPath string, names []string) error {
+	root := Root(discoveryPath)
+	for _, name := range names {
+		if !IsUnderRoot(name, root) && name+"." != root {
+			return &RootMismatchError{DiscoveryPath: discoveryPath, Name: name}
+		}
+	}
+	return nil
 }
 
-// extractIndices extracts numeric indices from parameter names
-func extractIndices(discoveryPath string, parameterNames []string) []int {
+// extractInstances extracts the instances listed directly below the discovery
+// path from parameter names. Numeric segments are returned as indices; other
+// segments are returned as aliases if the alias codec recognizes them.
+func extractInstances(discoveryPath string, parameterNames []string, codec AliasCodec) ([]int, []string) {
 	indices := []int{}
 	seen := make(map[int]bool)
+	var aliases []string
+	seenAlias := make(map[string]bool)
 
 	pathWithoutDot := strings.TrimSuffix(discoveryPath, ".")
 	prefixLen := len(pathWithoutDot) + 1 // +1 for the dot
@@ -289,10 +839,21 @@ func extractIndices(discoveryPath string, parameterNames []string) []int {
 				indices = append(indices, idx)
 				seen[idx] = true
 			}
+			continue
+		}
+
+		// Otherwise it may be an alias-addressed instance
+		if codec == nil {
+			continue
+		}
+		if alias, ok := codec.Decode(segment); ok && !seenAlias[alias] {
+			aliases = append(aliases, alias)
+			seenAlias[alias] = true
 		}
 	}
 
-	// Sort indices for consistent ordering
+	// Sort for consistent ordering
 	sort.Ints(indices)
-	return indices
+	sort.Strings(aliases)
+	return indices, aliases
 }
@@ -1,6 +1,7 @@
 package expander_test
 
 import (
+	"errors"
 	"testing"
 
 	expander "github.com/metalgrid/tr069-path-expander/v2"
@@ -73,6 +74,15 @@ var _ = Describe("TR-069 Path Expander", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(MatchError(expander.ErrInvalidPath))
 			})
+
+			It("should reject wildcards embedded in a segment", func() {
+				err := exp.Add("Device.WiFi.Access*Point.*.Enable")
+				Expect(err).To(MatchError(expander.ErrEmbeddedWildcard))
+				Expect(err).To(MatchError(ContainSubstring(`"Access*Point"`)))
+
+				err = exp.Add("Device.WiFi.AccessPoint.1*.Enable")
+				Expect(err).To(MatchError(expander.ErrEmbeddedWildcard))
+			})
 		})
 	})
 
@@ -142,6 +152,81 @@ var _ = Describe("TR-069 Path Expander", func() {
 		})
 	})
 
+	Describe("Trailing Parameter Wildcard", func() {
+		BeforeEach(func() {
+			exp = expander.Get()
+		})
+
+		It("should expand to the parameters of the object", func() {
+			Expect(exp.Add("Device.WiFi.AccessPoint.1.*")).To(Succeed())
+
+			path, hasMore := exp.Next()
+			Expect(hasMore).To(BeTrue())
+			Expect(path).To(Equal("Device.WiFi.AccessPoint.1."))
+
+			err := exp.Register([]string{
+				"Device.WiFi.AccessPoint.1.Enable",
+				"Device.WiFi.AccessPoint.1.SSIDReference",
+				"Device.WiFi.AccessPoint.1.Security.",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{
+				"Device.WiFi.AccessPoint.1.Enable",
+				"Device.WiFi.AccessPoint.1.SSIDReference",
+			}))
+		})
+
+		It("should expand to the parameters of every instance", func() {
+			Expect(exp.Add("Device.WiFi.AccessPoint.*.Security.*")).To(Succeed())
+
+			path, _ := exp.Next()
+			Expect(exp.Register([]string{path + "1.", path + "2."})).To(Succeed())
+			for {
+				path, hasMore := exp.Next()
+				if !hasMore {
+					break
+				}
+				Expect(exp.Register([]string{path + "ModeEnabled", path + "KeyPassphrase"})).To(Succeed())
+			}
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(HaveLen(4))
+			Expect(paths).To(ContainElement("Device.WiFi.AccessPoint.2.Security.ModeEnabled"))
+		})
+	})
+
+	Describe("Trailing Instance Wildcard", func() {
+		BeforeEach(func() {
+			exp = expander.Get()
+		})
+
+		It("should expand to the instance object paths of the table", func() {
+			Expect(exp.Add("Device.WiFi.AccessPoint.*")).To(Succeed())
+
+			path, hasMore := exp.Next()
+			Expect(hasMore).To(BeTrue())
+			Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+			Expect(exp.Register([]string{path + "1.", path + "2.", path + "2.Enable"})).To(Succeed())
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"Device.WiFi.AccessPoint.1.", "Device.WiFi.AccessPoint.2."}))
+		})
+
+		It("should share the discovery with deeper patterns", func() {
+			Expect(exp.Add("Device.WiFi.AccessPoint.*", "Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+
+			Expect(drainDiscoveries(exp)).To(HaveLen(1))
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"Device.WiFi.AccessPoint.1.", "Device.WiFi.AccessPoint.1.Enable"}))
+		})
+	})
+
 	Describe("Multi-level Wildcard Expansion", func() {
 		Context("when adding paths with multiple wildcards", func() {
 			BeforeEach(func() {
@@ -205,6 +290,175 @@ var _ = Describe("TR-069 Path Expander", func() {
 		})
 	})
 
+	Describe("Subtree Registration", func() {
+		BeforeEach(func() {
+			exp = expander.Get()
+		})
+
+		It("should resolve every wildcard level from one deep response", func() {
+			Expect(exp.Add(
+				"Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress",
+				"Device.Hosts.Host.*.HostName",
+			)).To(Succeed())
+
+			// The response to the access points resolves their associated
+			// devices; only the discovery outside of it is left
+			var discoveries []string
+			for path, hasMore := exp.Next(); hasMore; path, hasMore = exp.Next() {
+				discoveries = append(discoveries, path)
+				if path == "Device.Hosts.Host." {
+					Expect(exp.Register([]string{path + "1."})).To(Succeed())
+					continue
+				}
+				Expect(exp.RegisterTree([]string{
+					"Device.WiFi.AccessPoint.1.Enable",
+					"Device.WiFi.AccessPoint.1.AssociatedDevice.1.MACAddress",
+					"Device.WiFi.AccessPoint.1.AssociatedDevice.4.MACAddress",
+					"Device.WiFi.AccessPoint.2.Enable",
+					"Device.WiFi.AccessPoint.2.AssociatedDevice.",
+				})).To(Succeed())
+			}
+			Expect(discoveries).To(ConsistOf("Device.WiFi.AccessPoint.", "Device.Hosts.Host."))
+
+			Expect(exp.Collect()).To(Equal([]string{
+				"Device.Hosts.Host.1.HostName",
+				"Device.WiFi.AccessPoint.1.AssociatedDevice.1.MACAddress",
+				"Device.WiFi.AccessPoint.1.AssociatedDevice.4.MACAddress",
+			}))
+		})
+
+		It("should require a discovery", func() {
+			Expect(exp.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+			Expect(exp.RegisterTree([]string{"Device.WiFi.SSID.1.SSID"})).To(MatchError(expander.ErrNoDiscovery))
+		})
+	})
+
+	Describe("Shuffled Discoveries", func() {
+		drain := func(opts ...expander.Option) []string {
+			exp = expander.Get(opts...)
+			defer func() {
+				expander.Release(exp)
+				exp = nil
+			}()
+
+			for _, object := range []string{"AccessPoint", "SSID", "Radio", "EndPoint"} {
+				err := exp.Add("Device.WiFi." + object + ".*.Enable")
+				Expect(err).NotTo(HaveOccurred())
+			}
+			for _, object := range []string{"Interface", "Link", "VLANTermination", "RMONStats"} {
+				err := exp.Add("Device.Ethernet." + object + ".*.Enable")
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			var order []string
+			for {
+				path, hasMore := exp.Next()
+				if !hasMore {
+					return order
+				}
+				order = append(order, path)
+				Expect(exp.Register([]string{})).To(Succeed())
+			}
+		}
+
+		It("should reproduce the same order for the same seed", func() {
+			queued := drain()
+			first := drain(expander.WithShuffledDiscoveries(42))
+
+			Expect(first).To(ConsistOf(queued))
+			Expect(drain(expander.WithShuffledDiscoveries(42))).To(Equal(first))
+			Expect(drain(expander.WithShuffledDiscoveries(7))).NotTo(Equal(first))
+		})
+	})
+
+	Describe("Batched Discovery", func() {
+		BeforeEach(func() {
+			exp = expander.Get()
+		})
+
+		It("should dispatch independent discoveries together and accept results out of order", func() {
+			err := exp.Add("InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.WANIPConnection.*.Enable")
+			Expect(err).NotTo(HaveOccurred())
+
+			batch := exp.NextBatch(4)
+			Expect(batch).To(Equal([]string{"InternetGatewayDevice.WANDevice."}))
+			Expect(exp.RegisterFor(batch[0], []string{
+				"InternetGatewayDevice.WANDevice.1.",
+				"InternetGatewayDevice.WANDevice.2.",
+				"InternetGatewayDevice.WANDevice.3.",
+			})).To(Succeed())
+
+			batch = exp.NextBatch(2)
+			Expect(batch).To(Equal([]string{
+				"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.",
+				"InternetGatewayDevice.WANDevice.2.WANConnectionDevice.",
+			}))
+			Expect(exp.NextBatch(2)).To(Equal([]string{
+				"InternetGatewayDevice.WANDevice.3.WANConnectionDevice.",
+			}))
+
+			// Nothing left to dispatch, but the expansion is not complete yet
+			Expect(exp.NextBatch(2)).To(BeEmpty())
+			_, err = exp.Collect()
+			Expect(err).To(HaveOccurred())
+
+			Expect(exp.RegisterFor("InternetGatewayDevice.WANDevice.3.WANConnectionDevice.", nil)).To(Succeed())
+			Expect(exp.RegisterFor("InternetGatewayDevice.WANDevice.1.WANConnectionDevice.", []string{
+				"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.",
+			})).To(Succeed())
+			Expect(exp.RegisterFor("InternetGatewayDevice.WANDevice.2.WANConnectionDevice.", nil)).To(Succeed())
+
+			batch = exp.NextBatch(8)
+			Expect(batch).To(Equal([]string{
+				"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANIPConnection.",
+			}))
+			Expect(exp.RegisterFor(batch[0], []string{
+				"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANIPConnection.2.",
+			})).To(Succeed())
+
+			Expect(exp.NextBatch(8)).To(BeEmpty())
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{
+				"InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANIPConnection.2.Enable",
+			}))
+		})
+
+		It("should accept results for pending paths before they are dispatched", func() {
+			err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = exp.RegisterFor("Device.WiFi.AccessPoint", []string{"Device.WiFi.AccessPoint.1"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, hasMore := exp.Next()
+			Expect(hasMore).To(BeFalse())
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(ConsistOf("Device.WiFi.AccessPoint.1.Enable"))
+		})
+
+		It("should reject results for unknown or already registered paths", func() {
+			err := exp.Add("Device.WiFi.AccessPoint.*.Enable", "Device.Ethernet.Interface.*.Status")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = exp.RegisterFor("Device.WiFi.SSID.", []string{"Device.WiFi.SSID.1"})
+			Expect(err).To(MatchError(expander.ErrNotOutstanding))
+
+			path, _ := exp.Next()
+			Expect(exp.RegisterFor(path, nil)).To(Succeed())
+			Expect(exp.RegisterFor(path, nil)).To(MatchError(expander.ErrNotOutstanding))
+		})
+
+		It("should report Register calls without a dispatched path", func() {
+			err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(exp.Register(nil)).To(MatchError(expander.ErrNoDiscovery))
+		})
+	})
+
 	Describe("Common Ancestor Optimization", func() {
 		Context("when adding multiple paths with common ancestors", func() {
 			BeforeEach(func() {
@@ -374,6 +628,108 @@ var _ = Describe("TR-069 Path Expander", func() {
 		})
 	})
 
+	Describe("Literal And Wildcard Indices", func() {
+		add := func() {
+			err := exp.Add(
+				"Device.WiFi.AccessPoint.*.Enable",
+				"Device.WiFi.AccessPoint.2.Enable",
+				"Device.WiFi.AccessPoint.2.AssociatedDevice.*.MACAddress",
+			)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("should keep literal paths by default", func() {
+			exp = expander.Get()
+			add()
+
+			var discoveries []string
+			for {
+				path, hasMore := exp.Next()
+				if !hasMore {
+					break
+				}
+				discoveries = append(discoveries, path)
+				err := exp.Register([]string{path + "1"})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(discoveries).To(ConsistOf(
+				"Device.WiFi.AccessPoint.",
+				"Device.WiFi.AccessPoint.2.AssociatedDevice.",
+			))
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(ConsistOf(
+				"Device.WiFi.AccessPoint.1.Enable",
+				"Device.WiFi.AccessPoint.2.Enable",
+				"Device.WiFi.AccessPoint.2.AssociatedDevice.1.MACAddress",
+			))
+		})
+
+		It("should drop literal paths missing from discovery when validating", func() {
+			exp = expander.Get(expander.WithLiteralPolicy(expander.LiteralValidate))
+			add()
+
+			path, hasMore := exp.Next()
+			Expect(hasMore).To(BeTrue())
+			Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+
+			err := exp.Register([]string{"Device.WiFi.AccessPoint.1"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, hasMore = exp.Next()
+			Expect(hasMore).To(BeFalse())
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(ConsistOf("Device.WiFi.AccessPoint.1.Enable"))
+		})
+
+		It("should keep validated literal paths listed by discovery", func() {
+			exp = expander.Get(expander.WithLiteralPolicy(expander.LiteralValidate))
+			add()
+
+			_, _ = exp.Next()
+			err := exp.Register([]string{"Device.WiFi.AccessPoint.2"})
+			Expect(err).NotTo(HaveOccurred())
+
+			path, hasMore := exp.Next()
+			Expect(hasMore).To(BeTrue())
+			Expect(path).To(Equal("Device.WiFi.AccessPoint.2.AssociatedDevice."))
+
+			err = exp.Register([]string{"Device.WiFi.AccessPoint.2.AssociatedDevice.7"})
+			Expect(err).NotTo(HaveOccurred())
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{
+				"Device.WiFi.AccessPoint.2.AssociatedDevice.7.MACAddress",
+				"Device.WiFi.AccessPoint.2.Enable",
+			}))
+		})
+
+		It("should not discover below an undiscovered literal index through the wildcard", func() {
+			exp = expander.Get()
+			add()
+			Expect(exp.Add("Device.WiFi.AccessPoint.*.AC.*.Enable")).To(Succeed())
+
+			var discoveries []string
+			for {
+				path, hasMore := exp.Next()
+				if !hasMore {
+					break
+				}
+				discoveries = append(discoveries, path)
+				Expect(exp.Register([]string{path + "1"})).To(Succeed())
+			}
+			Expect(discoveries).To(Equal([]string{
+				"Device.WiFi.AccessPoint.",
+				"Device.WiFi.AccessPoint.2.AssociatedDevice.",
+				"Device.WiFi.AccessPoint.1.AC.",
+			}))
+		})
+	})
+
 	Describe("Error Handling", func() {
 		Context("when calling Collect before completion", func() {
 			BeforeEach(func() {
@@ -391,6 +747,31 @@ var _ = Describe("TR-069 Path Expander", func() {
 			})
 		})
 
+		Context("when registering names from another data model root", func() {
+			BeforeEach(func() {
+				exp = expander.Get()
+			})
+
+			It("should return a typed error and keep the discovery outstanding", func() {
+				err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+				Expect(err).NotTo(HaveOccurred())
+
+				path, _ := exp.Next()
+				err = exp.Register([]string{
+					"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1",
+				})
+				Expect(err).To(MatchError(expander.ErrRootMismatch))
+
+				var mismatch *expander.RootMismatchError
+				Expect(errors.As(err, &mismatch)).To(BeTrue())
+				Expect(mismatch.DiscoveryPath).To(Equal(path))
+				Expect(mismatch.Name).To(Equal("InternetGatewayDevice.LANDevice.1.WLANConfiguration.1"))
+
+				err = exp.RegisterFor(path, []string{"Device.WiFi.AccessPoint.1"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
 		Context("when registering after completion", func() {
 			BeforeEach(func() {
 				exp = expander.Get()
@@ -463,6 +844,86 @@ var _ = Describe("TR-069 Path Expander", func() {
 		})
 	})
 
+	Describe("Configured Pools", func() {
+		It("should hand out fresh expanders after warming", func() {
+			pool := expander.NewPool()
+			pool.Warm(4, expander.CapacityHints{Patterns: 8, Discoveries: 32, Paths: 256})
+			expander.WarmPool(2, expander.CapacityHints{Discoveries: 4})
+
+			for range 6 {
+				exp = pool.Get()
+				paths, err := exp.Collect()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(paths).To(BeEmpty())
+				expander.Release(exp)
+			}
+			exp = nil
+		})
+
+		It("should apply the pool options to every expander", func() {
+			pool := expander.NewPool(expander.WithAliasCodec(expander.BracketAliases))
+
+			exp = pool.Get()
+			err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _ = exp.Next()
+			err = exp.Register([]string{"Device.WiFi.AccessPoint.[guest]"})
+			Expect(err).NotTo(HaveOccurred())
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(ConsistOf("Device.WiFi.AccessPoint.[guest].Enable"))
+		})
+
+		It("should apply stateful pool options afresh on every Get", func() {
+			pool := expander.NewPool(expander.WithShuffledDiscoveries(7))
+			order := func() []string {
+				exp = pool.Get()
+				defer expander.Release(exp)
+				Expect(exp.Add("Device.WiFi.SSID.*.SSID", "Device.Hosts.Host.*.HostName", "Device.IP.Interface.*.Enable")).To(Succeed())
+				return drainDiscoveries(exp)
+			}
+
+			Expect(order()).To(Equal(order()))
+			exp = nil
+		})
+
+		It("should drop the pool options on Reset", func() {
+			pool := expander.NewPool(expander.WithAliasCodec(expander.BracketAliases))
+			exp = pool.Get()
+			exp.Reset()
+
+			err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _ = exp.Next()
+			err = exp.Register([]string{"Device.WiFi.AccessPoint.[guest]"})
+			Expect(err).NotTo(HaveOccurred())
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(BeEmpty())
+		})
+
+		It("should not leak pool options into the default pool", func() {
+			pool := expander.NewPool(expander.WithAliasCodec(expander.BracketAliases))
+			expander.Release(pool.Get())
+
+			exp = expander.Get()
+			err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _ = exp.Next()
+			err = exp.Register([]string{"Device.WiFi.AccessPoint.[guest]"})
+			Expect(err).NotTo(HaveOccurred())
+
+			paths, err := exp.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(BeEmpty())
+		})
+	})
+
 	Describe("Complex Real-World Scenario", func() {
 		BeforeEach(func() {
 			exp = expander.Get()
@@ -0,0 +1,86 @@
+package expander
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// cacheExport is the serialized form of the discoveries of an expander
+type cacheExport struct {
+	DeviceID    string              `json:"deviceId,omitempty"`
+	Discoveries map[string][]int    `json:"discoveries"`
+	Aliases     map[string][]string `json:"aliases,omitempty"`
+	Names       map[string][]string `json:"names,omitempty"`
+}
+
+// ExportCache serializes the discoveries resolved so far, along with the
+// device the expander is bound to, so they can be persisted between CWMP
+// sessions and imported on the next Inform. The export is signed if a key is
+// set with WithSigningKey.
+func (e *Expander) ExportCache() ([]byte, error) {
+	export := cacheExport{
+		DeviceID:    e.deviceID,
+		Discoveries: make(map[string][]int, len(e.processedDiscoveries)),
+	}
+	for path := range e.processedDiscoveries {
+		indices, cached := e.cache.Get(path)
+		if !cached {
+			continue
+		}
+		export.Discoveries[path] = indices
+		if aliases := e.aliases[path]; len(aliases) > 0 {
+			if export.Aliases == nil {
+				export.Aliases = make(map[string][]string)
+			}
+			export.Aliases[path] = aliases
+		}
+		if names, ok := e.names[path]; ok {
+			if export.Names == nil {
+				export.Names = make(map[string][]string)
+			}
+			export.Names[path] = names
+		}
+	}
+	return e.seal(json.Marshal(export))
+}
+
+// ImportCache loads discoveries produced by ExportCache so the corresponding
+// discoveries are skipped, as with Preload. It is refused with
+// ErrDeviceMismatch if the export belongs to another device than the one the
+// expander is bound to; an unbound expander is bound to the exported device.
+func (e *Expander) ImportCache(data []byte) error {
+	data, err := e.unseal(data)
+	if err != nil {
+		return err
+	}
+
+	var export cacheExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("failed to decode cache: %w", err)
+	}
+	if err := e.Bind(export.DeviceID); err != nil {
+		return err
+	}
+
+	for path, indices := range export.Discoveries {
+		if !strings.HasSuffix(path, ".") {
+			path += "."
+		}
+		cached := slices.Clone(indices)
+		slices.Sort(cached)
+		e.cache.Put(path, slices.Compact(cached))
+		if aliases := export.Aliases[path]; len(aliases) > 0 {
+			e.aliases[path] = slices.Clone(aliases)
+		}
+		if names, ok := export.Names[path]; ok {
+			e.names[path] = slices.Clone(names)
+		}
+		e.processedDiscoveries[path] = true
+	}
+
+	e.isComplete = false
+	e.generateDiscoveryPaths("")
+	return nil
+}
@@ -0,0 +1,52 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache Export", func() {
+	var exp, next *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get(expander.WithAliasCodec(expander.BracketAliases))
+		next = expander.Get(expander.WithAliasCodec(expander.BracketAliases))
+
+		Expect(exp.Bind("CPE-0001")).To(Succeed())
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+		_, _ = exp.Next()
+		err := exp.Register([]string{"Device.WiFi.AccessPoint.1", "Device.WiFi.AccessPoint.[guest]"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+		expander.Release(next)
+	})
+
+	It("should turn the next session into a zero-discovery expansion", func() {
+		data, err := exp.ExportCache()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(next.ImportCache(data)).To(Succeed())
+		Expect(next.DeviceID()).To(Equal("CPE-0001"))
+		Expect(next.Add("Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+
+		_, hasMore := next.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(next.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.[guest].Enable",
+		}))
+	})
+
+	It("should refuse exports from another device", func() {
+		data, err := exp.ExportCache()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(next.Bind("CPE-0002")).To(Succeed())
+		Expect(next.ImportCache(data)).To(MatchError(expander.ErrDeviceMismatch))
+		Expect(next.ImportCache([]byte("{"))).To(HaveOccurred())
+	})
+})
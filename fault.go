@@ -0,0 +1,61 @@
+package expander
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// FaultInvalidParameterName is the CWMP fault 9005, invalid parameter name,
+// which a device answers for a discovery path it doesn't implement
+const FaultInvalidParameterName = 9005
+
+// FaultError is a CWMP fault answered for a discovery, such as one replayed
+// from a transcript.
+type FaultError struct {
+	Code int
+}
+
+func (e *FaultError) Error() string {
+	return fmt.Sprintf("CWMP fault %d", e.Code)
+}
+
+// FaultCode returns the fault code, as the driver loops and IsTransient
+// recognize it.
+func (e *FaultError) FaultCode() int {
+	return e.Code
+}
+
+// RegisterFault registers a CWMP fault answered for a discovery path, such
+// as 9005 for a branch the device doesn't implement. The branch is pruned,
+// as if the discovery had listed nothing, and the expansion continues with
+// the rest; the fault is kept for Faults. The path must be outstanding or
+// pending, as with RegisterFor.
+func (e *Expander) RegisterFault(discoveryPath string, faultCode int) error {
+	if !strings.HasSuffix(discoveryPath, ".") {
+		discoveryPath += "."
+	}
+	if err := e.RegisterFor(discoveryPath, nil); err != nil {
+		return err
+	}
+	e.faults[discoveryPath] = faultCode
+	return nil
+}
+
+// Faults returns the fault codes registered with RegisterFault by discovery
+// path, for the branches pruned from the expansion.
+func (e *Expander) Faults() map[string]int {
+	return maps.Clone(e.faults)
+}
+
+// branchFault returns the fault code of a discovery error that only
+// concerns the branch below its discovery path, which the driver loops
+// register with RegisterFault instead of failing
+func branchFault(err error) (int, bool) {
+	var fault interface{ FaultCode() int }
+	if errors.As(err, &fault) && fault.FaultCode() == FaultInvalidParameterName {
+		return FaultInvalidParameterName, true
+	}
+	return 0, false
+}
@@ -0,0 +1,86 @@
+package expander_test
+
+import (
+	"context"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fault Registration", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		Expect(exp.Add("InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.SSID")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should prune a faulted branch and continue with the rest", func() {
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{
+			"InternetGatewayDevice.LANDevice.1.",
+			"InternetGatewayDevice.LANDevice.2.",
+		})).To(Succeed())
+
+		batch := exp.NextBatch(2)
+		Expect(batch).To(ConsistOf(
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.",
+			"InternetGatewayDevice.LANDevice.2.WLANConfiguration.",
+		))
+		Expect(exp.RegisterFault("InternetGatewayDevice.LANDevice.1.WLANConfiguration.", expander.FaultInvalidParameterName)).To(Succeed())
+		Expect(exp.RegisterFor("InternetGatewayDevice.LANDevice.2.WLANConfiguration.", []string{
+			"InternetGatewayDevice.LANDevice.2.WLANConfiguration.1.",
+		})).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{"InternetGatewayDevice.LANDevice.2.WLANConfiguration.1.SSID"}))
+		Expect(exp.Faults()).To(Equal(map[string]int{
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.": expander.FaultInvalidParameterName,
+		}))
+		Expect(path).To(Equal("InternetGatewayDevice.LANDevice."))
+	})
+
+	It("should reject faults for discoveries not handed out", func() {
+		err := exp.RegisterFault("InternetGatewayDevice.WANDevice.", expander.FaultInvalidParameterName)
+		Expect(err).To(MatchError(expander.ErrNotOutstanding))
+		Expect(exp.Faults()).To(BeEmpty())
+	})
+
+	It("should prune branches answered with fault 9005 in the driver loops", func() {
+		device := fakeDevice(
+			"InternetGatewayDevice.LANDevice.1.",
+			"InternetGatewayDevice.LANDevice.2.",
+			"InternetGatewayDevice.LANDevice.2.WLANConfiguration.1.",
+		)
+		fetch := func(ctx context.Context, path string) ([]string, error) {
+			if path == "InternetGatewayDevice.LANDevice.1.WLANConfiguration." {
+				return nil, cwmpFault(expander.FaultInvalidParameterName)
+			}
+			return device(ctx, path)
+		}
+
+		paths, err := exp.Expand(context.Background(), fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{"InternetGatewayDevice.LANDevice.2.WLANConfiguration.1.SSID"}))
+		Expect(exp.Faults()).To(HaveKeyWithValue("InternetGatewayDevice.LANDevice.1.WLANConfiguration.", expander.FaultInvalidParameterName))
+	})
+
+	It("should keep faults across a state round trip", func() {
+		exp.Next()
+		Expect(exp.RegisterFault("InternetGatewayDevice.LANDevice.", expander.FaultInvalidParameterName)).To(Succeed())
+
+		data, err := exp.MarshalState()
+		Expect(err).NotTo(HaveOccurred())
+		restored := expander.Get()
+		defer expander.Release(restored)
+		Expect(restored.UnmarshalState(data)).To(Succeed())
+		Expect(restored.Faults()).To(Equal(exp.Faults()))
+		Expect(restored.Collect()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,222 @@
+// Package filecache persists expander discoveries on disk, one file per
+// device, so discovery knowledge survives restarts of the ACS.
+package filecache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+)
+
+// fileSuffix is the extension of device cache files
+const fileSuffix = ".json"
+
+// Store is a directory of device caches. It is safe for concurrent use.
+type Store struct {
+	dir string
+
+	mu      sync.Mutex
+	devices map[string]*Cache
+}
+
+// Open opens the store in dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &Store{
+		dir:     dir,
+		devices: make(map[string]*Cache),
+	}, nil
+}
+
+// Device returns the cache of a device, loading it from disk the first time.
+// Every call for the same device returns the same cache.
+func (s *Store) Device(deviceID string) (*Cache, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cache, ok := s.devices[deviceID]; ok {
+		return cache, nil
+	}
+
+	cache := &Cache{
+		file:     filepath.Join(s.dir, url.PathEscape(deviceID)+fileSuffix),
+		deviceID: deviceID,
+		entries:  make(map[string][]int),
+	}
+	data, err := os.ReadFile(cache.file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read cache of %s: %w", deviceID, err)
+	default:
+		var stored cacheFile
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode cache of %s: %w", deviceID, err)
+		}
+		if stored.DeviceID != deviceID {
+			return nil, fmt.Errorf("%w: cache file %s holds %q, not %q",
+				expander.ErrDeviceMismatch, cache.file, stored.DeviceID, deviceID)
+		}
+		if stored.Entries != nil {
+			cache.entries = stored.Entries
+		}
+	}
+
+	s.devices[deviceID] = cache
+	return cache, nil
+}
+
+// Flush writes the pending changes of every device cache to disk and
+// returns the first error.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, cache := range s.devices {
+		errs = append(errs, cache.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes every device cache. The store must not be used afterwards.
+func (s *Store) Close() error {
+	return s.Flush()
+}
+
+// Remove deletes the cache of a device from memory and disk.
+func (s *Store) Remove(deviceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.devices, deviceID)
+	err := os.Remove(filepath.Join(s.dir, url.PathEscape(deviceID)+fileSuffix))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Cache is an expander.Cache for a single device. Changes are kept in
+// memory until Flush or Close writes them to disk, so an expansion costs one
+// write instead of one per discovery. It is safe for concurrent use.
+type Cache struct {
+	file     string
+	deviceID string
+
+	mu      sync.RWMutex
+	entries map[string][]int
+	dirty   bool
+	err     error
+}
+
+// cacheFile is the on-disk form of a device cache
+type cacheFile struct {
+	DeviceID string           `json:"deviceId"`
+	Entries  map[string][]int `json:"entries"`
+}
+
+var _ expander.Cache = (*Cache)(nil)
+
+// DeviceID returns the device the cache belongs to.
+func (c *Cache) DeviceID() string {
+	return c.deviceID
+}
+
+// Get returns the indices cached for a discovery path.
+func (c *Cache) Get(path string) ([]int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	indices, ok := c.entries[path]
+	return indices, ok
+}
+
+// Put stores the indices discovered for a discovery path.
+func (c *Cache) Put(path string, indices []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[path] = slices.Clone(indices)
+	c.dirty = true
+}
+
+// Invalidate drops every entry whose path starts with prefix.
+func (c *Cache) Invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		if strings.HasPrefix(path, prefix) {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+	}
+}
+
+// Flush atomically replaces the cache file with the current entries, if
+// they changed since the last successful flush.
+func (c *Cache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+	c.err = c.write()
+	c.dirty = c.err != nil
+	return c.err
+}
+
+// Close flushes the cache. The cache may still be used afterwards.
+func (c *Cache) Close() error {
+	return c.Flush()
+}
+
+// Err returns the error of the last failed flush, or nil if the last flush
+// succeeded.
+func (c *Cache) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.err
+}
+
+// write encodes the entries into a temporary file, synced to disk before it
+// is renamed over the cache file
+func (c *Cache) write() error {
+	data, err := json.Marshal(cacheFile{DeviceID: c.deviceID, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.file), filepath.Base(c.file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.file)
+}
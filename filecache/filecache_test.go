@@ -0,0 +1,122 @@
+package filecache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	"github.com/metalgrid/tr069-path-expander/v2/filecache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFileCache(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "File Cache Suite")
+}
+
+var _ = Describe("File Cache", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should keep discoveries across restarts", func() {
+		store, err := filecache.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		cache, err := store.Device("00259E-HG8245H-4857544300000001")
+		Expect(err).NotTo(HaveOccurred())
+
+		exp := expander.Get(expander.WithCache(cache))
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1", path + "2"})).To(Succeed())
+		expander.Release(exp)
+		Expect(cache.Flush()).To(Succeed())
+		Expect(cache.Err()).NotTo(HaveOccurred())
+
+		// A new store simulates a restarted ACS
+		restarted, err := filecache.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		cache, err = restarted.Device("00259E-HG8245H-4857544300000001")
+		Expect(err).NotTo(HaveOccurred())
+
+		exp = expander.Get(expander.WithCache(cache))
+		defer expander.Release(exp)
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.SSID.1.SSID", "Device.WiFi.SSID.2.SSID"}))
+	})
+
+	It("should keep devices apart and share caches per device", func() {
+		store, err := filecache.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+
+		first, err := store.Device("cpe/1")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := store.Device("cpe/2")
+		Expect(err).NotTo(HaveOccurred())
+		again, err := store.Device("cpe/1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(BeIdenticalTo(first))
+
+		first.Put("Device.Hosts.Host.", []int{1, 2})
+		_, ok := second.Get("Device.Hosts.Host.")
+		Expect(ok).To(BeFalse())
+
+		first.Invalidate("Device.Hosts.")
+		_, ok = first.Get("Device.Hosts.Host.")
+		Expect(ok).To(BeFalse())
+
+		Expect(store.Remove("cpe/1")).To(Succeed())
+		Expect(store.Remove("cpe/3")).To(Succeed())
+		entries, err := os.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("should write changes only when flushed", func() {
+		store, err := filecache.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		cache, err := store.Device("cpe")
+		Expect(err).NotTo(HaveOccurred())
+
+		cache.Put("Device.Hosts.Host.", []int{1, 2})
+		cache.Put("Device.WiFi.SSID.", []int{1})
+		Expect(filepath.Join(dir, "cpe.json")).NotTo(BeAnExistingFile())
+
+		Expect(store.Close()).To(Succeed())
+		restarted, err := filecache.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		cache, err = restarted.Device("cpe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cache.DeviceID()).To(Equal("cpe"))
+		indices, ok := cache.Get("Device.Hosts.Host.")
+		Expect(ok).To(BeTrue())
+		Expect(indices).To(Equal([]int{1, 2}))
+	})
+
+	It("should reject a cache file of another device", func() {
+		store, err := filecache.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		cache, err := store.Device("cpe-1")
+		Expect(err).NotTo(HaveOccurred())
+		cache.Put("Device.Hosts.Host.", []int{1})
+		Expect(cache.Close()).To(Succeed())
+
+		Expect(os.Rename(filepath.Join(dir, "cpe-1.json"), filepath.Join(dir, "cpe-2.json"))).To(Succeed())
+		_, err = store.Device("cpe-2")
+		Expect(err).To(MatchError(expander.ErrDeviceMismatch))
+	})
+
+	It("should report corrupt cache files", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "cpe.json"), []byte("{"), 0o644)).To(Succeed())
+		store, err := filecache.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = store.Device("cpe")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,242 @@
+package expander
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Filter restricts the instances a pattern expands to those whose parameter
+// Param, relative to the instance, has the value Equals. It mirrors a USP
+// search expression such as "Device.WiFi.AccessPoint.[Enable==true].".
+type Filter struct {
+	// Param is the parameter tested, relative to the instance, e.g. "Enable"
+	// or "Security.ModeEnabled"
+	Param string `json:"param"`
+
+	// Equals is the value the parameter must have
+	Equals string `json:"equals"`
+}
+
+// AddFiltered adds a pattern whose last wildcard only expands to instances
+// matching filter. The values needed to evaluate the filter are requested
+// through NextValueQuery once the instances are discovered, and the
+// expansion completes once they are registered with RegisterValues.
+//
+// Every addition of a pattern counts on its own: a pattern added with
+// several filters expands to the instances matching any of them, and one
+// also added with Add, before or after, to every instance.
+func (e *Expander) AddFiltered(pattern string, filter Filter) error {
+	if filter.Param == "" || wildcardDepth(pattern) == -1 {
+		return fmt.Errorf("%w: filter on %s needs a wildcard and a parameter", ErrInvalidPath, pattern)
+	}
+
+	_, known := e.patternPaths[pattern]
+	filters, filtered := e.filters[pattern]
+	if err := e.add(e.attribute(""), []string{pattern}); err != nil {
+		return err
+	}
+	if known && !filtered {
+		return nil
+	}
+	if !slices.Contains(filters, filter) {
+		filters = append(filters, filter)
+	}
+	e.filters[pattern] = filters
+	return nil
+}
+
+// NextValueQuery returns the parameter names whose values are needed to
+// evaluate the filters and search expressions of the instances discovered
+// so far, to be fetched in a single GetParameterValues call and registered
+// with RegisterValues. The query is cut to fit the envelope size of the
+// driver capabilities, if set. It returns nil if no value is needed at this
+// point.
+func (e *Expander) NextValueQuery() []string {
+	query := e.fitEnvelope(e.neededValues())
+	for _, name := range query {
+		e.valueQueries[name] = true
+	}
+	return query
+}
+
+// RegisterValues registers the values fetched for a value query. Names
+// queried but missing from values, e.g. because the device faulted on them,
+// are treated as not matching their filter.
+func (e *Expander) RegisterValues(values map[string]string) error {
+	if len(values) == 0 && len(e.valueQueries) == 0 {
+		return ErrEmptyResults
+	}
+	for name, value := range values {
+		name = e.canonicalValueName(name)
+		e.values[name] = value
+		delete(e.valueQueries, name)
+	}
+	for name := range e.valueQueries {
+		e.missingValues[name] = true
+		delete(e.valueQueries, name)
+	}
+
+	if len(e.pendingDiscoveries) == 0 && len(e.outstanding) == 0 {
+		e.finish()
+	}
+	return nil
+}
+
+// requeueValues forgets an unanswered value query so its names are queried again
+func (e *Expander) requeueValues(query []string) {
+	for _, name := range query {
+		delete(e.valueQueries, name)
+	}
+}
+
+// condition is a test on a parameter of the instances bound at one
+// wildcard position of a pattern
+type condition struct {
+	// depth is the index of the wildcard segment in the pattern
+	depth int
+
+	param string
+	op    string
+	value string
+}
+
+// holds reports whether a parameter value satisfies the condition. Order
+// comparisons are numeric when both sides are numbers, lexical otherwise.
+func (c condition) holds(value string) bool {
+	cmp := strings.Compare(value, c.value)
+	x, errX := strconv.ParseFloat(value, 64)
+	y, errY := strconv.ParseFloat(c.value, 64)
+	if errX == nil && errY == nil {
+		cmp = cmpFloat(x, y)
+	}
+
+	switch c.op {
+	case "==":
+		return value == c.value
+	case "!=":
+		return value != c.value
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// cmpFloat compares two numbers
+func cmpFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// name returns the name of the parameter tested for the instance an
+// expanded path belongs to
+func (c condition) name(path string) string {
+	object, _ := splitAfterSegments(path+".", c.depth+1)
+	return object + c.param
+}
+
+// condition returns the condition a filter puts on the instances bound at
+// depth
+func (f Filter) condition(depth int) condition {
+	return condition{depth: depth, param: f.Param, op: "==", value: f.Equals}
+}
+
+// conditions returns every condition whose values are needed to restrict
+// the instances of a pattern: its search expressions and its filters
+func (e *Expander) conditions(pattern string) []condition {
+	conditions := e.searches[pattern]
+	if filters := e.filters[pattern]; len(filters) > 0 {
+		conditions = slices.Clip(conditions)
+		depth := wildcardDepth(pattern)
+		for _, filter := range filters {
+			conditions = append(conditions, filter.condition(depth))
+		}
+	}
+	return conditions
+}
+
+// neededValues lists the condition parameters of the expanded instances
+// that are neither known nor queried yet
+func (e *Expander) neededValues() []string {
+	if len(e.filters) == 0 && len(e.searches) == 0 {
+		return nil
+	}
+
+	byPattern := make(map[string][]condition)
+	seen := make(map[string]bool)
+	var needed []string
+	e.walk(treeVisitor{
+		leaf: func(path, pattern string) {
+			conditions, ok := byPattern[pattern]
+			if !ok {
+				conditions = e.conditions(pattern)
+				byPattern[pattern] = conditions
+			}
+			for _, c := range conditions {
+				name := c.name(path)
+				if _, known := e.values[name]; known || e.missingValues[name] || e.valueQueries[name] || seen[name] {
+					continue
+				}
+				seen[name] = true
+				needed = append(needed, name)
+			}
+		},
+	})
+	slices.Sort(needed)
+	return needed
+}
+
+// valuesPending reports whether condition values are still needed or queried
+func (e *Expander) valuesPending() bool {
+	return len(e.valueQueries) > 0 || len(e.neededValues()) > 0
+}
+
+// matchesFilter reports whether an expanded path belongs to instances
+// satisfying every search expression of its pattern and any of its filters
+func (e *Expander) matchesFilter(path, pattern string) bool {
+	for _, c := range e.searches[pattern] {
+		if !e.satisfies(c, path) {
+			return false
+		}
+	}
+
+	filters := e.filters[pattern]
+	if len(filters) == 0 {
+		return true
+	}
+	depth := wildcardDepth(pattern)
+	return slices.ContainsFunc(filters, func(filter Filter) bool {
+		return e.satisfies(filter.condition(depth), path)
+	})
+}
+
+// satisfies reports whether the instance an expanded path belongs to is
+// known to satisfy a condition
+func (e *Expander) satisfies(c condition, path string) bool {
+	value, known := e.values[c.name(path)]
+	return known && c.holds(value)
+}
+
+// wildcardDepth returns the index of the last wildcard segment of a
+// pattern, or -1 if it has none
+func wildcardDepth(pattern string) int {
+	segments := splitSegments(pattern)
+	for i := len(segments) - 1; i >= 0; i-- {
+		if isBindingSegment(segments[i]) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,111 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filtered Expansion", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should only expand instances whose value matches", func() {
+		err := exp.AddFiltered("Device.WiFi.AccessPoint.*.SSIDReference", expander.Filter{Param: "Enable", Equals: "true"})
+		Expect(err).NotTo(HaveOccurred())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "2.", path + "3."})).To(Succeed())
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+
+		_, err = exp.Collect()
+		Expect(err).To(MatchError(ContainSubstring("filter values are needed")))
+
+		query := exp.NextValueQuery()
+		Expect(query).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.2.Enable",
+			"Device.WiFi.AccessPoint.3.Enable",
+		}))
+		Expect(exp.NextValueQuery()).To(BeEmpty())
+
+		// The device faulted on the third instance
+		Expect(exp.RegisterValues(map[string]string{
+			"Device.WiFi.AccessPoint.1.Enable": "true",
+			"Device.WiFi.AccessPoint.2.Enable": "false",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.AccessPoint.1.SSIDReference"}))
+	})
+
+	It("should filter on the innermost wildcard with a nested parameter", func() {
+		err := exp.AddFiltered("Device.Hosts.Host.*.IPv4Address.*.IPAddress",
+			expander.Filter{Param: "Status.Active", Equals: "1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exp.Add("Device.Hosts.Host.*.HostName")).To(Succeed())
+
+		drainDiscoveries(exp)
+		Expect(exp.NextValueQuery()).To(Equal([]string{"Device.Hosts.Host.1.IPv4Address.1.Status.Active"}))
+		Expect(exp.RegisterValues(map[string]string{"Device.Hosts.Host.1.IPv4Address.1.Status.Active": "0"})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.Hosts.Host.1.HostName"}))
+	})
+
+	It("should expand the instances matching any filter of a pattern", func() {
+		const pattern = "Device.WiFi.AccessPoint.*.SSIDReference"
+		Expect(exp.AddFiltered(pattern, expander.Filter{Param: "Enable", Equals: "true"})).To(Succeed())
+		Expect(exp.AddFiltered(pattern, expander.Filter{Param: "Status", Equals: "Error"})).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "2.", path + "3."})).To(Succeed())
+		Expect(exp.NextValueQuery()).To(HaveLen(6))
+		Expect(exp.RegisterValues(map[string]string{
+			"Device.WiFi.AccessPoint.1.Enable": "true",
+			"Device.WiFi.AccessPoint.1.Status": "Enabled",
+			"Device.WiFi.AccessPoint.2.Enable": "false",
+			"Device.WiFi.AccessPoint.2.Status": "Error",
+			"Device.WiFi.AccessPoint.3.Enable": "false",
+			"Device.WiFi.AccessPoint.3.Status": "Disabled",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.SSIDReference",
+			"Device.WiFi.AccessPoint.2.SSIDReference",
+		}))
+	})
+
+	DescribeTable("a pattern also added without a filter",
+		func(plainFirst bool) {
+			const pattern = "Device.WiFi.AccessPoint.*.SSIDReference"
+			if plainFirst {
+				Expect(exp.Add(pattern)).To(Succeed())
+			}
+			Expect(exp.AddFiltered(pattern, expander.Filter{Param: "Enable", Equals: "true"})).To(Succeed())
+			if !plainFirst {
+				Expect(exp.Add(pattern)).To(Succeed())
+			}
+
+			path, _ := exp.Next()
+			Expect(exp.Register([]string{path + "1.", path + "2."})).To(Succeed())
+			Expect(exp.NextValueQuery()).To(BeEmpty())
+			Expect(exp.Collect()).To(HaveLen(2))
+		},
+		Entry("before the filtered one", true),
+		Entry("after the filtered one", false),
+	)
+
+	It("should reject filters without a wildcard or a parameter", func() {
+		Expect(exp.AddFiltered("Device.WiFi.AccessPoint.1.Enable", expander.Filter{Param: "Enable"})).
+			To(MatchError(expander.ErrInvalidPath))
+		Expect(exp.AddFiltered("Device.WiFi.AccessPoint.*.Enable", expander.Filter{})).
+			To(MatchError(expander.ErrInvalidPath))
+	})
+})
@@ -0,0 +1,243 @@
+// Package genieacs implements the expander's Discoverer over the GenieACS
+// northbound interface (NBI), for ACS deployments fronted by GenieACS.
+//
+// GenieACS has no task issuing a bare GetParameterNames; discoveries are
+// run as refreshObject tasks, which make GenieACS walk the object on the
+// device, and the names are then read from the device document it stores.
+// Tasks the device does not complete within the request are polled until
+// GenieACS removes them, or until a fault is recorded for them.
+package genieacs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval is the interval between two polls of a pending task
+// unless Discoverer.PollInterval says otherwise
+const DefaultPollInterval = time.Second
+
+// ErrDeviceNotFound is returned when GenieACS has no document for the device
+var ErrDeviceNotFound = errors.New("device not found in GenieACS")
+
+// StatusError reports an NBI request answered with an unexpected HTTP status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("genieacs: HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// HTTPStatus returns the HTTP status, for expander.IsTransient.
+func (e *StatusError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// TaskFault reports a task that failed on the device or in GenieACS.
+type TaskFault struct {
+	// Code is the fault code recorded by GenieACS, e.g. "cwmp.9005"
+	Code string
+
+	// Message describes the fault
+	Message string
+}
+
+func (f *TaskFault) Error() string {
+	return fmt.Sprintf("genieacs: task fault %s: %s", f.Code, f.Message)
+}
+
+// FaultCode returns the CWMP fault code of a "cwmp.<code>" fault, for
+// expander.IsTransient, or 0 for faults raised by GenieACS itself.
+func (f *TaskFault) FaultCode() int {
+	code, _ := strconv.Atoi(strings.TrimPrefix(f.Code, "cwmp."))
+	return code
+}
+
+// Discoverer issues discoveries for one device through the GenieACS NBI.
+type Discoverer struct {
+	// BaseURL is the address of the NBI, e.g. "http://genieacs:7557"
+	BaseURL string
+
+	// DeviceID is the GenieACS ID of the device
+	DeviceID string
+
+	// Client issues the HTTP requests; nil means http.DefaultClient
+	Client *http.Client
+
+	// PollInterval is the interval between two polls of a pending task;
+	// zero means DefaultPollInterval
+	PollInterval time.Duration
+
+	// ConnectionRequest makes GenieACS wake the device up for every task
+	ConnectionRequest bool
+}
+
+// New returns a discoverer for a device behind the NBI at baseURL, waking
+// the device up with a connection request for every task.
+func New(baseURL, deviceID string) *Discoverer {
+	return &Discoverer{BaseURL: baseURL, DeviceID: deviceID, ConnectionRequest: true}
+}
+
+// GetParameterNames refreshes the object at path on the device and returns
+// the names listed directly below it, objects with a trailing dot.
+func (d *Discoverer) GetParameterNames(ctx context.Context, path string) ([]string, error) {
+	taskID, done, err := d.refresh(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		if err := d.await(ctx, taskID); err != nil {
+			return nil, err
+		}
+	}
+	return d.names(ctx, path)
+}
+
+// refresh creates a refreshObject task, reporting whether it completed
+// within the request
+func (d *Discoverer) refresh(ctx context.Context, path string) (string, bool, error) {
+	body, _ := json.Marshal(map[string]string{"name": "refreshObject", "objectName": path})
+	endpoint := d.BaseURL + "/devices/" + url.PathEscape(d.DeviceID) + "/tasks"
+	if d.ConnectionRequest {
+		endpoint += "?connection_request"
+	}
+
+	var task struct {
+		ID string `json:"_id"`
+	}
+	status, err := d.do(ctx, http.MethodPost, endpoint, body, &task, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return "", false, err
+	}
+	return task.ID, status == http.StatusOK, nil
+}
+
+// await polls a pending task until GenieACS removes it, which it does once
+// the task completed, or until a fault is recorded for it
+func (d *Discoverer) await(ctx context.Context, taskID string) error {
+	interval := d.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	tasks := d.BaseURL + "/tasks/?query=" + query("_id", taskID)
+	faults := d.BaseURL + "/faults/?query=" + query("_id", d.DeviceID+":task_"+taskID)
+	for {
+		var recorded []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if _, err := d.do(ctx, http.MethodGet, faults, nil, &recorded, http.StatusOK); err != nil {
+			return err
+		}
+		if len(recorded) > 0 {
+			return &TaskFault{Code: recorded[0].Code, Message: recorded[0].Message}
+		}
+
+		var pending []json.RawMessage
+		if _, err := d.do(ctx, http.MethodGet, tasks, nil, &pending, http.StatusOK); err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// names reads the names below path from the device document
+func (d *Discoverer) names(ctx context.Context, path string) ([]string, error) {
+	object := strings.TrimSuffix(path, ".")
+	endpoint := d.BaseURL + "/devices/?query=" + query("_id", d.DeviceID) +
+		"&projection=" + url.QueryEscape(object)
+
+	var devices []map[string]any
+	if _, err := d.do(ctx, http.MethodGet, endpoint, nil, &devices, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, d.DeviceID)
+	}
+
+	var node any = devices[0]
+	for segment := range strings.SplitSeq(object, ".") {
+		children, _ := node.(map[string]any)
+		if node = children[segment]; node == nil {
+			return nil, nil
+		}
+	}
+	children, _ := node.(map[string]any)
+
+	var names []string
+	for key, child := range children {
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+		attributes, _ := child.(map[string]any)
+		if isObject, _ := attributes["_object"].(bool); isObject {
+			names = append(names, object+"."+key+".")
+		} else {
+			names = append(names, object+"."+key)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// do issues an NBI request and decodes its JSON answer into out
+func (d *Discoverer) do(ctx context.Context, method, endpoint string, body []byte, out any, expected ...int) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(expected, resp.StatusCode) {
+		return 0, &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return 0, fmt.Errorf("genieacs: malformed answer to %s %s: %w", method, endpoint, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// query returns an escaped NBI query matching a field
+func query(field, value string) string {
+	q, _ := json.Marshal(map[string]string{field: value})
+	return url.QueryEscape(string(q))
+}
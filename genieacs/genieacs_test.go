@@ -0,0 +1,178 @@
+package genieacs_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	"github.com/metalgrid/tr069-path-expander/v2/genieacs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGenieACS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenieACS Suite")
+}
+
+const deviceDocument = `{
+	"_id": "00259E-HG8245-1234",
+	"Device": {
+		"_object": true,
+		"WiFi": {
+			"_object": true,
+			"SSIDNumberOfEntries": {"_value": 2, "_writable": false},
+			"SSID": {
+				"_object": true,
+				"_writable": true,
+				"1": {"_object": true, "SSID": {"_value": "home"}},
+				"3": {"_object": true, "SSID": {"_value": "guest"}}
+			}
+		}
+	}
+}`
+
+// nbi is a fake GenieACS NBI
+type nbi struct {
+	mu sync.Mutex
+
+	// pendingPolls is the number of polls a task stays pending
+	pendingPolls int
+
+	// fault is recorded for every task, if set
+	fault string
+
+	// status overrides the status of task creation, if set
+	status int
+
+	tasks []map[string]string
+}
+
+func (n *nbi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/tasks"):
+		if n.status != 0 {
+			w.WriteHeader(n.status)
+			_, _ = w.Write([]byte("busy"))
+			return
+		}
+		var task map[string]string
+		Expect(json.NewDecoder(r.Body).Decode(&task)).To(Succeed())
+		task["url"] = r.URL.String()
+		n.tasks = append(n.tasks, task)
+
+		if n.pendingPolls == 0 && n.fault == "" {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		_, _ = w.Write([]byte(`{"_id": "t1", "name": "refreshObject"}`))
+	case r.URL.Path == "/faults/":
+		Expect(r.URL.Query().Get("query")).To(Equal(`{"_id":"00259E-HG8245-1234:task_t1"}`))
+		if n.fault != "" {
+			_, _ = w.Write([]byte(`[{"code": "cwmp.9005", "message": "` + n.fault + `"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	case r.URL.Path == "/tasks/":
+		if n.pendingPolls > 0 {
+			n.pendingPolls--
+			_, _ = w.Write([]byte(`[{"_id": "t1"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	case r.URL.Path == "/devices/":
+		if r.URL.Query().Get("query") != `{"_id":"00259E-HG8245-1234"}` {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte("[" + deviceDocument + "]"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var _ = Describe("GenieACS Discoverer", func() {
+	var (
+		fake       *nbi
+		server     *httptest.Server
+		discoverer *genieacs.Discoverer
+	)
+
+	BeforeEach(func() {
+		fake = &nbi{}
+		server = httptest.NewServer(fake)
+		discoverer = genieacs.New(server.URL, "00259E-HG8245-1234")
+		discoverer.PollInterval = time.Millisecond
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list the names below a refreshed object", func() {
+		Expect(discoverer.GetParameterNames(context.Background(), "Device.WiFi.")).To(Equal([]string{
+			"Device.WiFi.SSID.",
+			"Device.WiFi.SSIDNumberOfEntries",
+		}))
+		Expect(fake.tasks).To(Equal([]map[string]string{{
+			"name":       "refreshObject",
+			"objectName": "Device.WiFi.",
+			"url":        "/devices/00259E-HG8245-1234/tasks?connection_request",
+		}}))
+
+		Expect(discoverer.GetParameterNames(context.Background(), "Device.Hosts.")).To(BeEmpty())
+	})
+
+	It("should poll tasks the device has not completed yet", func() {
+		fake.pendingPolls = 2
+		Expect(discoverer.GetParameterNames(context.Background(), "Device.WiFi.SSID.")).To(Equal([]string{
+			"Device.WiFi.SSID.1.",
+			"Device.WiFi.SSID.3.",
+		}))
+		Expect(fake.pendingPolls).To(BeZero())
+	})
+
+	It("should drive a session", func() {
+		session := expander.NewSession(discoverer)
+		defer session.Close()
+		Expect(session.Expander().Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+
+		Expect(session.Run(context.Background())).To(Equal([]string{
+			"Device.WiFi.SSID.1.SSID",
+			"Device.WiFi.SSID.3.SSID",
+		}))
+	})
+
+	It("should report faults and HTTP errors", func() {
+		fake.fault = "Invalid parameter name"
+		_, err := discoverer.GetParameterNames(context.Background(), "Device.WLAN.")
+		var fault *genieacs.TaskFault
+		Expect(err).To(BeAssignableToTypeOf(fault))
+		Expect(err).To(MatchError("genieacs: task fault cwmp.9005: Invalid parameter name"))
+		Expect(err.(*genieacs.TaskFault).FaultCode()).To(Equal(9005))
+
+		fake.status = http.StatusServiceUnavailable
+		_, err = discoverer.GetParameterNames(context.Background(), "Device.WiFi.")
+		Expect(err).To(Equal(&genieacs.StatusError{StatusCode: http.StatusServiceUnavailable, Body: "busy"}))
+		Expect(expander.IsTransient(err)).To(BeTrue())
+	})
+
+	It("should report unknown devices", func() {
+		unknown := genieacs.New(server.URL, "00259E-HG8245-1234")
+		unknown.DeviceID = "missing"
+		unknown.ConnectionRequest = false
+		_, err := unknown.GetParameterNames(context.Background(), "Device.")
+		Expect(err).To(MatchError(genieacs.ErrDeviceNotFound))
+		Expect(fake.tasks[0]["url"]).To(Equal("/devices/missing/tasks"))
+	})
+})
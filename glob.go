@@ -0,0 +1,95 @@
+package expander
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// WithGlobSegments lets a segment embedding wildcards, such as "*Service" in
+// "Device.Services.*Service.*.Enable", match every child name registered for
+// its parent, numeric or not. This enables expansion over heterogeneous
+// vendor subtrees. Only "*" is special in a glob segment; it matches any run
+// of characters. Child names are kept by the expander itself, not in the
+// Cache, so a glob segment whose parent was resolved from a shared cache
+// matches nothing until its parent is discovered again.
+//
+// Without this option, such patterns are rejected with ErrEmbeddedWildcard.
+func WithGlobSegments() Option {
+	return func(e *Expander) {
+		e.config.globSegments = true
+	}
+}
+
+// isGlobSegment reports whether a segment embeds a wildcard, other than
+// in an index exclusion such as "[*-2]" or the recursive wildcard "**"
+func isGlobSegment(segment string) bool {
+	if segment == "*" || segment == "**" || !strings.Contains(segment, "*") {
+		return false
+	}
+	selector, err := parseIndexSelector(segment)
+	return selector == nil && err == nil
+}
+
+// matchGlob reports whether name matches a glob segment where "*" matches
+// any run of characters
+func matchGlob(glob, name string) bool {
+	parts := strings.Split(glob, "*")
+
+	// The first and last parts are anchored at the ends of the name
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i == -1 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return len(name) >= len(last) && strings.HasSuffix(name, last)
+}
+
+// childNames returns the child names registered for a discovery path. They
+// are resolved along with the indices of the path.
+func (e *Expander) childNames(discoveryPath string) ([]string, bool) {
+	if _, cached := e.cache.Get(discoveryPath); !cached {
+		return nil, false
+	}
+	return e.names[discoveryPath], true
+}
+
+// extractChildNames returns the distinct segments listed directly below the
+// discovery path, sorted. Object names keep their trailing dot, e.g. "Radio."
+// next to the parameter "RadioNumberOfEntries".
+func extractChildNames(discoveryPath string, parameterNames []string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, param := range parameterNames {
+		remainder, ok := strings.CutPrefix(param, discoveryPath)
+		if !ok {
+			continue
+		}
+		segment, _, isObject := strings.Cut(remainder, ".")
+		if segment == "" {
+			continue
+		}
+		if isObject {
+			segment += "."
+		}
+		if !seen[segment] {
+			seen[segment] = true
+			names = append(names, segment)
+		}
+	}
+
+	// An object listed both with and without its trailing dot is an object
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return !strings.HasSuffix(name, ".") && seen[name+"."]
+	})
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,87 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Glob Segments", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get(expander.WithGlobSegments())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should expand over child names matching the glob", func() {
+		Expect(exp.Add("Device.Services.*Service.*.Enable")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.Services."))
+		Expect(exp.Register([]string{
+			"Device.Services.VoiceService.",
+			"Device.Services.StorageService.",
+			"Device.Services.VoiceServiceNumberOfEntries",
+			"Device.Services.X_ACME_IPTV.",
+		})).To(Succeed())
+
+		var discovered []string
+		for {
+			path, hasMore := exp.Next()
+			if !hasMore {
+				break
+			}
+			discovered = append(discovered, path)
+			Expect(exp.Register([]string{path + "1"})).To(Succeed())
+		}
+		Expect(discovered).To(ConsistOf(
+			"Device.Services.StorageService.",
+			"Device.Services.VoiceService.",
+		))
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.Services.StorageService.1.Enable",
+			"Device.Services.VoiceService.1.Enable",
+		}))
+	})
+
+	It("should match parameter names with a leaf glob", func() {
+		Expect(exp.Add("Device.DeviceInfo.*Version")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.DeviceInfo."))
+		Expect(exp.Register([]string{
+			"Device.DeviceInfo.SoftwareVersion",
+			"Device.DeviceInfo.HardwareVersion",
+			"Device.DeviceInfo.UpTime",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.DeviceInfo.HardwareVersion",
+			"Device.DeviceInfo.SoftwareVersion",
+		}))
+	})
+
+	It("should match globs with several wildcards", func() {
+		Expect(exp.Add("Device.X_*_Diag*.Enable")).To(Succeed())
+
+		_, _ = exp.Next()
+		Expect(exp.Register([]string{
+			"Device.X_ACME_Diagnostics.",
+			"Device.X_ACME_Debug.",
+			"Device.X_Diag.",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.X_ACME_Diagnostics.Enable"}))
+	})
+
+	It("should still reject embedded wildcards without the option", func() {
+		plain := expander.Get()
+		defer expander.Release(plain)
+		Expect(plain.Add("Device.Services.*Service.*.Enable")).To(MatchError(expander.ErrEmbeddedWildcard))
+	})
+})
@@ -0,0 +1,123 @@
+package expander
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxLiteralIndices bounds how many instance numbers an index selector may
+// enumerate without discovering its table
+const maxLiteralIndices = 256
+
+// indexSelector constrains a wildcard position to some instance numbers,
+// written in brackets in place of the wildcard: a range such as "[1-8]", a
+// set such as "[1,3,7]", or a set of ranges such as "[1-3,7]". An exclusion
+// such as "[*-2]" or "[*-2,5]" selects every instance but the listed ones.
+type indexSelector struct {
+	ranges []indexRange
+
+	// exclude selects the instances outside of ranges
+	exclude bool
+}
+
+// indexRange is an inclusive range of instance numbers
+type indexRange struct {
+	lo, hi int
+}
+
+// parseIndexSelector parses a bracketed index selector. It returns nil for
+// segments that are not selectors, such as "*" or an alias like "[guest]".
+func parseIndexSelector(segment string) (*indexSelector, error) {
+	if len(segment) < 3 || segment[0] != '[' || segment[len(segment)-1] != ']' {
+		return nil, nil
+	}
+	body := segment[1 : len(segment)-1]
+	selector := &indexSelector{}
+	if rest, found := strings.CutPrefix(body, "*-"); found {
+		selector.exclude = true
+		body = rest
+	}
+	if strings.Trim(body, "0123456789-,") != "" {
+		return nil, nil
+	}
+	if !selector.exclude && !strings.ContainsAny(body, "-,") {
+		return nil, nil
+	}
+
+	for item := range strings.SplitSeq(body, ",") {
+		r, err := parseIndexRange(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid index selector %s", ErrInvalidPath, segment)
+		}
+		selector.ranges = append(selector.ranges, r)
+	}
+	return selector, nil
+}
+
+// parseIndexRange parses a single index or an inclusive range of indices
+func parseIndexRange(item string) (indexRange, error) {
+	lo, hi, isRange := strings.Cut(item, "-")
+	first, err := strconv.Atoi(lo)
+	if err != nil {
+		return indexRange{}, err
+	}
+	if !isRange {
+		return indexRange{lo: first, hi: first}, nil
+	}
+
+	last, err := strconv.Atoi(hi)
+	if err != nil {
+		return indexRange{}, err
+	}
+	if first > last {
+		return indexRange{}, ErrInvalidPath
+	}
+	return indexRange{lo: first, hi: last}, nil
+}
+
+// matches reports whether an instance segment is selected
+func (s *indexSelector) matches(instance string) bool {
+	index, err := strconv.Atoi(instance)
+	if err != nil {
+		return false
+	}
+	for _, r := range s.ranges {
+		if index >= r.lo && index <= r.hi {
+			return !s.exclude
+		}
+	}
+	return s.exclude
+}
+
+// enumerate lists the selected instance numbers, or returns false if they
+// cannot be addressed without discovering the table
+func (s *indexSelector) enumerate() ([]string, bool) {
+	if s.exclude {
+		return nil, false
+	}
+	// Each range is checked on its own first, so wide ones can't overflow
+	// the count
+	count := 0
+	for _, r := range s.ranges {
+		if r.hi-r.lo >= maxLiteralIndices {
+			return nil, false
+		}
+		count += r.hi - r.lo + 1
+		if count > maxLiteralIndices {
+			return nil, false
+		}
+	}
+
+	seen := make(map[int]bool, count)
+	instances := make([]string, 0, count)
+	for _, r := range s.ranges {
+		for index := r.lo; index <= r.hi; index++ {
+			if !seen[index] {
+				seen[index] = true
+				instances = append(instances, strconv.Itoa(index))
+			}
+		}
+	}
+	return instances, true
+}
@@ -0,0 +1,166 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Index Ranges", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+		exp = nil
+	})
+
+	It("should address a literal range without discovery", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.Radio.[1-3].Enable")).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.Radio.1.Enable",
+			"Device.WiFi.Radio.2.Enable",
+			"Device.WiFi.Radio.3.Enable",
+		}))
+	})
+
+	It("should intersect the range with discovered instances", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.AccessPoint.[1-8].Enable", "Device.WiFi.AccessPoint.*.Status")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+		Expect(exp.Register([]string{path + "2.", path + "9."})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.2.Enable",
+			"Device.WiFi.AccessPoint.2.Status",
+			"Device.WiFi.AccessPoint.9.Status",
+		}))
+	})
+
+	It("should discover the table under the validating literal policy", func() {
+		exp = expander.Get(expander.WithLiteralPolicy(expander.LiteralValidate))
+		Expect(exp.Add("Device.WiFi.AccessPoint.[1-8].AssociatedDevice.*.MACAddress")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+		Expect(exp.Register([]string{path + "3."})).To(Succeed())
+
+		path, _ = exp.Next()
+		Expect(path).To(Equal("Device.WiFi.AccessPoint.3.AssociatedDevice."))
+		Expect(exp.Register([]string{path + "1."})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.AccessPoint.3.AssociatedDevice.1.MACAddress"}))
+	})
+
+	It("should discover tables for ranges too wide to enumerate", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.Hosts.Host.[1-100000].IPAddress")).To(Succeed())
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.Hosts.Host."))
+	})
+
+	It("should discover tables for ranges up to the largest index", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.AccessPoint.[0-9223372036854775807].Enable")).To(Succeed())
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+	})
+
+	It("should reject inverted ranges", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.Radio.[3-1].Enable")).To(MatchError(expander.ErrInvalidPath))
+		Expect(exp.Check()).To(Succeed())
+	})
+})
+
+var _ = Describe("Index Sets", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+		exp = nil
+	})
+
+	It("should discover below the selected instances only", func() {
+		exp = expander.Get()
+		Expect(exp.Add("InternetGatewayDevice.LANDevice.[1,3].Hosts.Host.*.IPAddress")).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(ConsistOf(
+			"InternetGatewayDevice.LANDevice.1.Hosts.Host.",
+			"InternetGatewayDevice.LANDevice.3.Hosts.Host.",
+		))
+		Expect(exp.Collect()).To(Equal([]string{
+			"InternetGatewayDevice.LANDevice.1.Hosts.Host.1.IPAddress",
+			"InternetGatewayDevice.LANDevice.3.Hosts.Host.1.IPAddress",
+		}))
+	})
+
+	It("should share discoveries with overlapping patterns", func() {
+		exp = expander.Get()
+		err := exp.Add(
+			"InternetGatewayDevice.LANDevice.[1,3].Hosts.Host.*.IPAddress",
+			"InternetGatewayDevice.LANDevice.[3,5-6].Hosts.Host.*.MACAddress",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(drainDiscoveries(exp)).To(HaveLen(4))
+		Expect(exp.Collect()).To(HaveLen(5))
+	})
+
+	DescribeTable("malformed sets",
+		func(segment string) {
+			exp = expander.Get()
+			err := exp.Add("Device.WiFi.SSID." + segment + ".SSID")
+			Expect(err).To(MatchError(expander.ErrInvalidPath))
+		},
+		Entry("empty item", "[1,,3]"),
+		Entry("trailing comma", "[1,]"),
+		Entry("inverted range", "[1,5-2]"),
+		Entry("open range", "[1-]"),
+	)
+})
+
+var _ = Describe("Index Exclusions", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+		exp = nil
+	})
+
+	It("should expand every discovered instance but the excluded ones", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.SSID.[*-2,4].SSID")).To(Succeed())
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.WiFi.SSID."))
+		Expect(exp.Register([]string{path + "1.", path + "2.", path + "3.", path + "4."})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.SSID.1.SSID", "Device.WiFi.SSID.3.SSID"}))
+	})
+
+	It("should not discover below excluded instances", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.Ethernet.VLANTermination.[*-1].Stats.*.Value")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "2."})).To(Succeed())
+
+		Expect(drainDiscoveries(exp)).To(Equal([]string{"Device.Ethernet.VLANTermination.2.Stats."}))
+	})
+
+	It("should reject empty exclusions", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.SSID.[*-].SSID")).To(MatchError(expander.ErrInvalidPath))
+	})
+})
@@ -0,0 +1,102 @@
+package expander
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ParameterInfo mirrors a ParameterInfoStruct from a GetParameterNames
+// response: the parameter or object name and its Writable flag.
+type ParameterInfo struct {
+	Name     string
+	Writable bool
+}
+
+// CreatableObject is a multi-instance object on which AddObject is permitted,
+// along with the instances it currently holds.
+type CreatableObject struct {
+	Path      string
+	Instances []int
+}
+
+// RegisterInfo behaves like Register but also records the Writable flag of
+// every name in infos. Flags are recorded even for names outside the current
+// discovery path, so the writability of a table object reported by its
+// parent can be registered alongside its instances.
+func (e *Expander) RegisterInfo(infos []ParameterInfo) error {
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+
+	if err := e.Register(names); err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		e.writable[info.Name] = info.Writable
+	}
+	return nil
+}
+
+// CollectWritable returns the expanded paths registered as writable with
+// RegisterInfo, e.g. to build a SetParameterValues request. A parameter's
+// flag is only known if a discovery response listed it, as responses to a
+// trailing "*" or full-subtree responses do; otherwise the flag of the
+// schema set with WithSchema is used, if any. Paths whose flag is unknown
+// are left out. This should be called after Next() returns false.
+func (e *Expander) CollectWritable() ([]string, error) {
+	paths, err := e.Collect()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(paths, func(path string) bool {
+		return !e.isWritable(path)
+	}), nil
+}
+
+// isWritable reports whether a path is known to be writable
+func (e *Expander) isWritable(path string) bool {
+	if writable, known := e.writable[path]; known {
+		return writable
+	}
+	if e.config.schema == nil {
+		return false
+	}
+	_, parameter, ok := e.config.schema.Parameter(path)
+	return ok && parameter.Writable
+}
+
+// CollectCreatableObjects lists the discovered multi-instance objects that
+// were registered as writable, meaning AddObject is permitted on them, along
+// with their current instances. This should be called after Next() returns false.
+func (e *Expander) CollectCreatableObjects() ([]CreatableObject, error) {
+	if err := e.ensureComplete(); err != nil {
+		return nil, err
+	}
+
+	var objects []CreatableObject
+	seen := make(map[string]bool)
+	for name, writable := range e.writable {
+		// Table objects may be reported with or without their trailing dot
+		path := name
+		if !strings.HasSuffix(path, ".") {
+			path += "."
+		}
+		indices, cached := e.cache.Get(path)
+		if !writable || !cached || seen[path] {
+			continue
+		}
+		seen[path] = true
+		objects = append(objects, CreatableObject{
+			Path:      path,
+			Instances: append([]int(nil), indices...),
+		})
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Path < objects[j].Path
+	})
+	return objects, nil
+}
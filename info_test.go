@@ -0,0 +1,86 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parameter Info Registration", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should list creatable objects under wildcarded parents", func() {
+		err := exp.Add("Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.RegisterInfo([]expander.ParameterInfo{
+			{Name: "Device.WiFi.AccessPoint.", Writable: true},
+			{Name: "Device.WiFi.AccessPoint.1.", Writable: true},
+			{Name: "Device.WiFi.AccessPoint.2.", Writable: true},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.RegisterInfo([]expander.ParameterInfo{
+			{Name: "Device.WiFi.AccessPoint.1.AssociatedDevice.4.", Writable: false},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.RegisterInfo(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		objects, err := exp.CollectCreatableObjects()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects).To(Equal([]expander.CreatableObject{
+			{Path: "Device.WiFi.AccessPoint.", Instances: []int{1, 2}},
+		}))
+	})
+
+	It("should collect the writable expanded paths", func() {
+		Expect(exp.Add("Device.WiFi.SSID.*.*")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.RegisterInfo([]expander.ParameterInfo{{Name: path + "1.", Writable: true}})).To(Succeed())
+		path, _ = exp.Next()
+		Expect(exp.RegisterInfo([]expander.ParameterInfo{
+			{Name: path + "Enable", Writable: true},
+			{Name: path + "SSID", Writable: true},
+			{Name: path + "BSSID", Writable: false},
+		})).To(Succeed())
+
+		Expect(exp.CollectWritable()).To(Equal([]string{
+			"Device.WiFi.SSID.1.Enable",
+			"Device.WiFi.SSID.1.SSID",
+		}))
+	})
+
+	It("should fall back to the schema for writable flags", func() {
+		withSchema := expander.Get(expander.WithSchema(loadTestSchema()))
+		defer expander.Release(withSchema)
+
+		Expect(withSchema.Add("Device.WiFi.SSID.*.SSID", "Device.DeviceInfo.ProcessStatus.Process.1.PID")).To(Succeed())
+		path, _ := withSchema.Next()
+		Expect(withSchema.Register([]string{path + "1."})).To(Succeed())
+
+		Expect(withSchema.CollectWritable()).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+	})
+
+	It("should require a complete expansion", func() {
+		err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+		Expect(err).NotTo(HaveOccurred())
+
+		objects, err := exp.CollectCreatableObjects()
+		Expect(err).To(HaveOccurred())
+		Expect(objects).To(BeNil())
+	})
+})
@@ -0,0 +1,105 @@
+package expander
+
+import "strings"
+
+// ObjectIntent describes a table entry to configure on the device. When
+// Instance is zero a new instance must be created with AddObject on Object
+// first; otherwise the existing instance is reused. Names in Values are
+// relative to the instance, e.g. "Enable".
+type ObjectIntent struct {
+	Object   string
+	Instance int
+	Values   []ParameterValue
+}
+
+// ManagementServerPreset lists the TR-157 ManagementServer parameters most
+// commonly subscribed to on Inform.
+var ManagementServerPreset = []string{
+	"Device.ManagementServer.URL",
+	"Device.ManagementServer.ConnectionRequestURL",
+	"Device.ManagementServer.PeriodicInformEnable",
+	"Device.ManagementServer.PeriodicInformInterval",
+	"Device.ManagementServer.ParameterKey",
+	"Device.ManagementServer.UpgradesManaged",
+}
+
+// InformOptions controls how a pattern set is planned into
+// ManagementServer.InformParameter entries.
+type InformOptions struct {
+	// Object is the InformParameter table, defaults to
+	// "Device.ManagementServer.InformParameter."
+	Object string
+
+	// Wildcards keeps the added patterns as ParameterName values. When false,
+	// the expanded paths are used and the expansion must be complete.
+	Wildcards bool
+
+	// EventList restricts the Inform events the parameters are reported on.
+	// Empty means every event.
+	EventList string
+
+	// Existing lists InformParameter instances already present on the device
+	// that may be overwritten before new ones are added.
+	Existing []int
+
+	// ChunkSize bounds the number of intents per chunk, so a plan can be spread
+	// over several sessions. Zero puts everything in a single chunk.
+	ChunkSize int
+}
+
+// PlanInformParameters translates the pattern set into InformParameter
+// entries, one per parameter name, split into chunks of at most
+// opts.ChunkSize intents.
+func (e *Expander) PlanInformParameters(opts InformOptions) ([][]ObjectIntent, error) {
+	object := opts.Object
+	if object == "" {
+		object = "Device.ManagementServer.InformParameter."
+	}
+	if !strings.HasSuffix(object, ".") {
+		object += "."
+	}
+
+	names := e.patterns
+	if !opts.Wildcards {
+		paths, err := e.Collect()
+		if err != nil {
+			return nil, err
+		}
+		names = paths
+	}
+
+	intents := make([]ObjectIntent, 0, len(names))
+	for i, name := range names {
+		intent := ObjectIntent{
+			Object: object,
+			Values: []ParameterValue{
+				{Name: "Enable", Value: "true"},
+				{Name: "ParameterName", Value: name},
+				{Name: "EventList", Value: opts.EventList},
+			},
+		}
+		if i < len(opts.Existing) {
+			intent.Instance = opts.Existing[i]
+		}
+		intents = append(intents, intent)
+	}
+
+	return chunkIntents(intents, opts.ChunkSize), nil
+}
+
+// chunkIntents splits intents into consecutive chunks of at most size entries
+func chunkIntents(intents []ObjectIntent, size int) [][]ObjectIntent {
+	if len(intents) == 0 {
+		return nil
+	}
+	if size <= 0 || size >= len(intents) {
+		return [][]ObjectIntent{intents}
+	}
+
+	chunks := make([][]ObjectIntent, 0, (len(intents)+size-1)/size)
+	for len(intents) > size {
+		chunks = append(chunks, intents[:size:size])
+		intents = intents[size:]
+	}
+	return append(chunks, intents)
+}
@@ -0,0 +1,68 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InformParameter Planning", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should plan one entry per preset parameter", func() {
+		err := exp.Add(expander.ManagementServerPreset...)
+		Expect(err).NotTo(HaveOccurred())
+
+		chunks, err := exp.PlanInformParameters(expander.InformOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(chunks).To(HaveLen(1))
+		Expect(chunks[0]).To(HaveLen(len(expander.ManagementServerPreset)))
+		Expect(chunks[0][0]).To(Equal(expander.ObjectIntent{
+			Object: "Device.ManagementServer.InformParameter.",
+			Values: []expander.ParameterValue{
+				{Name: "Enable", Value: "true"},
+				{Name: "ParameterName", Value: "Device.ManagementServer.ConnectionRequestURL"},
+				{Name: "EventList", Value: ""},
+			},
+		}))
+	})
+
+	It("should reuse existing instances and chunk the rest", func() {
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.WiFi.AccessPoint.*.Status",
+			"Device.DeviceInfo.UpTime",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		chunks, err := exp.PlanInformParameters(expander.InformOptions{
+			Object:    "InternetGatewayDevice.ManagementServer.InformParameter",
+			Wildcards: true,
+			EventList: "2 PERIODIC",
+			Existing:  []int{4},
+			ChunkSize: 2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(chunks).To(HaveLen(2))
+		Expect(chunks[0]).To(HaveLen(2))
+		Expect(chunks[1]).To(HaveLen(1))
+
+		Expect(chunks[0][0].Object).To(Equal("InternetGatewayDevice.ManagementServer.InformParameter."))
+		Expect(chunks[0][0].Instance).To(Equal(4))
+		Expect(chunks[0][0].Values).To(ContainElement(
+			expander.ParameterValue{Name: "ParameterName", Value: "Device.WiFi.AccessPoint.*.Enable"},
+		))
+		Expect(chunks[0][1].Instance).To(BeZero())
+		Expect(chunks[1][0].Values).To(ContainElement(
+			expander.ParameterValue{Name: "EventList", Value: "2 PERIODIC"},
+		))
+	})
+})
@@ -0,0 +1,23 @@
+package expander
+
+import "iter"
+
+// All returns an iterator over the expanded paths, in the same order as
+// Collect but without copying them into a new slice. Sensitive paths are
+// filtered as in Collect.
+//
+// The sequence is empty while the expansion is not complete; call Collect
+// to find out why. The expander must not be modified or released while
+// the iteration is in progress.
+func (e *Expander) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if e.ensureComplete() != nil {
+			return
+		}
+		for _, path := range e.expandedPaths {
+			if e.isVisible(path) && !yield(path) {
+				return
+			}
+		}
+	}
+}
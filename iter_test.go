@@ -0,0 +1,54 @@
+package expander_test
+
+import (
+	"slices"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path Iterator", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get(expander.WithSensitiveFilter(expander.SensitiveExclude, nil))
+		err := exp.Add("Device.WiFi.AccessPoint.*.Security.KeyPassphrase", "Device.WiFi.AccessPoint.*.Enable")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should be empty while the expansion is incomplete", func() {
+		Expect(slices.Collect(exp.All())).To(BeEmpty())
+	})
+
+	It("should yield the same paths as Collect", func() {
+		_, _ = exp.Next()
+		err := exp.Register([]string{"Device.WiFi.AccessPoint.1", "Device.WiFi.AccessPoint.2"})
+		Expect(err).NotTo(HaveOccurred())
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slices.Collect(exp.All())).To(Equal(paths))
+		Expect(paths).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.2.Enable",
+		}))
+	})
+
+	It("should stop when the loop breaks", func() {
+		_, _ = exp.Next()
+		err := exp.Register([]string{"Device.WiFi.AccessPoint.1", "Device.WiFi.AccessPoint.2"})
+		Expect(err).NotTo(HaveOccurred())
+
+		var seen []string
+		for path := range exp.All() {
+			seen = append(seen, path)
+			break
+		}
+		Expect(seen).To(Equal([]string{"Device.WiFi.AccessPoint.1.Enable"}))
+	})
+})
@@ -0,0 +1,163 @@
+package expander
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned when an expansion exceeds its limits
+var (
+	// ErrTooManyPaths is returned once the expansion exceeds the limit set
+	// with WithMaxExpandedPaths
+	ErrTooManyPaths = errors.New("too many expanded paths")
+
+	// ErrTooManyWildcards is returned by Add for patterns with more
+	// wildcard levels than allowed
+	ErrTooManyWildcards = errors.New("too many wildcard levels")
+)
+
+// DefaultMaxWildcards is the number of wildcard levels a pattern may have
+// unless set otherwise with WithMaxWildcards
+const DefaultMaxWildcards = 6
+
+// WithMaxExpandedPaths aborts the expansion once it is projected to exceed
+// n paths, so a pattern like Device.Hosts.Host.*.* on a busy gateway can't
+// exhaust the memory of the worker. The projection is checked on every
+// registration, as in Quota.MaxProjectedPaths, and the expanded paths on
+// completion. Once aborted, the registration that crossed the limit,
+// Collect and later registrations fail with ErrTooManyPaths, and Next hands
+// out nothing more.
+func WithMaxExpandedPaths(n int) Option {
+	return func(e *Expander) {
+		e.config.maxPaths = n
+	}
+}
+
+// checkPathLimit aborts the expansion if it is projected to exceed the
+// limit on expanded paths. A registration only changes the projection of
+// the branches leading to or lying below its discovery path, so the running
+// projection before it is updated with the difference of these branches.
+func (e *Expander) checkPathLimit(discoveryPath string, projected, before int) error {
+	if e.config.maxPaths <= 0 || e.aborted != nil {
+		return e.aborted
+	}
+	if projected < 0 || e.projected < 0 {
+		e.projected = e.projectTree(&e.paths, "")
+	} else {
+		e.projected = projected + e.projectTree(&e.paths, discoveryPath) - before
+	}
+	if e.projected > e.config.maxPaths {
+		e.aborted = fmt.Errorf("%w: %d projected, limit is %d", ErrTooManyPaths, e.projected, e.config.maxPaths)
+	}
+	return e.aborted
+}
+
+// projectBelow returns the projection of the branches leading to or lying
+// below a discovery path, as needed to update the running projection
+func (e *Expander) projectBelow(discoveryPath string) int {
+	if e.config.maxPaths <= 0 || e.projected < 0 {
+		return 0
+	}
+	return e.projectTree(&e.paths, discoveryPath)
+}
+
+// WithInstanceCap uses at most n of the instances discovered for every
+// table, the lowest indices first, followed by alias-addressed instances in
+// alphabetical order, so monitoring jobs get bounded output
+// even from pathological devices. Every capped discovery raises a
+// WarningInstanceCap, and the report of CollectReport is flagged Truncated.
+func WithInstanceCap(n int) Option {
+	return func(e *Expander) {
+		e.config.instanceCap = n
+	}
+}
+
+// capInstances applies the instance cap to the indices and aliases
+// resolved for a discovery path, both sorted on registration
+func (e *Expander) capInstances(indices []int, aliases []string) ([]int, []string) {
+	limit := e.config.instanceCap
+	if limit <= 0 || len(indices)+len(aliases) <= limit {
+		return indices, aliases
+	}
+	if len(indices) >= limit {
+		return indices[:limit], nil
+	}
+	return indices, aliases[:limit-len(indices)]
+}
+
+// checkInstanceCap warns about a discovery listing more instances than the
+// instance cap
+func (e *Expander) checkInstanceCap(discoveryPath string, indices []int, aliases []string) {
+	if limit := e.config.instanceCap; limit > 0 && len(indices)+len(aliases) > limit {
+		e.warnOnce(WarningInstanceCap, discoveryPath, "%d instances capped to %d", len(indices)+len(aliases), limit)
+	}
+}
+
+// WithDiscoveryBudget limits the discoveries handed out by Next and
+// NextBatch to n, requeued ones included, for a predictable cost per CWMP
+// session. Once the budget is spent nothing more is handed out and the
+// expansion completes with the branches resolved so far; CollectReport
+// lists the branches left as BranchOverBudget. Retries made by the driver
+// loops with WithRetry are not counted.
+func WithDiscoveryBudget(n int) Option {
+	return func(e *Expander) {
+		e.config.discoveryBudget = n
+	}
+}
+
+// budgetSpent reports whether the discovery budget is spent
+func (e *Expander) budgetSpent() bool {
+	return e.config.discoveryBudget > 0 && e.dispatched >= e.config.discoveryBudget
+}
+
+// WildcardDepthError reports a pattern with more wildcard levels than
+// allowed.
+type WildcardDepthError struct {
+	Pattern string
+	Depth   int
+	Max     int
+}
+
+func (e *WildcardDepthError) Error() string {
+	return fmt.Sprintf("%v: %s has %d, limit is %d", ErrTooManyWildcards, e.Pattern, e.Depth, e.Max)
+}
+
+func (e *WildcardDepthError) Unwrap() error {
+	return ErrTooManyWildcards
+}
+
+// WithMaxWildcards sets the number of wildcard levels a pattern may have,
+// DefaultMaxWildcards otherwise, so operator-supplied patterns like
+// Device.*.*.*.*.*.*.* can't start combinatorial discovery storms. Every
+// segment needing a discovery counts: wildcards, "**", glob segments, index
+// selectors, named captures and search expressions. Add refuses deeper
+// patterns with a *WildcardDepthError. Negative values remove the limit.
+func WithMaxWildcards(n int) Option {
+	return func(e *Expander) {
+		e.config.maxWildcards = n
+	}
+}
+
+// checkWildcardDepth verifies that a normalized pattern stays within the
+// wildcard level limit
+func (e *Expander) checkWildcardDepth(pattern, normalized string) error {
+	limit := e.config.maxWildcards
+	if limit == 0 {
+		limit = DefaultMaxWildcards
+	}
+	if limit < 0 {
+		return nil
+	}
+
+	depth := 0
+	for segment := range strings.SplitSeq(normalized, ".") {
+		if isDiscoverySegment(segment) {
+			depth++
+		}
+	}
+	if depth > limit {
+		return &WildcardDepthError{Pattern: pattern, Depth: depth, Max: limit}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package expander_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// hostTable lists n instances below a table
+func hostTable(table string, n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = table + strconv.Itoa(i+1) + "."
+	}
+	return names
+}
+
+var _ = Describe("Expansion Limits", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	Context("on expanded paths", func() {
+		It("should abort once the projected paths exceed the limit", func() {
+			exp = expander.Get(expander.WithMaxExpandedPaths(10))
+			Expect(exp.Add("Device.Hosts.Host.*.*")).To(Succeed())
+
+			exp.Next()
+			err := exp.Register(hostTable("Device.Hosts.Host.", 11))
+			Expect(err).To(MatchError(expander.ErrTooManyPaths))
+			Expect(err).To(MatchError("too many expanded paths: 11 projected, limit is 10"))
+
+			_, hasMore := exp.Next()
+			Expect(hasMore).To(BeFalse())
+			_, err = exp.Collect()
+			Expect(err).To(MatchError(expander.ErrTooManyPaths))
+			Expect(exp.RegisterFor("Device.Hosts.Host.1.", nil)).To(MatchError(expander.ErrTooManyPaths))
+		})
+
+		It("should expand within the limit", func() {
+			exp = expander.Get(expander.WithMaxExpandedPaths(10))
+			Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+			exp.Next()
+			Expect(exp.Register(hostTable("Device.Hosts.Host.", 10))).To(Succeed())
+			Expect(exp.Collect()).To(HaveLen(10))
+		})
+
+		It("should keep the projection up to date across registrations and additions", func() {
+			exp = expander.Get(expander.WithMaxExpandedPaths(9))
+			Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress", "Device.WiFi.SSID.*.SSID")).To(Succeed())
+
+			Expect(exp.RegisterFor("Device.Hosts.Host.", hostTable("Device.Hosts.Host.", 3))).To(Succeed())
+			Expect(exp.RegisterFor("Device.Hosts.Host.1.IPv4Address.", hostTable("Device.Hosts.Host.1.IPv4Address.", 2))).To(Succeed())
+			Expect(exp.RegisterFor("Device.Hosts.Host.2.IPv4Address.", hostTable("Device.Hosts.Host.2.IPv4Address.", 2))).To(Succeed())
+			Expect(exp.Add("Device.DeviceInfo.UpTime")).To(Succeed())
+			Expect(exp.RegisterFor("Device.WiFi.SSID.", hostTable("Device.WiFi.SSID.", 3))).To(Succeed())
+
+			err := exp.RegisterFor("Device.Hosts.Host.3.IPv4Address.", hostTable("Device.Hosts.Host.3.IPv4Address.", 2))
+			Expect(err).To(MatchError("too many expanded paths: 10 projected, limit is 9"))
+		})
+
+		It("should abort the driver loop", func() {
+			exp = expander.Get(expander.WithMaxExpandedPaths(3))
+			Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.")).To(Succeed())
+
+			_, err := exp.Expand(context.Background(), func(_ context.Context, path string) ([]string, error) {
+				return hostTable(path, 2), nil
+			})
+			var discoveryErr *expander.DiscoveryError
+			Expect(errors.As(err, &discoveryErr)).To(BeFalse())
+			Expect(err).To(MatchError(expander.ErrTooManyPaths))
+
+			report := exp.CollectReport()
+			Expect(report.Errors).To(BeEmpty())
+		})
+
+		It("should abort the parallel driver loop", func() {
+			exp = expander.Get(
+				expander.WithMaxExpandedPaths(3),
+				expander.WithDriverCapabilities(expander.DriverCapabilities{ParallelRequests: 4}),
+			)
+			Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.")).To(Succeed())
+
+			_, err := exp.Expand(context.Background(), func(_ context.Context, path string) ([]string, error) {
+				return hostTable(path, 2), nil
+			})
+			Expect(err).To(MatchError(expander.ErrTooManyPaths))
+			Expect(exp.CollectReport().Errors).To(BeEmpty())
+		})
+
+		It("should report no paths once aborted", func() {
+			exp = expander.Get(expander.WithMaxExpandedPaths(5))
+			Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+			Expect(exp.RegisterFor("Device.Hosts.Host.", hostTable("Device.Hosts.Host.", 1000))).To(MatchError(expander.ErrTooManyPaths))
+
+			report := exp.CollectReport()
+			Expect(report.Paths).To(BeEmpty())
+			Expect(report.Aborted).To(MatchError(expander.ErrTooManyPaths))
+			Expect(report.Err()).To(MatchError(expander.ErrTooManyPaths))
+		})
+	})
+
+	Context("on instances", func() {
+		It("should use the lowest instances up to the cap", func() {
+			exp = expander.Get(expander.WithInstanceCap(2))
+			Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress")).To(Succeed())
+
+			exp.Next()
+			Expect(exp.Register([]string{"Device.Hosts.Host.7.", "Device.Hosts.Host.3.", "Device.Hosts.Host.5."})).To(Succeed())
+			Expect(drainDiscoveries(exp)).To(Equal([]string{
+				"Device.Hosts.Host.3.IPv4Address.",
+				"Device.Hosts.Host.5.IPv4Address.",
+			}))
+
+			report := exp.CollectReport()
+			Expect(report.Paths).To(Equal([]string{
+				"Device.Hosts.Host.3.IPv4Address.1.IPAddress",
+				"Device.Hosts.Host.5.IPv4Address.1.IPAddress",
+			}))
+			Expect(report.Truncated).To(BeTrue())
+			Expect(report.Warnings).To(Equal([]expander.Warning{{
+				Kind:   expander.WarningInstanceCap,
+				Path:   "Device.Hosts.Host.",
+				Detail: "3 instances capped to 2",
+			}}))
+		})
+
+		It("should fill the cap with aliases after indices", func() {
+			exp = expander.Get(expander.WithInstanceCap(2), expander.WithAliasCodec(expander.BracketAliases))
+			Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+			exp.Next()
+			Expect(exp.Register([]string{"Device.Hosts.Host.[b].", "Device.Hosts.Host.[a].", "Device.Hosts.Host.4."})).To(Succeed())
+			Expect(exp.Collect()).To(Equal([]string{
+				"Device.Hosts.Host.4.IPAddress",
+				"Device.Hosts.Host.[a].IPAddress",
+			}))
+		})
+
+		It("should not flag reports within the cap", func() {
+			exp = expander.Get(expander.WithInstanceCap(2))
+			Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+			exp.Next()
+			Expect(exp.Register(hostTable("Device.Hosts.Host.", 2))).To(Succeed())
+			Expect(exp.CollectReport().Truncated).To(BeFalse())
+		})
+	})
+
+	Context("on discoveries", func() {
+		It("should complete with the branches resolved within the budget", func() {
+			exp = expander.Get(expander.WithDiscoveryBudget(3))
+			Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress")).To(Succeed())
+
+			var calls int
+			paths, err := exp.Expand(context.Background(), func(_ context.Context, path string) ([]string, error) {
+				calls++
+				return hostTable(path, 3), nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls).To(Equal(3))
+			Expect(paths).To(Equal([]string{
+				"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+				"Device.Hosts.Host.1.IPv4Address.2.IPAddress",
+				"Device.Hosts.Host.1.IPv4Address.3.IPAddress",
+				"Device.Hosts.Host.2.IPv4Address.1.IPAddress",
+				"Device.Hosts.Host.2.IPv4Address.2.IPAddress",
+				"Device.Hosts.Host.2.IPv4Address.3.IPAddress",
+			}))
+
+			report := exp.CollectReport()
+			Expect(report.Complete).To(BeTrue())
+			Expect(report.Errors).To(Equal([]*expander.BranchError{
+				{Kind: expander.BranchOverBudget, Path: "Device.Hosts.Host.3.IPv4Address."},
+			}))
+		})
+
+		It("should count requeued discoveries", func() {
+			exp = expander.Get(expander.WithDiscoveryBudget(2))
+			Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+			path, _ := exp.Next()
+			Expect(exp.Requeue(path)).To(Succeed())
+			again, _ := exp.Next()
+			Expect(again).To(Equal(path))
+			Expect(exp.Register(hostTable(path, 1))).To(Succeed())
+
+			_, hasMore := exp.Next()
+			Expect(hasMore).To(BeFalse())
+			Expect(exp.Collect()).To(Equal([]string{"Device.Hosts.Host.1.IPAddress"}))
+		})
+	})
+
+	Context("on wildcard levels", func() {
+		It("should refuse patterns deeper than the default", func() {
+			exp = expander.Get()
+			Expect(exp.Add("Device.*.*.*.*.*.*")).To(Succeed())
+
+			err := exp.Add("Device.*.*.*.*.*.*.*")
+			Expect(err).To(MatchError(expander.ErrTooManyWildcards))
+			Expect(err).To(Equal(&expander.WildcardDepthError{Pattern: "Device.*.*.*.*.*.*.*", Depth: 7, Max: expander.DefaultMaxWildcards}))
+		})
+
+		It("should count every segment needing a discovery", func() {
+			exp = expander.Get(expander.WithMaxWildcards(3), expander.WithGlobSegments())
+			Expect(exp.Add("Device.IP.Interface.[1-2].IPv4Address.*.IPAddress")).To(Succeed())
+
+			err := exp.Add("Device.Services.*Service.*.VoiceProfile.[Enable==true].Line.*.Enable")
+			Expect(err).To(MatchError("too many wildcard levels: Device.Services.*Service.*.VoiceProfile.[Enable==true].Line.*.Enable has 4, limit is 3"))
+		})
+
+		It("should allow any depth without a limit", func() {
+			exp = expander.Get(expander.WithMaxWildcards(-1))
+			Expect(exp.Add("Device.*.*.*.*.*.*.*.*")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,102 @@
+package expander
+
+import "sync/atomic"
+
+// PoolEvent identifies an operation on a Pool.
+type PoolEvent int
+
+// Pool events reported to an Instrumenter
+const (
+	// PoolGet is reported when an expander is handed out
+	PoolGet PoolEvent = iota + 1
+
+	// PoolPut is reported when an expander is returned
+	PoolPut
+
+	// PoolNew is reported when an expander is allocated, on demand or by Warm
+	PoolNew
+)
+
+// PoolStats counts the operations performed on a Pool.
+type PoolStats struct {
+	// Gets is the number of expanders handed out
+	Gets uint64
+
+	// Puts is the number of expanders returned
+	Puts uint64
+
+	// News is the number of expanders allocated, on demand or by Warm
+	News uint64
+}
+
+// IdleEstimate returns an upper bound on the number of expanders idle in the
+// pool. The garbage collector may reclaim pooled expanders at any time, so
+// the actual number can be lower.
+func (s PoolStats) IdleEstimate() int64 {
+	// Every Get consumes an idle expander or a newly allocated one
+	return int64(s.News) + int64(s.Puts) - int64(s.Gets)
+}
+
+// Instrumenter receives the operations performed on a pool along with the
+// counters after the operation, to feed metrics systems. It is called
+// synchronously from Get and Release and must be safe for concurrent use.
+type Instrumenter interface {
+	ObservePool(event PoolEvent, stats PoolStats)
+}
+
+// poolMetrics holds the counters of a pool
+type poolMetrics struct {
+	gets, puts, news atomic.Uint64
+	instrumenter     atomic.Pointer[Instrumenter]
+}
+
+// record counts an event and reports it to the instrumenter, if any
+func (m *poolMetrics) record(event PoolEvent) {
+	switch event {
+	case PoolGet:
+		m.gets.Add(1)
+	case PoolPut:
+		m.puts.Add(1)
+	case PoolNew:
+		m.news.Add(1)
+	}
+
+	if instrumenter := m.instrumenter.Load(); instrumenter != nil {
+		(*instrumenter).ObservePool(event, m.stats())
+	}
+}
+
+// stats returns a snapshot of the counters
+func (m *poolMetrics) stats() PoolStats {
+	return PoolStats{
+		Gets: m.gets.Load(),
+		Puts: m.puts.Load(),
+		News: m.news.Load(),
+	}
+}
+
+// Stats returns the operations performed on the pool so far.
+func (p *Pool) Stats() PoolStats {
+	return p.metrics.stats()
+}
+
+// SetInstrumenter reports every subsequent operation on the pool to
+// instrumenter. A nil instrumenter stops reporting.
+func (p *Pool) SetInstrumenter(instrumenter Instrumenter) {
+	if instrumenter == nil {
+		p.metrics.instrumenter.Store(nil)
+		return
+	}
+	p.metrics.instrumenter.Store(&instrumenter)
+}
+
+// Stats returns the operations performed on the default pool so far.
+func Stats() PoolStats {
+	return defaultPool.Stats()
+}
+
+// SetInstrumenter reports every subsequent operation on the default pool to
+// instrumenter. A nil instrumenter stops reporting.
+func SetInstrumenter(instrumenter Instrumenter) {
+	defaultPool.SetInstrumenter(instrumenter)
+}
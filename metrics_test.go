@@ -0,0 +1,60 @@
+package expander_test
+
+import (
+	"sync"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// eventRecorder is an Instrumenter remembering the events it observed
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []expander.PoolEvent
+	last   expander.PoolStats
+}
+
+func (r *eventRecorder) ObservePool(event expander.PoolEvent, stats expander.PoolStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	r.last = stats
+}
+
+var _ = Describe("Pool Metrics", func() {
+	It("should count gets, puts and allocations", func() {
+		pool := expander.NewPool()
+		pool.Warm(2, expander.CapacityHints{})
+		Expect(pool.Stats()).To(Equal(expander.PoolStats{News: 2}))
+		Expect(pool.Stats().IdleEstimate()).To(Equal(int64(2)))
+
+		first := pool.Get()
+		second := pool.Get()
+		expander.Release(first)
+
+		stats := pool.Stats()
+		Expect(stats.Gets).To(Equal(uint64(2)))
+		Expect(stats.Puts).To(Equal(uint64(1)))
+		Expect(stats.News).To(BeNumerically(">=", 2))
+
+		expander.Release(second)
+	})
+
+	It("should report operations to the instrumenter", func() {
+		recorder := &eventRecorder{}
+		pool := expander.NewPool()
+		pool.SetInstrumenter(recorder)
+
+		exp := pool.Get()
+		expander.Release(exp)
+		Expect(recorder.events).To(HaveLen(3))
+		Expect(recorder.events).To(ContainElements(expander.PoolNew, expander.PoolGet, expander.PoolPut))
+		Expect(recorder.events[len(recorder.events)-1]).To(Equal(expander.PoolPut))
+		Expect(recorder.last).To(Equal(pool.Stats()))
+
+		pool.SetInstrumenter(nil)
+		expander.Release(pool.Get())
+		Expect(recorder.events).To(HaveLen(3))
+	})
+})
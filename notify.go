@@ -0,0 +1,78 @@
+package expander
+
+import "sort"
+
+// NotificationLevel is the TR-069 notification attribute of a parameter.
+type NotificationLevel int
+
+// Notification levels as defined for SetParameterAttributes
+const (
+	NotificationOff NotificationLevel = iota
+	NotificationPassive
+	NotificationActive
+)
+
+// ParameterAttribute is a single SetParameterAttributesStruct entry.
+type ParameterAttribute struct {
+	Name               string
+	Notification       NotificationLevel
+	NotificationChange bool
+}
+
+// PlanNotifications builds the SetParameterAttributes list enabling the given
+// notification level for everything matched by each pattern. Patterns without
+// an entry in levels are left untouched. When a path is matched by several
+// patterns the highest level wins.
+//
+// Patterns ending with a dot expand to partial paths covering a whole subtree;
+// descendants of such a partial path are omitted when they share its level.
+// Entries are ordered so that partial paths precede their descendants.
+func (e *Expander) PlanNotifications(levels map[string]NotificationLevel) ([]ParameterAttribute, error) {
+	byPattern, err := e.CollectByPattern()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]NotificationLevel)
+	for pattern, level := range levels {
+		for _, path := range byPattern[pattern] {
+			if current, ok := wanted[path]; !ok || level > current {
+				wanted[path] = level
+			}
+		}
+	}
+
+	names := make([]string, 0, len(wanted))
+	for name := range wanted {
+		if coveredByAncestor(name, wanted) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attributes := make([]ParameterAttribute, 0, len(names))
+	for _, name := range names {
+		attributes = append(attributes, ParameterAttribute{
+			Name:               name,
+			Notification:       wanted[name],
+			NotificationChange: true,
+		})
+	}
+	return attributes, nil
+}
+
+// coveredByAncestor reports whether a partial path above name is already
+// planned with the same notification level
+func coveredByAncestor(name string, wanted map[string]NotificationLevel) bool {
+	level := wanted[name]
+	for i := 0; i < len(name)-1; i++ {
+		if name[i] != '.' {
+			continue
+		}
+		if ancestorLevel, ok := wanted[name[:i+1]]; ok && ancestorLevel == level {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notification Planning", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should use partial paths for covered subtrees", func() {
+		err := exp.Add(
+			"Device.WiFi.SSID.*.",
+			"Device.WiFi.SSID.*.Enable",
+			"Device.WiFi.SSID.*.Status",
+			"Device.DeviceInfo.UpTime",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.Register([]string{"Device.WiFi.SSID.1", "Device.WiFi.SSID.2"})
+		Expect(err).NotTo(HaveOccurred())
+
+		attributes, err := exp.PlanNotifications(map[string]expander.NotificationLevel{
+			"Device.WiFi.SSID.*.":       expander.NotificationPassive,
+			"Device.WiFi.SSID.*.Enable": expander.NotificationPassive,
+			"Device.WiFi.SSID.*.Status": expander.NotificationActive,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(attributes).To(Equal([]expander.ParameterAttribute{
+			{Name: "Device.WiFi.SSID.1.", Notification: expander.NotificationPassive, NotificationChange: true},
+			{Name: "Device.WiFi.SSID.1.Status", Notification: expander.NotificationActive, NotificationChange: true},
+			{Name: "Device.WiFi.SSID.2.", Notification: expander.NotificationPassive, NotificationChange: true},
+			{Name: "Device.WiFi.SSID.2.Status", Notification: expander.NotificationActive, NotificationChange: true},
+		}))
+	})
+
+	It("should keep the highest level for overlapping patterns", func() {
+		err := exp.Add(
+			"Device.WiFi.SSID.*.Enable",
+			"Device.WiFi.SSID.1.Enable",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.Register([]string{"Device.WiFi.SSID.1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		attributes, err := exp.PlanNotifications(map[string]expander.NotificationLevel{
+			"Device.WiFi.SSID.*.Enable": expander.NotificationPassive,
+			"Device.WiFi.SSID.1.Enable": expander.NotificationActive,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(attributes).To(Equal([]expander.ParameterAttribute{
+			{Name: "Device.WiFi.SSID.1.Enable", Notification: expander.NotificationActive, NotificationChange: true},
+		}))
+	})
+})
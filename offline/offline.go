@@ -0,0 +1,214 @@
+// Package offline expands pattern sets against parameter dumps collected
+// from many devices, for analytics run away from any CWMP session. Dumps are
+// read as a stream and only the names of one device are held in memory at a
+// time, so dumps far larger than memory can be processed.
+//
+// A dump is a text stream with one parameter per line: the device ID and
+// the parameter name separated by a tab, optionally followed by another tab
+// and a value, which is ignored. Blank lines and lines starting with '#' are
+// skipped. The lines of a device must be contiguous.
+package offline
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+)
+
+// DefaultMaxDeviceNames bounds the names held for one device unless
+// Config.MaxDeviceNames says otherwise
+const DefaultMaxDeviceNames = 1 << 20
+
+// maxLineLen bounds the length of a dump line, values included
+const maxLineLen = 1 << 20
+
+var (
+	// ErrNoPatterns is returned when no pattern is configured
+	ErrNoPatterns = errors.New("offline expansion requires at least one pattern")
+
+	// ErrMalformedLine is returned for dump lines without a device ID and name
+	ErrMalformedLine = errors.New("malformed dump line")
+
+	// ErrUngrouped is returned when the lines of a device are not contiguous
+	ErrUngrouped = errors.New("dump lines are not grouped by device")
+
+	// ErrTooManyNames is returned when a device exceeds MaxDeviceNames
+	ErrTooManyNames = errors.New("too many names for one device")
+)
+
+// Config describes an offline expansion.
+type Config struct {
+	// Patterns is the pattern set expanded for every device
+	Patterns []string
+
+	// Options are applied to the expander used for every device
+	Options []expander.Option
+
+	// MaxDeviceNames bounds the names held in memory for one device. Zero
+	// means DefaultMaxDeviceNames.
+	MaxDeviceNames int
+}
+
+// Sink receives the expanded paths of every device, in dump order.
+type Sink interface {
+	Put(deviceID string, paths []string) error
+}
+
+// SinkFunc is a function usable as a Sink.
+type SinkFunc func(deviceID string, paths []string) error
+
+// Put calls f(deviceID, paths).
+func (f SinkFunc) Put(deviceID string, paths []string) error {
+	return f(deviceID, paths)
+}
+
+// Stats describes what an offline expansion processed.
+type Stats struct {
+	// Devices is the number of devices expanded
+	Devices int
+
+	// Names is the number of parameter names read
+	Names int
+
+	// Paths is the number of expanded paths delivered to the sink
+	Paths int
+}
+
+// Expand reads a dump from r and delivers the expanded paths of every device
+// to sink as soon as the device's lines end. Context cancellation is checked
+// between devices. On error, the stats cover the devices delivered so far.
+func Expand(ctx context.Context, r io.Reader, cfg Config, sink Sink) (Stats, error) {
+	if len(cfg.Patterns) == 0 {
+		return Stats{}, ErrNoPatterns
+	}
+	maxNames := cfg.MaxDeviceNames
+	if maxNames <= 0 {
+		maxNames = DefaultMaxDeviceNames
+	}
+
+	run := &run{
+		cfg:  cfg,
+		pool: expander.NewPool(cfg.Options...),
+		sink: sink,
+		done: make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLen)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		deviceID, name, ok := parseLine(text)
+		if !ok {
+			return run.stats, fmt.Errorf("line %d: %w", line, ErrMalformedLine)
+		}
+		if deviceID != run.device {
+			if err := run.flush(ctx); err != nil {
+				return run.stats, err
+			}
+			if run.done[deviceID] {
+				return run.stats, fmt.Errorf("line %d: device %s: %w", line, deviceID, ErrUngrouped)
+			}
+			run.device = deviceID
+		}
+		if len(run.names) == maxNames {
+			return run.stats, fmt.Errorf("device %s: %w", deviceID, ErrTooManyNames)
+		}
+		run.names = append(run.names, name)
+		run.stats.Names++
+	}
+	if err := scanner.Err(); err != nil {
+		return run.stats, err
+	}
+	return run.stats, run.flush(ctx)
+}
+
+// ExpandFile runs Expand on the dump stored at path.
+func ExpandFile(ctx context.Context, path string, cfg Config, sink Sink) (Stats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer f.Close()
+
+	return Expand(ctx, f, cfg, sink)
+}
+
+// run holds the state of an offline expansion
+type run struct {
+	cfg  Config
+	pool *expander.Pool
+	sink Sink
+
+	// device and names hold the device being read and its names so far
+	device string
+	names  []string
+
+	// done records the devices already delivered
+	done map[string]bool
+
+	stats Stats
+}
+
+// flush expands and delivers the device being read, if any
+func (r *run) flush(ctx context.Context) error {
+	if r.device == "" {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sort.Strings(r.names)
+	paths, err := r.expand()
+	if err != nil {
+		return fmt.Errorf("device %s: %w", r.device, err)
+	}
+	if err := r.sink.Put(r.device, paths); err != nil {
+		return err
+	}
+
+	r.done[r.device] = true
+	r.stats.Devices++
+	r.stats.Paths += len(paths)
+	r.device = ""
+	r.names = r.names[:0]
+	return nil
+}
+
+// expand expands the patterns against the sorted names of the device
+func (r *run) expand() ([]string, error) {
+	exp := r.pool.Get()
+	defer expander.Release(exp)
+
+	if err := exp.Bind(r.device); err != nil {
+		return nil, err
+	}
+	if err := exp.Add(r.cfg.Patterns...); err != nil {
+		return nil, err
+	}
+	return exp.ExpandAgainst(r.names)
+}
+
+// parseLine splits a dump line into the device ID and the parameter name
+func parseLine(line string) (string, string, bool) {
+	deviceID, rest, found := strings.Cut(line, "\t")
+	if !found || deviceID == "" {
+		return "", "", false
+	}
+	name, _, _ := strings.Cut(rest, "\t")
+	if name == "" {
+		return "", "", false
+	}
+	return deviceID, name, true
+}
@@ -0,0 +1,102 @@
+package offline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/metalgrid/tr069-path-expander/v2/offline"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOffline(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Offline Suite")
+}
+
+// collect returns a sink recording the paths of every device
+func collect(results map[string][]string) offline.Sink {
+	return offline.SinkFunc(func(deviceID string, paths []string) error {
+		results[deviceID] = paths
+		return nil
+	})
+}
+
+var _ = Describe("Offline Expansion", func() {
+	const dump = `# device	name	value
+cpe-1	Device.WiFi.SSID.1.SSID	home
+cpe-1	Device.WiFi.SSID.1.Enable	true
+cpe-1	Device.WiFi.SSID.3.SSID	guest
+
+cpe-2	Device.WiFi.SSID.2.SSID	office
+cpe-2	Device.Hosts.HostNumberOfEntries	0
+`
+
+	var (
+		cfg     offline.Config
+		results map[string][]string
+	)
+
+	BeforeEach(func() {
+		cfg = offline.Config{Patterns: []string{"Device.WiFi.SSID.*.SSID"}}
+		results = make(map[string][]string)
+	})
+
+	It("should expand the patterns for every device", func() {
+		stats, err := offline.Expand(context.Background(), strings.NewReader(dump), cfg, collect(results))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats).To(Equal(offline.Stats{Devices: 2, Names: 5, Paths: 3}))
+		Expect(results).To(Equal(map[string][]string{
+			"cpe-1": {"Device.WiFi.SSID.1.SSID", "Device.WiFi.SSID.3.SSID"},
+			"cpe-2": {"Device.WiFi.SSID.2.SSID"},
+		}))
+	})
+
+	It("should read dumps from files", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "dump.tsv")
+		Expect(os.WriteFile(path, []byte(dump), 0o600)).To(Succeed())
+
+		stats, err := offline.ExpandFile(context.Background(), path, cfg, collect(results))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats.Devices).To(Equal(2))
+	})
+
+	It("should reject devices split across the dump", func() {
+		split := dump + "cpe-1\tDevice.WiFi.SSID.4.SSID\n"
+		stats, err := offline.Expand(context.Background(), strings.NewReader(split), cfg, collect(results))
+		Expect(err).To(MatchError(offline.ErrUngrouped))
+		Expect(stats.Devices).To(Equal(2))
+	})
+
+	It("should bound the names held for one device", func() {
+		cfg.MaxDeviceNames = 2
+		_, err := offline.Expand(context.Background(), strings.NewReader(dump), cfg, collect(results))
+		Expect(err).To(MatchError(offline.ErrTooManyNames))
+		Expect(results).To(BeEmpty())
+	})
+
+	It("should reject malformed lines", func() {
+		_, err := offline.Expand(context.Background(), strings.NewReader("Device.WiFi.SSID.1.SSID\n"), cfg, collect(results))
+		Expect(err).To(MatchError(offline.ErrMalformedLine))
+	})
+
+	It("should require patterns", func() {
+		_, err := offline.Expand(context.Background(), strings.NewReader(dump), offline.Config{}, collect(results))
+		Expect(err).To(MatchError(offline.ErrNoPatterns))
+	})
+
+	It("should stop between devices when the context is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		sink := offline.SinkFunc(func(deviceID string, paths []string) error {
+			cancel()
+			return nil
+		})
+
+		stats, err := offline.Expand(ctx, strings.NewReader(dump), cfg, sink)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(stats.Devices).To(Equal(1))
+	})
+})
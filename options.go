@@ -0,0 +1,236 @@
+package expander
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/metalgrid/tr069-path-expander/v2/datamodel"
+)
+
+// Option configures optional behavior of an Expander obtained from Get.
+type Option func(*Expander)
+
+// config holds the optional behavior of an expander. The zero value is the
+// default behavior.
+type config struct {
+	// aliasCodec recognizes and renders alias-addressed instances
+	aliasCodec AliasCodec
+
+	// aliasOutput renders instances with a known Alias value as aliases
+	aliasOutput bool
+
+	// unreadable lists patterns known to fault when read
+	unreadable []string
+
+	// includeUnreadable keeps unreadable paths in the results
+	includeUnreadable bool
+
+	// sensitiveMode selects how sensitive paths are treated at collect time
+	sensitiveMode SensitiveMode
+
+	// classifySensitive decides which paths are sensitive
+	classifySensitive Classifier
+
+	// captureCallers records the call site of unlabeled Add calls
+	captureCallers bool
+
+	// literalPolicy decides how literal indices next to wildcards are treated
+	literalPolicy LiteralPolicy
+
+	// shuffle picks the next pending discovery when set
+	shuffle *rand.Rand
+
+	// traversal decides where new discoveries join the queue
+	traversal TraversalOrder
+
+	// pathOrder sorts the expanded paths, lexically when nil
+	pathOrder PathOrder
+
+	// globSegments matches segments with embedded wildcards against child names
+	globSegments bool
+
+	// quotas limits what each owner may add, when set
+	quotas *quotas
+
+	// responseLimit is the number of names a discovery response may hold
+	// before responseAction applies; zero means no limit
+	responseLimit  int
+	responseAction ResponseAction
+
+	// subtrees expands partial path patterns to every parameter below them
+	subtrees bool
+
+	// capabilities describes what the driver can do
+	capabilities DriverCapabilities
+
+	// signingKey signs and verifies serialized payloads, when set
+	signingKey []byte
+
+	// schema is the data model of the device, when loaded
+	schema *datamodel.Schema
+
+	// retries is the number of times a transient discovery failure is
+	// retried, backoff the wait before the first retry
+	retries int
+	backoff time.Duration
+
+	// limiter paces discovery requests, when set
+	limiter Limiter
+
+	// lenientSubtrees resolves deeper discoveries from subtree responses to
+	// NextLevel=true requests
+	lenientSubtrees bool
+
+	// caseInsensitive tolerates names registered in another case
+	caseInsensitive bool
+
+	// prefixRepair prepends the discovery path to relative names
+	prefixRepair bool
+
+	// maxPaths bounds the number of expanded paths, when positive
+	maxPaths int
+
+	// instanceCap bounds the instances used per wildcard, when positive
+	instanceCap int
+
+	// discoveryBudget bounds the discoveries handed out, when positive
+	discoveryBudget int
+
+	// maxWildcards bounds the wildcard levels of a pattern; zero means
+	// DefaultMaxWildcards, negative no limit
+	maxWildcards int
+
+	// blocked lists the prefixes of subtrees the expansion stays out of
+	blocked []string
+
+	// allowed lists the prefixes of the subtrees the expansion is
+	// restricted to, when non-nil
+	allowed []string
+
+	// sampleSize is the number of instances sampled per table, when
+	// positive, drawn from sampleSeed
+	sampleSize int
+	sampleSeed int64
+}
+
+// WithAliasCodec makes the expander recognize alias-addressed instances in
+// registered parameter names and render them with the codec in expanded paths.
+// Without a codec, non-numeric instance segments are ignored.
+func WithAliasCodec(codec AliasCodec) Option {
+	return func(e *Expander) {
+		e.config.aliasCodec = codec
+	}
+}
+
+// WithAliasOutput renders instances in expanded paths by alias instead of
+// number wherever their Alias value was registered with RegisterAliasValues,
+// e.g. "Device.WiFi.AccessPoint.[guest].Enable" instead of
+// "Device.WiFi.AccessPoint.2.Enable". Aliases are rendered with the alias
+// codec, BracketAliases by default. Discoveries below such instances are
+// alias-addressed as well, so the device must support alias-based addressing.
+func WithAliasOutput() Option {
+	return func(e *Expander) {
+		e.config.aliasOutput = true
+	}
+}
+
+// WithUnreadable marks parameters or subtrees known to fault on read, such as
+// passphrases on some firmwares. Patterns may contain wildcards and may be
+// partial paths ending with a dot to cover a whole subtree. Matching paths are
+// excluded from the results and no discovery is issued below them.
+func WithUnreadable(patterns ...string) Option {
+	return func(e *Expander) {
+		e.config.unreadable = append(e.config.unreadable, patterns...)
+	}
+}
+
+// WithIncludeUnreadable keeps paths marked by WithUnreadable in the results.
+func WithIncludeUnreadable() Option {
+	return func(e *Expander) {
+		e.config.includeUnreadable = true
+	}
+}
+
+// WithSensitiveFilter applies classify to the results at collect time and
+// excludes or flags the paths it reports as sensitive, depending on mode.
+// A nil classifier uses DefaultSensitiveClassifier.
+func WithSensitiveFilter(mode SensitiveMode, classify Classifier) Option {
+	return func(e *Expander) {
+		if classify == nil {
+			classify = DefaultSensitiveClassifier
+		}
+		e.config.sensitiveMode = mode
+		e.config.classifySensitive = classify
+	}
+}
+
+// WithCallerCapture records the call site of every Add call in the pattern
+// provenance. Capturing call sites has a cost and is meant for debugging
+// runaway pattern sources.
+func WithCallerCapture() Option {
+	return func(e *Expander) {
+		e.config.captureCallers = true
+	}
+}
+
+// LiteralPolicy decides how a literal instance number is treated when the
+// same table is also addressed by a wildcard, e.g. "AccessPoint.2.Enable"
+// next to "AccessPoint.*.Enable".
+type LiteralPolicy int
+
+// Literal index policies
+const (
+	// LiteralKeep always emits literal paths, whether or not discovery of the
+	// table lists the instance. This is the default.
+	LiteralKeep LiteralPolicy = iota
+
+	// LiteralValidate only emits literal paths, and only discovers below them,
+	// once discovery of the table has listed the instance.
+	LiteralValidate
+)
+
+// WithLiteralPolicy sets how literal indices next to wildcards are treated.
+// Literal paths with no wildcard sibling are never validated.
+func WithLiteralPolicy(policy LiteralPolicy) Option {
+	return func(e *Expander) {
+		e.config.literalPolicy = policy
+	}
+}
+
+// TraversalOrder decides in which order the pending discoveries of the
+// branches of the path tree are handed out.
+type TraversalOrder int
+
+// Traversal orders
+const (
+	// BreadthFirst appends new discoveries to the queue, so the discoveries
+	// of a level are handed out next to each other before the level below.
+	// Devices caching GetParameterNames answers internally benefit from the
+	// related requests staying adjacent. This is the default.
+	BreadthFirst TraversalOrder = iota
+
+	// DepthFirst puts the discoveries found below a registered discovery in
+	// front of the queue, so each branch is finished before the next one is
+	// started and its paths can be streamed sooner.
+	DepthFirst
+)
+
+// WithTraversalOrder sets the order in which pending discoveries are handed
+// out, e.g. per device model. Shuffling and pattern priorities still apply
+// on top of it.
+func WithTraversalOrder(order TraversalOrder) Option {
+	return func(e *Expander) {
+		e.config.traversal = order
+	}
+}
+
+// WithShuffledDiscoveries makes Next and NextBatch hand out the pending
+// discoveries in a random order drawn from seed, instead of queue order.
+// The same seed and the same registrations always yield the same order, so
+// load tests stay reproducible while avoiding synchronized request patterns
+// across devices polled on the same schedule.
+func WithShuffledDiscoveries(seed int64) Option {
+	return func(e *Expander) {
+		e.config.shuffle = rand.New(rand.NewPCG(uint64(seed), 0))
+	}
+}
@@ -0,0 +1,84 @@
+package expander
+
+import (
+	"slices"
+	"strings"
+)
+
+// PathOrder compares two expanded paths, returning a negative number when a
+// sorts before b, a positive number when a sorts after b and zero when they
+// are equal, as strings.Compare does.
+type PathOrder func(a, b string) int
+
+// WithPathOrder sorts the expanded paths returned by Collect and the paths
+// of each pattern with order instead of lexically, e.g. with NaturalOrder so
+// "AccessPoint.2.Enable" sorts before "AccessPoint.10.Enable". A nil order
+// sorts lexically.
+func WithPathOrder(order PathOrder) Option {
+	return func(e *Expander) {
+		e.config.pathOrder = order
+	}
+}
+
+// NaturalOrder compares paths segment by segment, numerically where both
+// segments are instance numbers and lexically otherwise, so paths sort in
+// the order of their instance numbers. A path sorts before the paths below it.
+func NaturalOrder(a, b string) int {
+	for {
+		segmentA, restA, moreA := strings.Cut(a, ".")
+		segmentB, restB, moreB := strings.Cut(b, ".")
+		if c := compareSegments(segmentA, segmentB); c != 0 {
+			return c
+		}
+		switch {
+		case !moreA && !moreB:
+			return 0
+		case !moreA:
+			return -1
+		case !moreB:
+			return 1
+		}
+		a, b = restA, restB
+	}
+}
+
+// compareSegments compares two path segments, numerically if both are
+// made of digits
+func compareSegments(a, b string) int {
+	if !isNumber(a) || !isNumber(b) {
+		return strings.Compare(a, b)
+	}
+
+	// Compare by magnitude without parsing, so no number overflows; leading
+	// zeros only break ties
+	trimmedA, trimmedB := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(trimmedA) != len(trimmedB) {
+		return len(trimmedA) - len(trimmedB)
+	}
+	if c := strings.Compare(trimmedA, trimmedB); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// isNumber reports whether a segment is a non-empty run of digits
+func isNumber(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for i := range len(segment) {
+		if segment[i] < '0' || segment[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// sortPaths sorts expanded paths in the configured path order
+func (e *Expander) sortPaths(paths []string) {
+	if e.config.pathOrder == nil {
+		slices.Sort(paths)
+		return
+	}
+	slices.SortFunc(paths, e.config.pathOrder)
+}
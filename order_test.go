@@ -0,0 +1,66 @@
+package expander_test
+
+import (
+	"strings"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path Order", func() {
+	// collect expands access points 1, 2 and 10
+	collect := func(opts ...expander.Option) []string {
+		exp := expander.Get(opts...)
+		defer expander.Release(exp)
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{
+			"Device.WiFi.AccessPoint.10.",
+			"Device.WiFi.AccessPoint.2.",
+			"Device.WiFi.AccessPoint.1.",
+		})).To(Succeed())
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		return paths
+	}
+
+	It("should sort lexically by default", func() {
+		Expect(collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.10.Enable",
+			"Device.WiFi.AccessPoint.2.Enable",
+		}))
+	})
+
+	It("should sort by instance number in natural order", func() {
+		Expect(collect(expander.WithPathOrder(expander.NaturalOrder))).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.2.Enable",
+			"Device.WiFi.AccessPoint.10.Enable",
+		}))
+	})
+
+	It("should sort with a custom order", func() {
+		descending := func(a, b string) int { return strings.Compare(b, a) }
+		Expect(collect(expander.WithPathOrder(descending))).To(Equal([]string{
+			"Device.WiFi.AccessPoint.2.Enable",
+			"Device.WiFi.AccessPoint.10.Enable",
+			"Device.WiFi.AccessPoint.1.Enable",
+		}))
+	})
+
+	DescribeTable("NaturalOrder",
+		func(a, b string, want int) {
+			Expect(expander.NaturalOrder(a, b)).To(Equal(want))
+			Expect(expander.NaturalOrder(b, a)).To(Equal(-want))
+		},
+		Entry("equal paths", "Device.Hosts.Host.3.IPAddress", "Device.Hosts.Host.3.IPAddress", 0),
+		Entry("instance numbers", "Device.Hosts.Host.9.IPAddress", "Device.Hosts.Host.12.IPAddress", -1),
+		Entry("names", "Device.Hosts.Host.1.Active", "Device.Hosts.Host.1.IPAddress", -1),
+		Entry("parents first", "Device.Hosts.Host.1.", "Device.Hosts.Host.1.Active", -1),
+		Entry("objects before deeper names", "Device.IP.Interface.2", "Device.IP.Interface.10.Status", -1),
+		Entry("leading zeros", "Device.X.1", "Device.X.01", 1),
+	)
+})
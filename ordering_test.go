@@ -0,0 +1,52 @@
+package expander_test
+
+import (
+	"context"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Discovery Ordering", func() {
+	patterns := []string{
+		"Device.WiFi.SSID.*.SSID",
+		"Device.IP.Interface.*.IPv4Address.*.IPAddress",
+		"Device.Hosts.Host.*.IPAddress",
+		"Device.Ethernet.Interface.*.Status",
+	}
+
+	// record expands the patterns and returns the discoveries in the order
+	// they were handed out
+	record := func(patterns ...string) []string {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.Add(patterns...)).To(Succeed())
+
+		var sequence []string
+		_, err := exp.Expand(context.Background(), func(_ context.Context, path string) ([]string, error) {
+			sequence = append(sequence, path)
+			return []string{path + "2.", path + "1."}, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return sequence
+	}
+
+	It("should hand out discoveries in the order the patterns were added", func() {
+		Expect(record(patterns...)).To(Equal([]string{
+			"Device.WiFi.SSID.",
+			"Device.IP.Interface.",
+			"Device.Hosts.Host.",
+			"Device.Ethernet.Interface.",
+			"Device.IP.Interface.1.IPv4Address.",
+			"Device.IP.Interface.2.IPv4Address.",
+		}))
+	})
+
+	It("should repeat the same sequence on every run", func() {
+		first := record(patterns...)
+		for range 20 {
+			Expect(record(patterns...)).To(Equal(first))
+		}
+	})
+})
@@ -0,0 +1,56 @@
+package expander
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidLevel is returned when a truncation depth is not positive
+var ErrInvalidLevel = errors.New("level must be positive")
+
+// CollectPartialPaths returns the expanded paths truncated to their first
+// level segments, as partial paths with a trailing dot, for callers that
+// issue partial path GetParameterValues requests and filter the response
+// client-side. For example, level 4 turns "Device.WiFi.AccessPoint.1.Enable"
+// into "Device.WiFi.AccessPoint.1.". Paths with no more than level segments
+// are returned unchanged. The result is sorted and free of duplicates and
+// of paths already covered by another partial path in it.
+// This should be called after Next() returns false.
+func (e *Expander) CollectPartialPaths(level int) ([]string, error) {
+	if level < 1 {
+		return nil, ErrInvalidLevel
+	}
+	if err := e.ensureComplete(); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var partials []string
+	for _, path := range e.visiblePaths(e.expandedPaths) {
+		object := path
+		if strings.Count(path, ".") >= level {
+			object, _ = splitAfterSegments(path, level)
+		}
+		if !seen[object] {
+			seen[object] = true
+			partials = append(partials, object)
+		}
+	}
+	sort.Strings(partials)
+
+	// Sorting puts every partial path right before the paths it covers
+	result := partials[:0]
+	for _, path := range partials {
+		if n := len(result); n > 0 && isPartialPrefix(result[n-1], path) {
+			continue
+		}
+		result = append(result, path)
+	}
+	return result, nil
+}
+
+// isPartialPrefix reports whether partial is a partial path covering path
+func isPartialPrefix(partial, path string) bool {
+	return partial[len(partial)-1] == '.' && len(path) > len(partial) && path[:len(partial)] == partial
+}
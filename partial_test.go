@@ -0,0 +1,64 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Partial Path Collection", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.WiFi.AccessPoint.*.Security.ModeEnabled",
+			"Device.WiFi.AccessPoint.*.AssociatedDevice.",
+			"Device.DeviceInfo.UpTime",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.Register([]string{"Device.WiFi.AccessPoint.1", "Device.WiFi.AccessPoint.2"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should truncate paths to object instances", func() {
+		paths, err := exp.CollectPartialPaths(4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.DeviceInfo.UpTime",
+			"Device.WiFi.AccessPoint.1.",
+			"Device.WiFi.AccessPoint.2.",
+		}))
+	})
+
+	It("should drop paths covered by a shallower partial path", func() {
+		paths, err := exp.CollectPartialPaths(3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.DeviceInfo.UpTime",
+			"Device.WiFi.AccessPoint.",
+		}))
+	})
+
+	It("should keep shorter paths unchanged", func() {
+		paths, err := exp.CollectPartialPaths(5)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(ContainElements(
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.1.Security.",
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.",
+		))
+	})
+
+	It("should reject non-positive levels", func() {
+		_, err := exp.CollectPartialPaths(0)
+		Expect(err).To(MatchError(expander.ErrInvalidLevel))
+	})
+})
@@ -0,0 +1,84 @@
+package expander
+
+import (
+	"slices"
+	"strings"
+)
+
+// matchPattern checks whether a concrete path is matched by a pattern and
+// returns the instance segments bound to each wildcard, named wildcard and
+// index selector, in order. Patterns ending with a dot are partial paths and
+// match every path underneath them. Like expansion, "**" matches any number
+// of levels and a trailing "**" the parameters of the subtree, glob segments
+// match the names they embed, and a trailing wildcard or capture matches
+// instance object paths as well as parameters.
+func matchPattern(pattern, path string) ([]string, bool) {
+	pattern = normalizePattern(pattern)
+	partial := strings.HasSuffix(pattern, ".")
+	if partial {
+		pattern = strings.TrimSuffix(pattern, ".")
+	}
+	return matchSegments(strings.Split(pattern, "."), strings.Split(path, "."), partial, nil)
+}
+
+// matchSegments matches the segments of a pattern against those of a path,
+// appending bound instance segments to bindings
+func matchSegments(pattern, path []string, partial bool, bindings []string) ([]string, bool) {
+	for i, segment := range pattern {
+		if segment == "**" {
+			return matchRecursive(pattern[i+1:], path, partial, bindings)
+		}
+		if len(path) == 0 {
+			return nil, false
+		}
+
+		if isBindingSegment(segment) {
+			selector, _ := parseIndexSelector(segment)
+			if path[0] == "" || (selector != nil && !selector.matches(path[0])) {
+				return nil, false
+			}
+			bindings = append(bindings, path[0])
+		} else if isGlobSegment(segment) {
+			if !matchGlob(segment, path[0]) {
+				return nil, false
+			}
+		} else if segment != path[0] {
+			return nil, false
+		}
+		path = path[1:]
+	}
+
+	// A partial path pattern only matches strictly below itself
+	if partial {
+		return bindings, len(path) > 0
+	}
+
+	// A trailing wildcard also matches the instance object paths of a table
+	if len(path) == 1 && path[0] == "" && len(pattern) > 0 && pattern[len(pattern)-1] == "*" {
+		return bindings, true
+	}
+	return bindings, len(path) == 0
+}
+
+// matchRecursive matches the pattern segments following "**" at every level
+// below the current one, shallowest first
+func matchRecursive(rest, path []string, partial bool, bindings []string) ([]string, bool) {
+	// A trailing "**" stands for parameters, at least one level down
+	minimum := 0
+	if len(rest) == 0 && !partial {
+		minimum = 1
+	}
+
+	for skip := 0; skip <= len(path); skip++ {
+		if skip > 0 && path[skip-1] == "" {
+			break
+		}
+		if skip < minimum {
+			continue
+		}
+		if values, ok := matchSegments(rest, path[skip:], partial, slices.Clip(bindings)); ok {
+			return values, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,175 @@
+// Package poller packages the periodic polling workflow built on top of the
+// expander: expand a fixed pattern manifest against one device on every
+// cycle, reuse discovery results between cycles, rediscover periodically to
+// pick up added or removed instances, and report what changed.
+package poller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+)
+
+var (
+	// ErrNoPatterns is returned by New when the manifest is empty
+	ErrNoPatterns = errors.New("poller requires at least one pattern")
+
+	// ErrNoDiscovery is returned by New when no discovery function is given
+	ErrNoDiscovery = errors.New("poller requires a discovery function")
+
+	// ErrNoInterval is returned by Run when the interval is not positive
+	ErrNoInterval = errors.New("poller requires a positive interval")
+)
+
+// Config describes a polling workflow for a single device.
+type Config struct {
+	// Patterns is the manifest of paths to expand on every cycle
+	Patterns []string
+
+	// Discover issues a GetParameterNames call against the device
+	Discover expander.DiscoveryFunc
+
+	// Interval is the time between the start of two cycles in Run
+	Interval time.Duration
+
+	// RefreshEvery is the number of cycles a discovery result is reused
+	// before it is considered stale and fetched again. Zero or one
+	// rediscovers on every cycle.
+	RefreshEvery int
+
+	// Options are applied to the expander used for every cycle
+	Options []expander.Option
+}
+
+// Result describes the outcome of a single polling cycle.
+type Result struct {
+	// Cycle is the 1-based number of the cycle
+	Cycle int
+
+	// Paths holds every expanded path, sorted as Collect sorts them
+	Paths []string
+
+	// Added and Removed hold the paths that appeared or disappeared since
+	// the last successful cycle, sorted lexically. The first cycle reports
+	// every path as added.
+	Added   []string
+	Removed []string
+
+	// Refreshed reports whether cached discoveries were dropped before the cycle
+	Refreshed bool
+
+	// Discoveries is the number of discoveries sent to the device
+	Discoveries int
+
+	// Err is set when the cycle failed; the other fields are then unset
+	// apart from Cycle
+	Err error
+}
+
+// Poller runs the polling cycles described by a Config. It is not safe for
+// concurrent use.
+type Poller struct {
+	config Config
+	cycle  int
+
+	// discovered holds the names returned for each discovery path since the
+	// last refresh
+	discovered map[string][]string
+	age        int
+	previous   []string
+}
+
+// New validates cfg and returns a Poller for it.
+func New(cfg Config) (*Poller, error) {
+	if len(cfg.Patterns) == 0 {
+		return nil, ErrNoPatterns
+	}
+	if cfg.Discover == nil {
+		return nil, ErrNoDiscovery
+	}
+	return &Poller{
+		config:     cfg,
+		discovered: make(map[string][]string),
+	}, nil
+}
+
+// Poll runs a single cycle. A failed cycle drops the reused discoveries so
+// the next one starts afresh; the previous paths are kept for diffing.
+func (p *Poller) Poll(ctx context.Context) (Result, error) {
+	p.cycle++
+	result := Result{Cycle: p.cycle}
+
+	if p.age >= max(p.config.RefreshEvery, 1) {
+		clear(p.discovered)
+		p.age = 0
+		result.Refreshed = p.cycle > 1
+	}
+	p.age++
+
+	exp := expander.Get(p.config.Options...)
+	defer expander.Release(exp)
+
+	if err := exp.Add(p.config.Patterns...); err != nil {
+		return p.fail(result, err)
+	}
+
+	paths, err := exp.Expand(ctx, func(ctx context.Context, path string) ([]string, error) {
+		if names, ok := p.discovered[path]; ok {
+			return names, nil
+		}
+		names, err := p.config.Discover(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		result.Discoveries++
+		p.discovered[path] = names
+		return names, nil
+	})
+	if err != nil {
+		return p.fail(result, err)
+	}
+
+	result.Paths = paths
+	changes := expander.Snapshot{Paths: p.previous}.Diff(expander.Snapshot{Paths: paths})
+	result.Added, result.Removed = changes.AddedPaths, changes.RemovedPaths
+	p.previous = paths
+	return result, nil
+}
+
+// Run polls immediately and then once per interval until ctx is done, handing
+// every result to fn, failed cycles included. It stops with the first error
+// returned by fn, or with the context error.
+func (p *Poller) Run(ctx context.Context, fn func(Result) error) error {
+	if p.config.Interval <= 0 {
+		return ErrNoInterval
+	}
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		result, _ := p.Poll(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(result); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// fail records a failed cycle
+func (p *Poller) fail(result Result, err error) (Result, error) {
+	clear(p.discovered)
+	p.age = 0
+	result.Err = err
+	return result, err
+}
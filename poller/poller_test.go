@@ -0,0 +1,154 @@
+package poller_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	"github.com/metalgrid/tr069-path-expander/v2/poller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPoller(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Poller Suite")
+}
+
+// device answers discoveries from a mutable list of parameter names and
+// counts the calls it receives
+type device struct {
+	names []string
+	calls int
+	err   error
+}
+
+func (d *device) discover(_ context.Context, path string) ([]string, error) {
+	d.calls++
+	if d.err != nil {
+		return nil, d.err
+	}
+	var result []string
+	for _, name := range d.names {
+		if strings.HasPrefix(name, path) {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+var _ = Describe("Poller", func() {
+	var dev *device
+
+	BeforeEach(func() {
+		dev = &device{names: []string{"Device.WiFi.SSID.1.", "Device.WiFi.SSID.2."}}
+	})
+
+	It("should validate its configuration", func() {
+		_, err := poller.New(poller.Config{Discover: dev.discover})
+		Expect(err).To(MatchError(poller.ErrNoPatterns))
+
+		_, err = poller.New(poller.Config{Patterns: []string{"Device.WiFi.SSID.*.SSID"}})
+		Expect(err).To(MatchError(poller.ErrNoDiscovery))
+	})
+
+	It("should reuse discoveries until they are stale and report changes", func() {
+		p, err := poller.New(poller.Config{
+			Patterns:     []string{"Device.WiFi.SSID.*.SSID"},
+			Discover:     dev.discover,
+			RefreshEvery: 2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		first, err := p.Poll(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.Cycle).To(Equal(1))
+		Expect(first.Discoveries).To(Equal(1))
+		Expect(first.Added).To(Equal([]string{"Device.WiFi.SSID.1.SSID", "Device.WiFi.SSID.2.SSID"}))
+		Expect(first.Removed).To(BeEmpty())
+
+		dev.names = []string{"Device.WiFi.SSID.2.", "Device.WiFi.SSID.3."}
+
+		cached, err := p.Poll(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached.Discoveries).To(Equal(0))
+		Expect(cached.Refreshed).To(BeFalse())
+		Expect(cached.Added).To(BeEmpty())
+
+		refreshed, err := p.Poll(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refreshed.Refreshed).To(BeTrue())
+		Expect(refreshed.Discoveries).To(Equal(1))
+		Expect(refreshed.Paths).To(Equal([]string{"Device.WiFi.SSID.2.SSID", "Device.WiFi.SSID.3.SSID"}))
+		Expect(refreshed.Added).To(Equal([]string{"Device.WiFi.SSID.3.SSID"}))
+		Expect(refreshed.Removed).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+	})
+
+	It("should report changes with paths in natural order", func() {
+		dev.names = []string{"Device.WiFi.SSID.2.", "Device.WiFi.SSID.10."}
+		p, err := poller.New(poller.Config{
+			Patterns: []string{"Device.WiFi.SSID.*.SSID"},
+			Discover: dev.discover,
+			Options:  []expander.Option{expander.WithPathOrder(expander.NaturalOrder)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = p.Poll(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		dev.names = []string{"Device.WiFi.SSID.2.", "Device.WiFi.SSID.3.", "Device.WiFi.SSID.10."}
+		result, err := p.Poll(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Paths).To(Equal([]string{
+			"Device.WiFi.SSID.2.SSID",
+			"Device.WiFi.SSID.3.SSID",
+			"Device.WiFi.SSID.10.SSID",
+		}))
+		Expect(result.Added).To(Equal([]string{"Device.WiFi.SSID.3.SSID"}))
+		Expect(result.Removed).To(BeEmpty())
+	})
+
+	It("should rediscover after a failed cycle", func() {
+		p, err := poller.New(poller.Config{
+			Patterns:     []string{"Device.WiFi.SSID.*.SSID"},
+			Discover:     dev.discover,
+			RefreshEvery: 10,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		dev.err = errors.New("session closed")
+		result, err := p.Poll(context.Background())
+		Expect(err).To(MatchError(dev.err))
+		Expect(result.Err).To(MatchError(dev.err))
+
+		dev.err = nil
+		result, err = p.Poll(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Discoveries).To(Equal(1))
+		Expect(result.Paths).To(HaveLen(2))
+	})
+
+	It("should run cycles until the callback stops it", func() {
+		p, err := poller.New(poller.Config{
+			Patterns: []string{"Device.WiFi.SSID.*.SSID"},
+			Discover: dev.discover,
+			Interval: time.Millisecond,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		done := errors.New("done")
+		var cycles []int
+		err = p.Run(context.Background(), func(result poller.Result) error {
+			cycles = append(cycles, result.Cycle)
+			if len(cycles) == 3 {
+				return done
+			}
+			return nil
+		})
+		Expect(err).To(MatchError(done))
+		Expect(cycles).To(Equal([]int{1, 2, 3}))
+		Expect(dev.calls).To(Equal(3))
+	})
+})
@@ -2,40 +2,140 @@ package expander
 
 import "sync"
 
-// expanderPool manages a pool of expanders for performance optimization.
+// Pool manages a pool of expanders sharing one configuration. Expanders
+// configured with heavy options should come from their own Pool so they are
+// never handed out to callers expecting a lightweight expander. The options
+// are applied again on every Get, once the expander is reset, so options
+// with state of their own, such as WithShuffledDiscoveries, start afresh;
+// heavy inputs such as schemas should be built once and shared.
+type Pool struct {
+	pool    sync.Pool
+	opts    []Option
+	metrics poolMetrics
+}
+
+// defaultPool backs the package-level Get and Release functions.
 // When an expander is retrieved from the pool, it starts with a fresh state.
-var expanderPool = sync.Pool{
-	New: func() any {
-		return &Expander{
-			paths: pathTree{
-				root: &pathNode{
-					children: make(map[string]*pathNode),
-				},
+var defaultPool = NewPool()
+
+// CapacityHints describe the typical workload of an expander so its internal
+// structures can be preallocated.
+type CapacityHints struct {
+	// Patterns is the expected number of distinct patterns
+	Patterns int
+
+	// Discoveries is the expected number of discovery paths
+	Discoveries int
+
+	// Paths is the expected number of expanded paths
+	Paths int
+}
+
+// defaultHints are used for expanders created on demand
+var defaultHints = CapacityHints{Discoveries: 8, Paths: 16}
+
+// NewPool creates a pool whose expanders are configured with opts.
+func NewPool(opts ...Option) *Pool {
+	p := &Pool{opts: opts}
+	p.pool.New = func() any {
+		p.metrics.record(PoolNew)
+		return newExpander(defaultHints)
+	}
+	return p
+}
+
+// Warm preallocates n expanders sized according to hints and adds them to the
+// pool, so bursts of Get calls don't pay for allocation and map growth.
+// Pooled expanders may still be reclaimed by the garbage collector.
+func (p *Pool) Warm(n int, hints CapacityHints) {
+	for range n {
+		p.metrics.record(PoolNew)
+		p.pool.Put(newExpander(hints))
+	}
+}
+
+// WarmPool preallocates n expanders sized according to hints in the default
+// pool. It is intended to be called at startup, before a reconnect storm.
+func WarmPool(n int, hints CapacityHints) {
+	defaultPool.Warm(n, hints)
+}
+
+// newExpander allocates an expander with empty state sized according to hints
+func newExpander(hints CapacityHints) *Expander {
+	cache := make(mapCache, hints.Discoveries)
+	return &Expander{
+		paths: pathTree{
+			root: &pathNode{
+				children: make(map[string]*pathNode),
 			},
-			cache:                make(map[string][]int),
-			processedDiscoveries: make(map[string]bool),
-			expandedSet:          make(map[string]bool),
-			pendingDiscoveries:   make([]string, 0, 8),
-			expandedPaths:        make([]string, 0, 16),
-		}
-	},
+		},
+		cache:                cache,
+		localCache:           cache,
+		aliases:              make(map[string][]string),
+		names:                make(map[string][]string),
+		aliasValues:          make(map[string]map[int]string),
+		processedDiscoveries: make(map[string]bool, hints.Discoveries),
+		outstanding:          make(map[string]bool),
+		expandedSet:          make(map[string]bool, hints.Paths),
+		patternPaths:         make(map[string][]string, hints.Patterns),
+		provenance:           make(map[string][]Attribution),
+		writable:             make(map[string]bool),
+		owners:               make(map[string][]string),
+		responseSizes:        make(map[string]int, hints.Discoveries),
+		filters:              make(map[string][]Filter),
+		values:               make(map[string]string),
+		valueQueries:         make(map[string]bool),
+		missingValues:        make(map[string]bool),
+		searches:             make(map[string][]condition),
+		faults:               make(map[string]int),
+		skipped:              make(map[string]bool),
+		malformed:            make(map[string]error),
+		blockedHits:          make(map[string]bool),
+		priorities:           make(map[string]int),
+		discoveryPriorities:  make(map[string]int),
+		pendingDiscoveries:   make([]string, 0, hints.Discoveries),
+		queued:               make(map[string]bool, hints.Discoveries),
+		expandedPaths:        make([]string, 0, hints.Paths),
+		patterns:             make([]string, 0, hints.Patterns),
+	}
 }
 
-// Get retrieves an expander from the pool with a fresh state.
-// The expander should be returned to the pool using Release() when done.
-// If you want to reuse the cache, keep the expander instance and don't release it.
-func Get() *Expander {
-	exp := expanderPool.Get().(*Expander)
+// Get retrieves an expander from the pool with a fresh state, configured with
+// the pool's options followed by opts.
+func (p *Pool) Get(opts ...Option) *Expander {
+	exp := p.pool.Get().(*Expander)
+	p.metrics.record(PoolGet)
 	// Ensure clean state
 	exp.Reset()
+	exp.pool = p
+	for _, opt := range p.opts {
+		opt(exp)
+	}
+	for _, opt := range opts {
+		opt(exp)
+	}
 	return exp
 }
 
-// Release returns an expander to the pool for reuse.
+// Get retrieves an expander from the default pool with a fresh state and applies the
+// given options. The expander should be returned to the pool using Release() when done.
+// If you want to reuse the cache, keep the expander instance and don't release it.
+func Get(opts ...Option) *Expander {
+	return defaultPool.Get(opts...)
+}
+
+// Release returns an expander to the pool it was retrieved from.
 // The expander's state will be reset when it's retrieved again.
 // Do not use the expander after calling Release().
 func Release(exp *Expander) {
-	if exp != nil {
-		expanderPool.Put(exp)
+	if exp == nil {
+		return
+	}
+
+	pool := exp.pool
+	if pool == nil {
+		pool = defaultPool
 	}
+	pool.metrics.record(PoolPut)
+	pool.pool.Put(exp)
 }
@@ -0,0 +1,86 @@
+package expander
+
+import "strings"
+
+// AddPrioritized adds paths like Add with a priority: Next and NextBatch
+// hand out the pending discoveries needed by patterns of a higher priority
+// first, so e.g. WAN status for an outage dashboard is discovered before
+// inventory patterns sharing the expander. Patterns added otherwise have
+// priority zero; a pattern added several times keeps its highest priority.
+// Discoveries of equal priority keep their queue order.
+func (e *Expander) AddPrioritized(priority int, paths ...string) error {
+	if err := e.add(e.attribute(""), paths); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if current, ok := e.priorities[path]; !ok || priority > current {
+			e.priorities[path] = priority
+		}
+	}
+	e.forgetPriorities()
+	return nil
+}
+
+// prioritizedPattern is a pattern split into the segments of its tree path,
+// along with its priority
+type prioritizedPattern struct {
+	segments []string
+	priority int
+}
+
+// discoveryPriority returns the highest priority of the patterns needing a
+// discovery path
+func (e *Expander) discoveryPriority(discoveryPath string) int {
+	if priority, ok := e.discoveryPriorities[discoveryPath]; ok {
+		return priority
+	}
+	if e.prioritized == nil {
+		e.prioritized = make([]prioritizedPattern, len(e.patterns))
+		for i, pattern := range e.patterns {
+			e.prioritized[i] = prioritizedPattern{
+				segments: strings.Split(e.treePath(pattern), "."),
+				priority: e.priorities[pattern],
+			}
+		}
+	}
+
+	path := strings.Split(strings.TrimSuffix(discoveryPath, "."), ".")
+	best, found := 0, false
+	for _, pattern := range e.prioritized {
+		if len(pattern.segments) <= len(path) || !matchesPrefix(pattern.segments, path) {
+			continue
+		}
+		if !found || pattern.priority > best {
+			best, found = pattern.priority, true
+		}
+	}
+	e.discoveryPriorities[discoveryPath] = best
+	return best
+}
+
+// forgetPriorities drops the priorities computed for discovery paths once
+// patterns or priorities change
+func (e *Expander) forgetPriorities() {
+	e.prioritized = nil
+	clear(e.discoveryPriorities)
+}
+
+// promotePriority moves the first pending discovery of the highest
+// priority to the front of the queue
+func (e *Expander) promotePriority() {
+	if len(e.priorities) == 0 || len(e.pendingDiscoveries) < 2 {
+		return
+	}
+
+	best, bestPriority := 0, e.discoveryPriority(e.pendingDiscoveries[0])
+	for i := 1; i < len(e.pendingDiscoveries); i++ {
+		if priority := e.discoveryPriority(e.pendingDiscoveries[i]); priority > bestPriority {
+			best, bestPriority = i, priority
+		}
+	}
+	if best > 0 {
+		path := e.pendingDiscoveries[best]
+		copy(e.pendingDiscoveries[1:best+1], e.pendingDiscoveries[:best])
+		e.pendingDiscoveries[0] = path
+	}
+}
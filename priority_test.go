@@ -0,0 +1,67 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pattern Priorities", func() {
+	const (
+		inventory = "Device.Hosts.Host.*.IPAddress"
+		status    = "Device.IP.Interface.*.Status"
+	)
+
+	It("should discover for high-priority patterns first", func() {
+		// Without priorities the order follows the tree walk, so try repeatedly
+		for range 20 {
+			exp := expander.Get()
+			Expect(exp.Add(inventory)).To(Succeed())
+			Expect(exp.AddPrioritized(10, status)).To(Succeed())
+
+			path, hasMore := exp.Next()
+			Expect(hasMore).To(BeTrue())
+			Expect(path).To(Equal("Device.IP.Interface."))
+			expander.Release(exp)
+		}
+	})
+
+	It("should defer low-priority patterns", func() {
+		for range 20 {
+			exp := expander.Get()
+			Expect(exp.AddPrioritized(-1, inventory)).To(Succeed())
+			Expect(exp.Add(status)).To(Succeed())
+
+			path, _ := exp.Next()
+			Expect(path).To(Equal("Device.IP.Interface."))
+			Expect(exp.Register([]string{"Device.IP.Interface.1."})).To(Succeed())
+			path, _ = exp.Next()
+			Expect(path).To(Equal("Device.Hosts.Host."))
+			expander.Release(exp)
+		}
+	})
+
+	It("should follow priorities raised while discoveries are pending", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.AddPrioritized(1, "Device.WiFi.SSID.*.SSID")).To(Succeed())
+		Expect(exp.Add(inventory, status)).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.WiFi.SSID."))
+
+		Expect(exp.AddPrioritized(5, status)).To(Succeed())
+		path, _ = exp.Next()
+		Expect(path).To(Equal("Device.IP.Interface."))
+	})
+
+	It("should expand prioritized patterns like added ones", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.AddPrioritized(5, status)).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{"Device.IP.Interface.1.", "Device.IP.Interface.2."})).To(Succeed())
+		Expect(exp.Collect()).To(ConsistOf("Device.IP.Interface.1.Status", "Device.IP.Interface.2.Status"))
+	})
+})
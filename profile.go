@@ -0,0 +1,60 @@
+package expander
+
+// Profiles bundle options into coherent behavior sets, so callers can opt
+// into a way of working instead of tuning every option individually. A
+// profile is an Option like any other; options given after it override the
+// settings it makes. Profiles may take on new options as behavior is added.
+
+// WithOptions combines several options into one, applied in order.
+func WithOptions(opts ...Option) Option {
+	return func(e *Expander) {
+		for _, opt := range opts {
+			opt(e)
+		}
+	}
+}
+
+// StrictProfile only reports what the device confirms and keeps credentials
+// out of the results: literal indices next to wildcards are validated
+// against discovery, alias-addressed instances are ignored, and sensitive
+// parameters are excluded.
+func StrictProfile() Option {
+	return WithOptions(
+		WithLiteralPolicy(LiteralValidate),
+		WithAliasCodec(nil),
+		WithSensitiveFilter(SensitiveExclude, nil),
+	)
+}
+
+// LenientFieldProfile accepts what deployed firmwares tend to return:
+// alias-addressed instances in the TR-069 Amendment 5 syntax are expanded,
+// literal indices are kept without validation, segments embedding wildcards
+// match vendor-specific names instead of being rejected, and responses
+// listing whole subtrees, names in another case or names relative to the
+// discovery path are taken as the device meant them.
+func LenientFieldProfile() Option {
+	return WithOptions(
+		WithAliasCodec(BracketAliases),
+		WithLiteralPolicy(LiteralKeep),
+		WithGlobSegments(),
+		WithLenientSubtrees(),
+		WithCaseInsensitive(),
+		WithPrefixRepair(),
+	)
+}
+
+// HighThroughputProfile keeps the cost per device low when expanding for
+// many devices: no discovery is issued only to validate literal indices,
+// deeper levels a device lists anyway resolve the discoveries below the
+// requested one, discoveries are not paced, and call sites of Add are not
+// captured.
+func HighThroughputProfile() Option {
+	return WithOptions(
+		WithLiteralPolicy(LiteralKeep),
+		WithLenientSubtrees(),
+		WithLimiter(nil),
+		func(e *Expander) {
+			e.config.captureCallers = false
+		},
+	)
+}
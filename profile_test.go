@@ -0,0 +1,88 @@
+package expander_test
+
+import (
+	"context"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Profiles", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+		exp = nil
+	})
+
+	It("should validate literals and exclude credentials in the strict profile", func() {
+		exp = expander.Get(expander.StrictProfile())
+		err := exp.Add("Device.WiFi.AccessPoint.*.Security.KeyPassphrase", "Device.WiFi.AccessPoint.7.Enable")
+		Expect(err).NotTo(HaveOccurred())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "[guest]."})).To(Succeed())
+
+		Expect(exp.Collect()).To(BeEmpty())
+	})
+
+	It("should accept aliases and glob segments in the lenient field profile", func() {
+		exp = expander.Get(expander.LenientFieldProfile())
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.X_*_Band")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "[guest]."})).To(Succeed())
+		path, _ = exp.Next()
+		Expect(path).To(Equal("Device.WiFi.AccessPoint.[guest]."))
+		Expect(exp.Register([]string{path + "X_ACME_Band", path + "Enable"})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.AccessPoint.[guest].X_ACME_Band"}))
+	})
+
+	It("should take relative, differently cased and deep names in the lenient field profile", func() {
+		exp = expander.Get(expander.LenientFieldProfile())
+		Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{"1.", "1.IPv4Address.1.", "device.hosts.host.2.ipv4address.3."})).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+			"Device.Hosts.Host.2.IPv4Address.3.IPAddress",
+		}))
+		Expect(exp.Warnings()).To(ContainElement(HaveField("Path", path)))
+	})
+
+	It("should let later options override a profile", func() {
+		exp = expander.Get(expander.StrictProfile(), expander.WithLiteralPolicy(expander.LiteralKeep))
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable", "Device.WiFi.AccessPoint.7.Enable")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1."})).To(Succeed())
+
+		Expect(exp.Collect()).To(ConsistOf("Device.WiFi.AccessPoint.1.Enable", "Device.WiFi.AccessPoint.7.Enable"))
+	})
+
+	It("should apply the high throughput profile", func() {
+		exp = expander.Get(expander.WithCallerCapture(), expander.HighThroughputProfile())
+		Expect(exp.Add("Device.DeviceInfo.SoftwareVersion")).To(Succeed())
+		Expect(exp.Provenance("Device.DeviceInfo.SoftwareVersion")).To(BeEmpty())
+	})
+
+	It("should skip discoveries and pacing in the high throughput profile", func() {
+		exp = expander.Get(expander.WithRateLimit(0.001), expander.HighThroughputProfile())
+		Expect(exp.Add("Device.Hosts.Host.*.IPv4Address.*.IPAddress", "Device.Hosts.Host.5.IPv4Address.*.IPAddress")).To(Succeed())
+
+		var requested []string
+		paths, err := exp.Expand(context.Background(), func(ctx context.Context, path string) ([]string, error) {
+			requested = append(requested, path)
+			return fakeDevice("Device.Hosts.Host.1.IPv4Address.1.IPAddress")(ctx, path)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requested).To(Equal([]string{"Device.Hosts.Host.", "Device.Hosts.Host.5.IPv4Address."}))
+		Expect(paths).To(Equal([]string{"Device.Hosts.Host.1.IPv4Address.1.IPAddress"}))
+	})
+})
@@ -0,0 +1,47 @@
+package expander
+
+import (
+	"runtime"
+	"slices"
+	"strconv"
+)
+
+// Attribution identifies who added a pattern: an explicit label, the call
+// site of the Add call, or both.
+type Attribution struct {
+	Label    string
+	CallSite string
+}
+
+// AddLabeled adds paths like Add and attributes them to label, so operators
+// can trace which subsystem added a pattern.
+func (e *Expander) AddLabeled(label string, paths ...string) error {
+	return e.add(e.attribute(label), paths)
+}
+
+// Provenance returns every distinct attribution recorded for a pattern, in
+// the order they were first seen. Unlabeled Add calls are only attributed
+// when WithCallerCapture is enabled.
+func (e *Expander) Provenance(pattern string) []Attribution {
+	return slices.Clone(e.provenance[pattern])
+}
+
+// attribute builds the attribution for an Add call made by the caller of the
+// exported method invoking it
+func (e *Expander) attribute(label string) Attribution {
+	attribution := Attribution{Label: label}
+	if e.config.captureCallers {
+		if _, file, line, ok := runtime.Caller(2); ok {
+			attribution.CallSite = file + ":" + strconv.Itoa(line)
+		}
+	}
+	return attribution
+}
+
+// recordProvenance remembers that a pattern was added by the attributed caller
+func (e *Expander) recordProvenance(pattern string, attribution Attribution) {
+	if attribution == (Attribution{}) || slices.Contains(e.provenance[pattern], attribution) {
+		return
+	}
+	e.provenance[pattern] = append(e.provenance[pattern], attribution)
+}
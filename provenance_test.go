@@ -0,0 +1,41 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pattern Provenance", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should record labels for each pattern", func() {
+		exp = expander.Get()
+		Expect(exp.AddLabeled("monitoring", "Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+		Expect(exp.AddLabeled("inventory", "Device.WiFi.AccessPoint.*.Enable", "Device.DeviceInfo.UpTime")).To(Succeed())
+		Expect(exp.AddLabeled("monitoring", "Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+		Expect(exp.Add("Device.DeviceInfo.UpTime")).To(Succeed())
+
+		Expect(exp.Provenance("Device.WiFi.AccessPoint.*.Enable")).To(Equal([]expander.Attribution{
+			{Label: "monitoring"},
+			{Label: "inventory"},
+		}))
+		Expect(exp.Provenance("Device.DeviceInfo.UpTime")).To(Equal([]expander.Attribution{
+			{Label: "inventory"},
+		}))
+	})
+
+	It("should capture call sites when enabled", func() {
+		exp = expander.Get(expander.WithCallerCapture())
+		Expect(exp.Add("Device.DeviceInfo.UpTime")).To(Succeed())
+
+		provenance := exp.Provenance("Device.DeviceInfo.UpTime")
+		Expect(provenance).To(HaveLen(1))
+		Expect(provenance[0].Label).To(BeEmpty())
+		Expect(provenance[0].CallSite).To(ContainSubstring("provenance_test.go:"))
+	})
+})
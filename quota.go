@@ -0,0 +1,145 @@
+package expander
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrQuotaExceeded is returned by Add when an owner would exceed its quota
+var ErrQuotaExceeded = errors.New("owner quota exceeded")
+
+// Quota limits the patterns a single owner may add. Owners are the labels
+// given to AddLabeled; patterns added with Add belong to the empty owner.
+// Zero fields are unlimited.
+type Quota struct {
+	// MaxPatterns bounds the number of distinct patterns of the owner
+	MaxPatterns int
+
+	// MaxProjectedPaths bounds the number of paths the patterns of the owner
+	// are projected to expand to. The projection uses the discoveries known
+	// at Add time and counts one instance for every wildcard not discovered
+	// yet and none for glob segments not discovered yet, so it is exact for
+	// devices whose discoveries are cached.
+	MaxProjectedPaths int
+}
+
+// QuotaError reports the quota an owner would exceed.
+type QuotaError struct {
+	Owner string
+
+	// Limit names the exceeded limit: "patterns" or "projected paths"
+	Limit string
+
+	Max   int
+	Value int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v: owner %q would reach %d %s, limit is %d", ErrQuotaExceeded, e.Owner, e.Value, e.Limit, e.Max)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// quotas holds the quota of every owner
+type quotas struct {
+	fallback  Quota
+	overrides map[string]Quota
+}
+
+// WithOwnerQuota limits every owner to quota, except those listed in
+// overrides, so a misbehaving tenant of a multi-tenant ACS can't blow up the
+// expansion shared by all tenants of a device. Add refuses patterns that
+// would exceed a quota with a *QuotaError; nothing is added then.
+func WithOwnerQuota(quota Quota, overrides map[string]Quota) Option {
+	return func(e *Expander) {
+		e.config.quotas = &quotas{fallback: quota, overrides: overrides}
+	}
+}
+
+// quotaFor returns the quota of an owner
+func (q *quotas) quotaFor(owner string) Quota {
+	if quota, ok := q.overrides[owner]; ok {
+		return quota
+	}
+	return q.fallback
+}
+
+// checkQuota verifies that adding paths on behalf of owner stays within its quota
+func (e *Expander) checkQuota(owner string, paths []string) error {
+	if e.config.quotas == nil {
+		return nil
+	}
+	quota := e.config.quotas.quotaFor(owner)
+
+	patterns := slices.Clone(e.owners[owner])
+	for _, path := range paths {
+		if !slices.Contains(patterns, path) {
+			patterns = append(patterns, path)
+		}
+	}
+
+	if quota.MaxPatterns > 0 && len(patterns) > quota.MaxPatterns {
+		return &QuotaError{Owner: owner, Limit: "patterns", Max: quota.MaxPatterns, Value: len(patterns)}
+	}
+	if quota.MaxProjectedPaths > 0 {
+		if projected := e.project(patterns); projected > quota.MaxProjectedPaths {
+			return &QuotaError{Owner: owner, Limit: "projected paths", Max: quota.MaxProjectedPaths, Value: projected}
+		}
+	}
+	return nil
+}
+
+// project estimates the number of paths patterns expand to with the
+// discoveries known so far, counting one instance per unknown wildcard
+func (e *Expander) project(patterns []string) int {
+	var tree pathTree
+	for _, pattern := range patterns {
+		_ = tree.addPathAs(e.treePath(pattern), pattern)
+	}
+	return e.projectTree(&tree, "")
+}
+
+// projectTree projects the paths of a tree, restricted to the branches
+// leading to or lying below within if set
+func (e *Expander) projectTree(tree *pathTree, within string) int {
+	unknown := []string{"*"}
+	source := treeSource{
+		instances: func(discoveryPath string) ([]string, bool) {
+			if instances, ok := e.instances(discoveryPath); ok {
+				return instances, true
+			}
+			return unknown, true
+		},
+		instance: func(discoveryPath, segment string) (bool, bool) {
+			if listed, resolved := e.hasInstance(discoveryPath, segment); resolved {
+				return listed, true
+			}
+			return segment == "*", true
+		},
+		names: func(discoveryPath string) ([]string, bool) {
+			if names, ok := e.childNames(discoveryPath); ok {
+				return names, true
+			}
+			return nil, true
+		},
+	}
+
+	projected := 0
+	tree.walk(source, treeVisitor{
+		within: within,
+		leaf: func(string, string) {
+			projected++
+		},
+	})
+	return projected
+}
+
+// recordOwner remembers that a pattern was added by owner
+func (e *Expander) recordOwner(owner, pattern string) {
+	if !slices.Contains(e.owners[owner], pattern) {
+		e.owners[owner] = append(e.owners[owner], pattern)
+	}
+}
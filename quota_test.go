@@ -0,0 +1,51 @@
+package expander_test
+
+import (
+	"errors"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Owner Quotas", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get(expander.WithOwnerQuota(
+			expander.Quota{MaxPatterns: 2, MaxProjectedPaths: 4},
+			map[string]expander.Quota{"provisioning": {}},
+		))
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should limit the number of patterns per owner", func() {
+		Expect(exp.AddLabeled("tenant-a", "Device.DeviceInfo.UpTime", "Device.DeviceInfo.SerialNumber")).To(Succeed())
+		Expect(exp.AddLabeled("tenant-a", "Device.DeviceInfo.UpTime")).To(Succeed())
+
+		err := exp.AddLabeled("tenant-a", "Device.DeviceInfo.ModelName")
+		Expect(err).To(MatchError(expander.ErrQuotaExceeded))
+
+		var quotaErr *expander.QuotaError
+		Expect(errors.As(err, &quotaErr)).To(BeTrue())
+		Expect(*quotaErr).To(Equal(expander.QuotaError{Owner: "tenant-a", Limit: "patterns", Max: 2, Value: 3}))
+
+		Expect(exp.AddLabeled("tenant-b", "Device.DeviceInfo.ModelName")).To(Succeed())
+		Expect(exp.AddLabeled("provisioning", "Device.A", "Device.B", "Device.C")).To(Succeed())
+	})
+
+	It("should limit the projected number of paths using known discoveries", func() {
+		Expect(exp.Preload("", map[string][]int{"Device.Hosts.Host.": {1, 2, 3, 4, 5}})).To(Succeed())
+
+		err := exp.AddLabeled("tenant-a", "Device.Hosts.Host.*.HostName")
+		Expect(err).To(MatchError(expander.ErrQuotaExceeded))
+		Expect(err).To(MatchError(ContainSubstring("5 projected paths")))
+
+		// Undiscovered wildcards project a single instance
+		Expect(exp.AddLabeled("tenant-a", "Device.WiFi.SSID.*.SSID")).To(Succeed())
+		Expect(exp.Provenance("Device.Hosts.Host.*.HostName")).To(BeEmpty())
+	})
+})
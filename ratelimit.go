@@ -0,0 +1,67 @@
+package expander
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter paces discovery requests. Wait blocks until the next request may
+// be issued or ctx is done. *rate.Limiter from golang.org/x/time/rate
+// satisfies it.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimit makes the driver loops issue at most rps discovery requests
+// per second, retries included, evenly spaced, so low-end devices aren't
+// hammered with back-to-back GetParameterNames calls. Zero or less removes
+// the limit. Each expander the option is applied to gets a limiter of its
+// own.
+func WithRateLimit(rps float64) Option {
+	return func(e *Expander) {
+		e.config.limiter = nil
+		if rps > 0 {
+			e.config.limiter = &intervalLimiter{interval: time.Duration(float64(time.Second) / rps)}
+		}
+	}
+}
+
+// WithLimiter makes the driver loops wait on l before every discovery
+// request, retries included. Nil removes the limit.
+func WithLimiter(l Limiter) Option {
+	return func(e *Expander) {
+		e.config.limiter = l
+	}
+}
+
+// intervalLimiter spaces requests at least interval apart
+type intervalLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// Wait reserves the next free slot and sleeps until it comes
+func (l *intervalLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	if slot.Equal(now) {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(slot.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+package expander_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// countingLimiter counts the requests it lets through, failing once spent
+type countingLimiter struct {
+	waits  int
+	budget int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	if l.waits == l.budget {
+		return errors.New("budget spent")
+	}
+	l.waits++
+	return ctx.Err()
+}
+
+var _ = Describe("Rate Limiting", func() {
+	device := fakeDevice(
+		"InternetGatewayDevice.LANDevice.1.",
+		"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.",
+		"InternetGatewayDevice.LANDevice.2.",
+		"InternetGatewayDevice.LANDevice.2.WLANConfiguration.1.",
+	)
+	pattern := "InternetGatewayDevice.LANDevice.*.WLANConfiguration.*."
+
+	It("should space discoveries evenly", func() {
+		exp := expander.Get(expander.WithRateLimit(50))
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		var issued []time.Time
+		start := time.Now()
+		_, err := exp.Expand(context.Background(), func(ctx context.Context, path string) ([]string, error) {
+			issued = append(issued, time.Now())
+			return device(ctx, path)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issued).To(HaveLen(3))
+		Expect(issued[0].Sub(start)).To(BeNumerically("<", 20*time.Millisecond))
+		// Slots are reserved at fixed intervals, so a late wake-up shortens
+		// the gap to the next discovery but never moves it ahead of its slot
+		for i := 1; i < len(issued); i++ {
+			Expect(issued[i].Sub(start)).To(BeNumerically(">=", time.Duration(i)*19*time.Millisecond))
+		}
+	})
+
+	It("should give every expander built from the option its own limit", func() {
+		limit := expander.WithRateLimit(20)
+		first := func(exp *expander.Expander) time.Duration {
+			Expect(exp.Add(pattern)).To(Succeed())
+			var issued []time.Time
+			start := time.Now()
+			_, err := exp.Expand(context.Background(), func(ctx context.Context, path string) ([]string, error) {
+				issued = append(issued, time.Now())
+				return device(ctx, path)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return issued[0].Sub(start)
+		}
+
+		exp := expander.Get(limit)
+		defer expander.Release(exp)
+		Expect(first(exp)).To(BeNumerically("<", 20*time.Millisecond))
+
+		other := expander.Get(limit)
+		defer expander.Release(other)
+		Expect(first(other)).To(BeNumerically("<", 20*time.Millisecond))
+	})
+
+	It("should wait on a pluggable limiter before every discovery", func() {
+		limiter := &countingLimiter{budget: 10}
+		exp := expander.Get(expander.WithLimiter(limiter))
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		paths, err := exp.Expand(context.Background(), device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(2))
+		Expect(limiter.waits).To(Equal(3))
+	})
+
+	It("should report a limiter failure as a resumable discovery error", func() {
+		limiter := &countingLimiter{budget: 1}
+		exp := expander.Get(expander.WithLimiter(limiter))
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		_, err := exp.Expand(context.Background(), device)
+		var discoveryErr *expander.DiscoveryError
+		Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+		Expect(discoveryErr.Attempts).To(Equal(0))
+
+		limiter.budget = 10
+		paths, err := exp.Expand(context.Background(), device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(2))
+	})
+
+	It("should stop waiting when the context is cancelled", func() {
+		exp := expander.Get(expander.WithRateLimit(0.001))
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := exp.Expand(ctx, device)
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+})
@@ -0,0 +1,119 @@
+package expander_test
+
+import (
+	"context"
+	"strings"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// nextLevelDevice answers discoveries like GetParameterNames with
+// NextLevel=true: only the immediate children, objects with a trailing dot
+func nextLevelDevice(params ...string) expander.DiscoveryFunc {
+	return func(_ context.Context, path string) ([]string, error) {
+		seen := make(map[string]bool)
+		var result []string
+		for _, param := range params {
+			remainder, ok := strings.CutPrefix(param, path)
+			if !ok {
+				continue
+			}
+			name := path + remainder
+			if segment, _, isObject := strings.Cut(remainder, "."); isObject {
+				name = path + segment + "."
+			}
+			if !seen[name] {
+				seen[name] = true
+				result = append(result, name)
+			}
+		}
+		return result, nil
+	}
+}
+
+var _ = Describe("Recursive Wildcard", func() {
+	device := nextLevelDevice(
+		"Device.Ethernet.Interface.1.MACAddress",
+		"Device.Ethernet.Interface.1.Enable",
+		"Device.WiFi.AccessPoint.1.AssociatedDevice.2.MACAddress",
+		"Device.WiFi.Radio.1.Enable",
+		"Device.X_ACME_Mesh.Node.MACAddress",
+	)
+
+	It("should match parameters at any depth", func() {
+		paths, err := expander.ExpandAll(context.Background(), []string{"Device.**.MACAddress"}, device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.Ethernet.Interface.1.MACAddress",
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.2.MACAddress",
+			"Device.X_ACME_Mesh.Node.MACAddress",
+		}))
+	})
+
+	It("should combine with wildcards below it", func() {
+		paths, err := expander.ExpandAll(context.Background(), []string{"Device.**.Radio.*.Enable"}, device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{"Device.WiFi.Radio.1.Enable"}))
+	})
+
+	It("should stand for every parameter of a subtree when trailing", func() {
+		paths, err := expander.ExpandAll(context.Background(), []string{"Device.Ethernet.**"}, device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.Ethernet.Interface.1.Enable",
+			"Device.Ethernet.Interface.1.MACAddress",
+		}))
+	})
+
+	It("should discover each level once", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.Add("Device.WiFi.**.MACAddress", "Device.WiFi.**.Enable")).To(Succeed())
+
+		var discovered []string
+		for {
+			path, hasMore := exp.Next()
+			if !hasMore {
+				break
+			}
+			discovered = append(discovered, path)
+			names, _ := device(context.Background(), path)
+			Expect(exp.Register(names)).To(Succeed())
+		}
+		Expect(discovered).To(HaveLen(7))
+		Expect(exp.Collect()).To(HaveLen(2))
+	})
+
+	It("should leave out blocked subtrees matched by or containing it", func() {
+		paths, err := expander.ExpandAll(context.Background(), []string{"Device.**.MACAddress"}, device,
+			expander.WithBlockedSubtrees([]string{"Device.WiFi.AccessPoint.*.AssociatedDevice."}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.Ethernet.Interface.1.MACAddress",
+			"Device.X_ACME_Mesh.Node.MACAddress",
+		}))
+
+		paths, err = expander.ExpandAll(context.Background(), []string{"Device.**.MACAddress", "Device.WiFi.Radio.*.Enable"}, device,
+			expander.WithBlockedSubtrees([]string{"Device.**.Node."}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.Ethernet.Interface.1.MACAddress",
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.2.MACAddress",
+			"Device.WiFi.Radio.1.Enable",
+		}))
+	})
+
+	It("should stay within allowed subtrees matched by or containing it", func() {
+		paths, err := expander.ExpandAll(context.Background(), []string{"Device.**.MACAddress"}, device,
+			expander.WithAllowedSubtrees([]string{"Device.WiFi."}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{"Device.WiFi.AccessPoint.1.AssociatedDevice.2.MACAddress"}))
+
+		paths, err = expander.ExpandAll(context.Background(), []string{"Device.**.MACAddress", "Device.WiFi.Radio.*.Enable"}, device,
+			expander.WithAllowedSubtrees([]string{"Device.**.Interface."}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{"Device.Ethernet.Interface.1.MACAddress"}))
+	})
+})
@@ -0,0 +1,104 @@
+package expander
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// InstanceKeys holds the values of the parameters that identify instances of
+// a table, such as SSID or MACAddress, read before and after the instances
+// were renumbered. Keys are full parameter names, e.g.
+// "Device.WiFi.SSID.2.SSID", as returned by GetParameterValues.
+type InstanceKeys struct {
+	Old map[string]string
+	New map[string]string
+}
+
+// SuggestRenumbering maps the old instance numbers of the table at objectPath
+// to their new numbers, e.g. after a reboot, so per-instance state can be
+// carried over instead of being reset. The instances are taken from the
+// table as discovered in oldCache and newCache; an old instance is mapped
+// when exactly one new instance carries the same key values. Instances
+// without key values or with ambiguous ones are left out.
+func SuggestRenumbering(objectPath string, oldCache, newCache Cache, keyValues InstanceKeys) (map[int]int, error) {
+	if !strings.HasSuffix(objectPath, ".") {
+		objectPath += "."
+	}
+
+	oldIndices, cached := oldCache.Get(objectPath)
+	if !cached {
+		return nil, fmt.Errorf("%w: %s not discovered before renumbering", ErrNoDiscovery, objectPath)
+	}
+	newIndices, cached := newCache.Get(objectPath)
+	if !cached {
+		return nil, fmt.Errorf("%w: %s not discovered after renumbering", ErrNoDiscovery, objectPath)
+	}
+
+	oldKeys := instanceKeys(objectPath, oldIndices, keyValues.Old)
+	newKeys := instanceKeys(objectPath, newIndices, keyValues.New)
+
+	// Index new instances by key, remembering keys shared by several instances
+	byKey := make(map[string]int, len(newKeys))
+	ambiguous := make(map[string]bool)
+	for index, key := range newKeys {
+		if _, seen := byKey[key]; seen {
+			ambiguous[key] = true
+		}
+		byKey[key] = index
+	}
+
+	seenOld := make(map[string]bool, len(oldKeys))
+	for _, key := range oldKeys {
+		if seenOld[key] {
+			ambiguous[key] = true
+		}
+		seenOld[key] = true
+	}
+
+	mapping := make(map[int]int)
+	for index, key := range oldKeys {
+		if next, found := byKey[key]; found && !ambiguous[key] {
+			mapping[index] = next
+		}
+	}
+	return mapping, nil
+}
+
+// instanceKeys builds the identifying key of every listed instance of a
+// table from the values of the parameters directly below it
+func instanceKeys(table string, indices []int, values map[string]string) map[int]string {
+	names := make(map[int][]string)
+	for name := range values {
+		remainder, found := strings.CutPrefix(name, table)
+		if !found {
+			continue
+		}
+		segment, param, found := strings.Cut(remainder, ".")
+		index, err := strconv.Atoi(segment)
+		if !found || err != nil || strings.Contains(param, ".") {
+			continue
+		}
+		names[index] = append(names[index], name)
+	}
+
+	keys := make(map[int]string, len(indices))
+	for _, index := range indices {
+		params := names[index]
+		if len(params) == 0 {
+			continue
+		}
+		slices.Sort(params)
+
+		var b strings.Builder
+		for _, name := range params {
+			b.WriteString(name[strings.LastIndexByte(name, '.')+1:])
+			b.WriteByte('=')
+			b.WriteString(strconv.Quote(values[name]))
+			b.WriteByte(';')
+		}
+		keys[index] = b.String()
+	}
+	return keys
+}
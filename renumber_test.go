@@ -0,0 +1,72 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Renumbering", func() {
+	const table = "Device.WiFi.SSID."
+
+	var oldCache, newCache recordingCache
+
+	BeforeEach(func() {
+		oldCache = recordingCache{table: {1, 2, 3}}
+		newCache = recordingCache{table: {1, 2, 3}}
+	})
+
+	It("should map instances by their key values", func() {
+		mapping, err := expander.SuggestRenumbering("Device.WiFi.SSID", oldCache, newCache, expander.InstanceKeys{
+			Old: map[string]string{
+				"Device.WiFi.SSID.1.SSID": "home",
+				"Device.WiFi.SSID.2.SSID": "guest",
+				"Device.WiFi.SSID.3.SSID": "iot",
+			},
+			New: map[string]string{
+				"Device.WiFi.SSID.1.SSID": "guest",
+				"Device.WiFi.SSID.2.SSID": "iot",
+				"Device.WiFi.SSID.3.SSID": "home",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mapping).To(Equal(map[int]int{1: 3, 2: 1, 3: 2}))
+	})
+
+	It("should leave out instances with ambiguous or missing keys", func() {
+		newCache[table] = []int{4, 5}
+		mapping, err := expander.SuggestRenumbering(table, oldCache, newCache, expander.InstanceKeys{
+			Old: map[string]string{
+				"Device.WiFi.SSID.1.SSID": "home",
+				"Device.WiFi.SSID.2.SSID": "guest",
+			},
+			New: map[string]string{
+				"Device.WiFi.SSID.4.SSID": "home",
+				"Device.WiFi.SSID.5.SSID": "home",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mapping).To(BeEmpty())
+	})
+
+	It("should combine several key parameters", func() {
+		keys := expander.InstanceKeys{
+			Old: map[string]string{
+				"Device.WiFi.SSID.1.SSID": "home", "Device.WiFi.SSID.1.BSSID": "aa",
+				"Device.WiFi.SSID.2.SSID": "home", "Device.WiFi.SSID.2.BSSID": "bb",
+			},
+			New: map[string]string{
+				"Device.WiFi.SSID.1.SSID": "home", "Device.WiFi.SSID.1.BSSID": "bb",
+				"Device.WiFi.SSID.2.SSID": "home", "Device.WiFi.SSID.2.BSSID": "aa",
+			},
+		}
+		mapping, err := expander.SuggestRenumbering(table, oldCache, newCache, keys)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mapping).To(Equal(map[int]int{1: 2, 2: 1}))
+	})
+
+	It("should fail for tables that were not discovered", func() {
+		_, err := expander.SuggestRenumbering("Device.Hosts.Host.", oldCache, newCache, expander.InstanceKeys{})
+		Expect(err).To(MatchError(expander.ErrNoDiscovery))
+	})
+})
@@ -0,0 +1,175 @@
+package expander
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// BranchErrorKind classifies why a branch is missing from an expansion.
+type BranchErrorKind int
+
+// Branch error kinds
+const (
+	// BranchFault reports a discovery pruned with RegisterFault
+	BranchFault BranchErrorKind = iota + 1
+
+	// BranchSkipped reports a discovery abandoned with Skip
+	BranchSkipped
+
+	// BranchMalformed reports a discovery whose results were rejected, such
+	// as names under another data model root, and not replaced since
+	BranchMalformed
+
+	// BranchUnresolved reports a discovery still pending or awaited
+	BranchUnresolved
+
+	// BranchOverBudget reports a discovery left pending once the budget
+	// set with WithDiscoveryBudget was spent
+	BranchOverBudget
+
+	// BranchBlocked reports a subtree left out as set with
+	// WithBlockedSubtrees
+	BranchBlocked
+)
+
+// String returns a short name for the kind.
+func (k BranchErrorKind) String() string {
+	switch k {
+	case BranchFault:
+		return "fault"
+	case BranchSkipped:
+		return "skipped"
+	case BranchMalformed:
+		return "malformed"
+	case BranchUnresolved:
+		return "unresolved"
+	case BranchOverBudget:
+		return "over-budget"
+	case BranchBlocked:
+		return "blocked"
+	default:
+		return fmt.Sprintf("BranchErrorKind(%d)", int(k))
+	}
+}
+
+// BranchError reports a discovery path whose branch is missing from an
+// expansion.
+type BranchError struct {
+	Kind BranchErrorKind
+	Path string
+
+	// FaultCode is the CWMP fault registered for a BranchFault
+	FaultCode int
+
+	// Err is the error the results of the discovery were last rejected
+	// with, if any
+	Err error
+}
+
+func (e *BranchError) Error() string {
+	switch {
+	case e.Kind == BranchFault:
+		return fmt.Sprintf("%s: fault %d", e.Path, e.FaultCode)
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %s: %v", e.Path, e.Kind, e.Err)
+	default:
+		return fmt.Sprintf("%s: %s", e.Path, e.Kind)
+	}
+}
+
+func (e *BranchError) Unwrap() error {
+	return e.Err
+}
+
+// ExpansionReport is the best-effort outcome of an expansion: the paths
+// expanded from the branches resolved so far, along with the branches
+// missing from them.
+type ExpansionReport struct {
+	// Paths are the expanded paths, as returned by Collect
+	Paths []string
+
+	// Errors lists the branches missing from Paths, sorted by path
+	Errors []*BranchError
+
+	// Warnings are the warnings raised while expanding
+	Warnings []Warning
+
+	// Complete reports whether every discovery was answered or abandoned
+	Complete bool
+
+	// Truncated reports that instances were left out as set with
+	// WithInstanceCap
+	Truncated bool
+
+	// Aborted is the error the expansion was aborted with, such as
+	// ErrTooManyPaths, in which case no paths are reported
+	Aborted error
+}
+
+// Err returns the abort error and the branch errors of the report joined,
+// or nil if every branch was expanded.
+func (r *ExpansionReport) Err() error {
+	errs := []error{r.Aborted}
+	for _, err := range r.Errors {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// CollectReport returns the paths expanded so far along with the branches
+// missing from them, instead of failing as Collect does while discoveries
+// are left. Faulted, skipped and blocked branches are reported, and so are pending
+// and awaited discoveries, along with the error their results were last
+// rejected with. Unlike Collect, it never hands out a discovery. An aborted
+// expansion reports no paths, only the error it was aborted with.
+func (e *Expander) CollectReport() *ExpansionReport {
+	if e.aborted != nil {
+		return &ExpansionReport{Warnings: e.Warnings(), Aborted: e.aborted}
+	}
+	if !e.isComplete {
+		e.generateExpandedPaths()
+	}
+	report := &ExpansionReport{
+		Paths:    e.visiblePaths(e.expandedPaths),
+		Warnings: e.Warnings(),
+		Complete: e.isComplete,
+	}
+	report.Truncated = slices.ContainsFunc(report.Warnings, func(w Warning) bool {
+		return w.Kind == WarningInstanceCap
+	})
+
+	for path, code := range e.faults {
+		report.Errors = append(report.Errors, &BranchError{Kind: BranchFault, Path: path, FaultCode: code})
+	}
+	for path := range e.blockedHits {
+		report.Errors = append(report.Errors, &BranchError{Kind: BranchBlocked, Path: path})
+	}
+	for path := range e.skipped {
+		report.Errors = append(report.Errors, &BranchError{Kind: BranchSkipped, Path: path, Err: e.malformed[path]})
+	}
+
+	unresolved := slices.Collect(maps.Keys(e.outstanding))
+	for _, path := range e.pendingDiscoveries {
+		if !e.processedDiscoveries[path] && !slices.Contains(unresolved, path) {
+			unresolved = append(unresolved, path)
+		}
+	}
+	for _, path := range unresolved {
+		switch err, malformed := e.malformed[path]; {
+		case malformed:
+			report.Errors = append(report.Errors, &BranchError{Kind: BranchMalformed, Path: path, Err: err})
+		case e.budgetSpent() && !e.outstanding[path]:
+			report.Errors = append(report.Errors, &BranchError{Kind: BranchOverBudget, Path: path})
+		default:
+			report.Errors = append(report.Errors, &BranchError{Kind: BranchUnresolved, Path: path})
+		}
+	}
+
+	slices.SortFunc(report.Errors, func(a, b *BranchError) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return report
+}
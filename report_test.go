@@ -0,0 +1,80 @@
+package expander_test
+
+import (
+	"errors"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Expansion Report", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		Expect(exp.Add("InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.SSID")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should report a complete expansion without errors", func() {
+		for path, ok := exp.Next(); ok; path, ok = exp.Next() {
+			Expect(exp.Register([]string{path + "1."})).To(Succeed())
+		}
+
+		report := exp.CollectReport()
+		Expect(report.Complete).To(BeTrue())
+		Expect(report.Paths).To(Equal([]string{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.SSID"}))
+		Expect(report.Errors).To(BeEmpty())
+		Expect(report.Err()).NotTo(HaveOccurred())
+	})
+
+	It("should report faulted, skipped, malformed and unresolved branches", func() {
+		exp.Next()
+		Expect(exp.Register([]string{
+			"InternetGatewayDevice.LANDevice.1.",
+			"InternetGatewayDevice.LANDevice.2.",
+			"InternetGatewayDevice.LANDevice.3.",
+			"InternetGatewayDevice.LANDevice.4.",
+			"InternetGatewayDevice.LANDevice.5.",
+		})).To(Succeed())
+
+		batch := exp.NextBatch(4)
+		Expect(batch).To(HaveLen(4))
+		Expect(exp.RegisterFor(batch[0], []string{batch[0] + "1."})).To(Succeed())
+		Expect(exp.RegisterFault(batch[1], expander.FaultInvalidParameterName)).To(Succeed())
+		exp.Skip(batch[2])
+		err := exp.RegisterFor(batch[3], []string{"Device.WiFi.SSID.1."})
+		Expect(err).To(MatchError(expander.ErrRootMismatch))
+
+		report := exp.CollectReport()
+		Expect(report.Complete).To(BeFalse())
+		Expect(report.Paths).To(Equal([]string{batch[0] + "1.SSID"}))
+		Expect(report.Errors).To(Equal([]*expander.BranchError{
+			{Kind: expander.BranchFault, Path: batch[1], FaultCode: expander.FaultInvalidParameterName},
+			{Kind: expander.BranchSkipped, Path: batch[2]},
+			{Kind: expander.BranchMalformed, Path: batch[3], Err: err},
+			{Kind: expander.BranchUnresolved, Path: "InternetGatewayDevice.LANDevice.5.WLANConfiguration."},
+		}))
+		Expect(errors.Is(report.Err(), expander.ErrRootMismatch)).To(BeTrue())
+		Expect(report.Err()).To(MatchError(ContainSubstring(batch[1] + ": fault 9005")))
+
+		// The report hands nothing out
+		next, _ := exp.Next()
+		Expect(next).To(Equal("InternetGatewayDevice.LANDevice.5.WLANConfiguration."))
+	})
+
+	It("should drop the malformed error once results are accepted", func() {
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{"Device.WiFi."})).To(MatchError(expander.ErrRootMismatch))
+		Expect(exp.CollectReport().Errors[0].Kind).To(Equal(expander.BranchMalformed))
+
+		Expect(exp.RegisterFor(path, nil)).To(Succeed())
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.CollectReport().Errors).To(BeEmpty())
+	})
+})
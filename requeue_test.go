@@ -0,0 +1,74 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Requeueing Discoveries", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		Expect(exp.Add(
+			"InternetGatewayDevice.LANDevice.*.Enable",
+			"InternetGatewayDevice.WANDevice.*.Enable",
+		)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should return a requeued discovery after the other pending ones", func() {
+		first, _ := exp.Next()
+		Expect(exp.Requeue(first)).To(Succeed())
+
+		second, _ := exp.Next()
+		Expect(second).NotTo(Equal(first))
+		Expect(exp.Register([]string{second + "1."})).To(Succeed())
+
+		again, _ := exp.Next()
+		Expect(again).To(Equal(first))
+		Expect(exp.Register([]string{first + "1."})).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{
+			"InternetGatewayDevice.LANDevice.1.Enable",
+			"InternetGatewayDevice.WANDevice.1.Enable",
+		}))
+	})
+
+	It("should reject a late answer for a requeued discovery", func() {
+		path, _ := exp.Next()
+		Expect(exp.Requeue(path)).To(Succeed())
+		Expect(exp.Register([]string{path + "1."})).To(MatchError(expander.ErrNoDiscovery))
+	})
+
+	It("should reject discoveries that were not handed out", func() {
+		Expect(exp.Requeue("InternetGatewayDevice.LANDevice.")).To(MatchError(expander.ErrNotOutstanding))
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1."})).To(Succeed())
+		Expect(exp.Requeue(path)).To(MatchError(expander.ErrNotOutstanding))
+	})
+
+	It("should queue a skipped discovery again", func() {
+		for path, ok := exp.Next(); ok; path, ok = exp.Next() {
+			exp.Skip(path)
+		}
+		Expect(exp.Collect()).To(BeEmpty())
+
+		Expect(exp.Requeue("InternetGatewayDevice.WANDevice")).To(Succeed())
+		Expect(exp.Skipped()).To(Equal([]string{"InternetGatewayDevice.LANDevice."}))
+		path, _ := exp.Next()
+		Expect(path).To(Equal("InternetGatewayDevice.WANDevice."))
+		Expect(exp.Register([]string{path + "2."})).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{"InternetGatewayDevice.WANDevice.2.Enable"}))
+	})
+})
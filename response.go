@@ -0,0 +1,146 @@
+package expander
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ResponseAction selects what happens to a discovery response exceeding the
+// limit set with WithResponseLimit.
+type ResponseAction int
+
+// Response limit actions
+const (
+	// ResponseFlag keeps the whole response and raises a warning
+	ResponseFlag ResponseAction = iota
+
+	// ResponseTruncate keeps the first names up to the limit and raises a warning
+	ResponseTruncate
+)
+
+// WithResponseLimit guards against pathological devices answering a
+// discovery with an absurd number of names, e.g. more than 100000. Responses
+// with more than max names raise a WarningOversizedResponse and, with
+// ResponseTruncate, are cut down to their first max names so the data that
+// fits is still expanded.
+func WithResponseLimit(max int, action ResponseAction) Option {
+	return func(e *Expander) {
+		e.config.responseLimit = max
+		e.config.responseAction = action
+	}
+}
+
+// ResponseSizes returns the number of names registered for every discovery
+// path, before any truncation.
+func (e *Expander) ResponseSizes() map[string]int {
+	return maps.Clone(e.responseSizes)
+}
+
+// guardResponse records the size of a response and applies the response
+// limit to it
+func (e *Expander) guardResponse(discoveryPath string, results []string) []string {
+	e.responseSizes[discoveryPath] = len(results)
+
+	limit := e.config.responseLimit
+	if limit <= 0 || len(results) <= limit {
+		return results
+	}
+	if e.config.responseAction == ResponseTruncate {
+		e.warn(WarningOversizedResponse, discoveryPath, "%d names truncated to %d", len(results), limit)
+		return results[:limit]
+	}
+	e.warn(WarningOversizedResponse, discoveryPath, "%d names exceed the limit of %d", len(results), limit)
+	return results
+}
+
+// WithPrefixRepair repairs responses of devices answering GetParameterNames
+// with names relative to the requested path, such as "1." and "2.Enable"
+// for Device.WiFi.SSID., by prepending the discovery path. Names are taken
+// as relative when they are under no data model root; each repaired
+// response raises a WarningRepairedPrefix. Without it such responses fail
+// with a *RootMismatchError.
+func WithPrefixRepair() Option {
+	return func(e *Expander) {
+		e.config.prefixRepair = true
+	}
+}
+
+// repairPrefixes prepends the discovery path to the relative names of a
+// response, when prefix repair is enabled
+func (e *Expander) repairPrefixes(discoveryPath string, results []string) []string {
+	if !e.config.prefixRepair {
+		return results
+	}
+
+	var repaired []string
+	for i, name := range results {
+		if name == "" || IsUnderRoot(name, Root(discoveryPath)) || IsUnderRoot(name, RootDevice) || IsUnderRoot(name, RootIGD) {
+			continue
+		}
+		if repaired == nil {
+			repaired = slices.Clone(results)
+		}
+		repaired[i] = discoveryPath + strings.TrimPrefix(name, ".")
+	}
+	if repaired == nil {
+		return results
+	}
+	e.warn(WarningRepairedPrefix, discoveryPath, "relative names prefixed with the discovery path")
+	return repaired
+}
+
+// auditResponse raises warnings for the names of a discovery response that
+// are ignored or redundant: names outside the discovery path, child
+// segments that are not instances where only instances are expected, and
+// instances listed twice
+func (e *Expander) auditResponse(discoveryPath string, results []string) {
+	// Child names are expected when they are matched by glob segments or "**"
+	instancesOnly := !e.config.globSegments && !e.paths.matchesNames
+
+	var foreign, nonNumeric, duplicates []string
+	listed := make(map[string]bool)
+	for _, name := range results {
+		remainder, ok := strings.CutPrefix(name, discoveryPath)
+		if !ok {
+			if name+"." != discoveryPath {
+				foreign = append(foreign, name)
+			}
+			continue
+		}
+
+		segment, rest, _ := strings.Cut(remainder, ".")
+		if segment == "" {
+			continue
+		}
+		if rest == "" {
+			if listed[segment] {
+				duplicates = append(duplicates, segment)
+			}
+			listed[segment] = true
+		}
+		if instancesOnly && !isInstanceSegment(segment) && !e.isAliasInstance(segment) && !slices.Contains(nonNumeric, segment) {
+			nonNumeric = append(nonNumeric, segment)
+		}
+	}
+
+	if len(foreign) > 0 {
+		e.warn(WarningForeignName, discoveryPath, "%d names outside the discovery path ignored, e.g. %s", len(foreign), foreign[0])
+	}
+	if len(nonNumeric) > 0 {
+		e.warn(WarningNonNumericInstance, discoveryPath, "segments ignored: %s", strings.Join(nonNumeric, ", "))
+	}
+	if len(duplicates) > 0 {
+		e.warn(WarningDuplicateInstance, discoveryPath, "instances listed more than once: %s", strings.Join(duplicates, ", "))
+	}
+}
+
+// isAliasInstance reports whether the alias codec recognizes a registered
+// segment as an alias-addressed instance
+func (e *Expander) isAliasInstance(segment string) bool {
+	if e.config.aliasCodec == nil {
+		return false
+	}
+	_, ok := e.config.aliasCodec.Decode(segment)
+	return ok
+}
@@ -0,0 +1,102 @@
+package expander_test
+
+import (
+	"strconv"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response Guardrails", func() {
+	const table = "Device.Hosts.Host."
+
+	var exp *expander.Expander
+
+	hosts := func(n int) []string {
+		names := make([]string, n)
+		for i := range names {
+			names[i] = table + strconv.Itoa(i+1) + "."
+		}
+		return names
+	}
+
+	AfterEach(func() {
+		expander.Release(exp)
+		exp = nil
+	})
+
+	It("should record the size of every response", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register(hosts(3))).To(Succeed())
+
+		Expect(exp.ResponseSizes()).To(Equal(map[string]int{path: 3}))
+		Expect(exp.Warnings()).To(BeEmpty())
+	})
+
+	It("should flag oversized responses and keep them whole", func() {
+		exp = expander.Get(expander.WithResponseLimit(2, expander.ResponseFlag))
+		Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+		_, _ = exp.Next()
+		Expect(exp.Register(hosts(3))).To(Succeed())
+
+		Expect(exp.Collect()).To(HaveLen(3))
+		Expect(exp.Warnings()).To(ConsistOf(HaveField("Kind", expander.WarningOversizedResponse)))
+	})
+
+	It("should truncate oversized responses to the limit", func() {
+		exp = expander.Get(expander.WithResponseLimit(2, expander.ResponseTruncate))
+		Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+		_, _ = exp.Next()
+		Expect(exp.Register(hosts(3))).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.Hosts.Host.1.IPAddress", "Device.Hosts.Host.2.IPAddress"}))
+		Expect(exp.ResponseSizes()).To(HaveKeyWithValue(table, 3))
+
+		warnings := exp.Warnings()
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0].String()).To(Equal("oversized-response: Device.Hosts.Host.: 3 names truncated to 2"))
+	})
+
+	It("should reject relative names by default", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+		_, _ = exp.Next()
+		Expect(exp.Register([]string{"1.", "2."})).To(MatchError(expander.ErrRootMismatch))
+	})
+
+	It("should prefix relative names with the discovery path", func() {
+		exp = expander.Get(expander.WithPrefixRepair())
+		Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+		_, _ = exp.Next()
+		Expect(exp.Register([]string{"1.", ".2.", "3.IPAddress", table + "4."})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.Hosts.Host.1.IPAddress",
+			"Device.Hosts.Host.2.IPAddress",
+			"Device.Hosts.Host.3.IPAddress",
+			"Device.Hosts.Host.4.IPAddress",
+		}))
+		Expect(exp.Warnings()).To(Equal([]expander.Warning{{
+			Kind:   expander.WarningRepairedPrefix,
+			Path:   table,
+			Detail: "relative names prefixed with the discovery path",
+		}}))
+	})
+
+	It("should not repair names under another data model root", func() {
+		exp = expander.Get(expander.WithPrefixRepair())
+		Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+		_, _ = exp.Next()
+		Expect(exp.Register([]string{"InternetGatewayDevice.LANDevice.1."})).To(MatchError(expander.ErrRootMismatch))
+		Expect(exp.Warnings()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,74 @@
+package expander
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Transient CWMP fault codes worth retrying
+const (
+	// FaultInternalError is the CWMP fault 9002, internal error
+	FaultInternalError = 9002
+
+	// FaultResourcesExceeded is the CWMP fault 9004, resources exceeded
+	FaultResourcesExceeded = 9004
+)
+
+// WithRetry makes the driver loops retry discoveries failing with a
+// transient error up to max times, waiting backoff before the first retry
+// and twice as long before each next one. Errors are transient as reported
+// by IsTransient. A discovery still failing is reported as a
+// *DiscoveryError with the number of attempts made.
+func WithRetry(max int, backoff time.Duration) Option {
+	return func(e *Expander) {
+		e.config.retries = max
+		e.config.backoff = backoff
+	}
+}
+
+// IsTransient reports whether a discovery error is worth retrying: a CWMP
+// fault 9002 or 9004, an HTTP 503 or 429, or an error reporting itself as
+// temporary. Faults and statuses are recognized on errors with a
+// FaultCode() int or HTTPStatus() int method, as the errors of the cwmpsoap
+// and genieacs packages have.
+func IsTransient(err error) bool {
+	var fault interface{ FaultCode() int }
+	if errors.As(err, &fault) {
+		code := fault.FaultCode()
+		return code == FaultInternalError || code == FaultResourcesExceeded
+	}
+	var status interface{ HTTPStatus() int }
+	if errors.As(err, &status) {
+		code := status.HTTPStatus()
+		return code == http.StatusServiceUnavailable || code == http.StatusTooManyRequests
+	}
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// discover answers a discovery from src, retrying transient failures as
+// configured with WithRetry and pacing requests as configured with
+// WithRateLimit. It returns the number of attempts made.
+func (e *Expander) discover(ctx context.Context, src DiscoverySource, path string) ([]string, int, error) {
+	delay := e.config.backoff
+	for attempt := 1; ; attempt++ {
+		if e.config.limiter != nil {
+			if err := e.config.limiter.Wait(ctx); err != nil {
+				return nil, attempt - 1, err
+			}
+		}
+		names, err := src.Discover(ctx, path)
+		if err == nil || attempt > e.config.retries || !IsTransient(err) {
+			return names, attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, attempt, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,126 @@
+package expander_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// cwmpFault is a discovery error carrying a CWMP fault code
+type cwmpFault int
+
+func (f cwmpFault) Error() string  { return fmt.Sprintf("fault %d", int(f)) }
+func (f cwmpFault) FaultCode() int { return int(f) }
+
+// httpStatus is a discovery error carrying an HTTP status
+type httpStatus int
+
+func (s httpStatus) Error() string   { return fmt.Sprintf("HTTP %d", int(s)) }
+func (s httpStatus) HTTPStatus() int { return int(s) }
+
+var _ = Describe("Discovery Retry", func() {
+	device := fakeDevice(
+		"InternetGatewayDevice.LANDevice.1.",
+		"InternetGatewayDevice.LANDevice.2.",
+	)
+
+	// failing answers the first n discoveries with err, then from device
+	failing := func(n int, err error) (expander.DiscoveryFunc, *int) {
+		calls := 0
+		return func(ctx context.Context, path string) ([]string, error) {
+			calls++
+			if calls <= n {
+				return nil, err
+			}
+			return device(ctx, path)
+		}, &calls
+	}
+
+	It("should retry transient faults until the discovery succeeds", func() {
+		exp := expander.Get(expander.WithRetry(3, time.Millisecond))
+		defer expander.Release(exp)
+		Expect(exp.Add("InternetGatewayDevice.LANDevice.*.")).To(Succeed())
+
+		fetch, calls := failing(2, cwmpFault(expander.FaultInternalError))
+		paths, err := exp.Expand(context.Background(), fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"InternetGatewayDevice.LANDevice.1.",
+			"InternetGatewayDevice.LANDevice.2.",
+		}))
+		Expect(*calls).To(Equal(3))
+	})
+
+	It("should report the attempts of a discovery failing after every retry", func() {
+		exp := expander.Get(expander.WithRetry(2, time.Millisecond))
+		defer expander.Release(exp)
+		Expect(exp.Add("InternetGatewayDevice.LANDevice.*.")).To(Succeed())
+
+		fetch, calls := failing(10, httpStatus(503))
+		_, err := exp.Expand(context.Background(), fetch)
+
+		var discoveryErr *expander.DiscoveryError
+		Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+		Expect(discoveryErr.Path).To(Equal("InternetGatewayDevice.LANDevice."))
+		Expect(discoveryErr.Attempts).To(Equal(3))
+		Expect(errors.Is(err, httpStatus(503))).To(BeTrue())
+		Expect(*calls).To(Equal(3))
+	})
+
+	It("should not retry permanent errors", func() {
+		exp := expander.Get(expander.WithRetry(3, time.Millisecond))
+		defer expander.Release(exp)
+		Expect(exp.Add("InternetGatewayDevice.LANDevice.*.")).To(Succeed())
+
+		fetch, calls := failing(1, cwmpFault(9003))
+		_, err := exp.Expand(context.Background(), fetch)
+
+		var discoveryErr *expander.DiscoveryError
+		Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+		Expect(discoveryErr.Attempts).To(Equal(1))
+		Expect(*calls).To(Equal(1))
+	})
+
+	It("should not retry without WithRetry", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.Add("InternetGatewayDevice.LANDevice.*.")).To(Succeed())
+
+		fetch, calls := failing(1, cwmpFault(expander.FaultInternalError))
+		_, err := exp.Expand(context.Background(), fetch)
+		Expect(err).To(HaveOccurred())
+		Expect(*calls).To(Equal(1))
+	})
+
+	It("should stop waiting for a retry when the context is cancelled", func() {
+		exp := expander.Get(expander.WithRetry(3, time.Hour))
+		defer expander.Release(exp)
+		Expect(exp.Add("InternetGatewayDevice.LANDevice.*.")).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		fetch, calls := failing(10, cwmpFault(expander.FaultInternalError))
+		_, err := exp.Expand(ctx, fetch)
+		Expect(err).To(HaveOccurred())
+		Expect(*calls).To(Equal(1))
+	})
+
+	DescribeTable("classifying errors as transient",
+		func(err error, transient bool) {
+			Expect(expander.IsTransient(err)).To(Equal(transient))
+		},
+		Entry("CWMP internal error", cwmpFault(9002), true),
+		Entry("CWMP resources exceeded", cwmpFault(9004), true),
+		Entry("CWMP invalid arguments", cwmpFault(9003), false),
+		Entry("HTTP 503", httpStatus(503), true),
+		Entry("HTTP 429", httpStatus(429), true),
+		Entry("HTTP 404", httpStatus(404), false),
+		Entry("wrapped fault", fmt.Errorf("session: %w", cwmpFault(9002)), true),
+		Entry("plain error", errors.New("boom"), false),
+	)
+})
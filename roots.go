@@ -0,0 +1,37 @@
+package expander
+
+import "strings"
+
+// Data model roots and path limits shared by the library and its callers
+const (
+	// RootDevice is the root object of the Device:2 data model (TR-181)
+	RootDevice = "Device."
+
+	// RootIGD is the root object of the InternetGatewayDevice data model (TR-098)
+	RootIGD = "InternetGatewayDevice."
+
+	// MaxPathLen is the maximum length of a parameter path, matching the
+	// string(256) type of parameter names in CWMP messages. Patterns are
+	// measured with search expressions and captures counted as "*".
+	MaxPathLen = 256
+
+	// MaxPathDepth is the maximum number of segments accepted in a pattern,
+	// not counting the trailing dot of partial paths
+	MaxPathDepth = 32
+)
+
+// Root returns the root object of a path including its trailing dot,
+// e.g. "Device." for "Device.WiFi.SSID.1.SSID".
+func Root(path string) string {
+	root, _, _ := strings.Cut(path, ".")
+	return root + "."
+}
+
+// IsUnderRoot reports whether path belongs to the data model rooted at root.
+// The root may be given with or without its trailing dot.
+func IsUnderRoot(path, root string) bool {
+	if !strings.HasSuffix(root, ".") {
+		root += "."
+	}
+	return strings.HasPrefix(path, root)
+}
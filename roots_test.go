@@ -0,0 +1,50 @@
+package expander_test
+
+import (
+	"strings"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Data Model Roots", func() {
+	DescribeTable("IsUnderRoot",
+		func(path, root string, under bool) {
+			Expect(expander.IsUnderRoot(path, root)).To(Equal(under))
+		},
+		Entry("device path", "Device.WiFi.SSID.1.SSID", expander.RootDevice, true),
+		Entry("igd path", "InternetGatewayDevice.LANDevice.1.", expander.RootIGD, true),
+		Entry("other root", "InternetGatewayDevice.LANDevice.1.", expander.RootDevice, false),
+		Entry("root without dot", "Device.DeviceInfo.UpTime", "Device", true),
+		Entry("similar prefix", "DeviceInfo.UpTime", "Device", false),
+	)
+
+	It("should extract the root of a path", func() {
+		Expect(expander.Root("Device.WiFi.SSID.1.SSID")).To(Equal(expander.RootDevice))
+		Expect(expander.Root("InternetGatewayDevice.")).To(Equal(expander.RootIGD))
+	})
+
+	It("should reject patterns beyond the protocol limits", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+
+		tooLong := "Device." + strings.Repeat("X", expander.MaxPathLen)
+		Expect(exp.Add(tooLong)).To(MatchError(expander.ErrInvalidPath))
+
+		tooDeep := "Device" + strings.Repeat(".X", expander.MaxPathDepth)
+		Expect(exp.Add(tooDeep)).To(MatchError(expander.ErrInvalidPath))
+
+		deepest := "Device" + strings.Repeat(".X", expander.MaxPathDepth-1)
+		Expect(exp.Add(deepest, deepest+".")).To(Succeed())
+	})
+
+	It("should measure only the path segments of patterns against the limits", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+
+		search := `Device.WiFi.AccessPoint.[SSIDReference=="` + strings.Repeat("X", expander.MaxPathLen) + `"].Enable`
+		Expect(exp.Add(search)).To(Succeed())
+		Expect(exp.Add("Device.WiFi.AccessPoint.{" + strings.Repeat("x", expander.MaxPathLen) + "}.Enable")).To(Succeed())
+	})
+})
@@ -0,0 +1,74 @@
+package expander
+
+import (
+	"cmp"
+	"encoding/binary"
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// WithSampledInstances expands only k instances of every table, drawn from
+// seed, for fleet-wide statistical monitoring that doesn't need every
+// entry. The sample is stable: an instance is picked by a hash of the seed,
+// the table and the instance, so the same instances are picked on every
+// run and most of the sample survives instances being added or removed.
+// Picked instances keep their order.
+func WithSampledInstances(k int, seed int64) Option {
+	return func(e *Expander) {
+		e.config.sampleSize = k
+		e.config.sampleSeed = seed
+	}
+}
+
+// sampleInstances picks the sample of the indices and aliases resolved for
+// a discovery path
+func (e *Expander) sampleInstances(discoveryPath string, indices []int, aliases []string) ([]int, []string) {
+	k := e.config.sampleSize
+	if k <= 0 || len(indices)+len(aliases) <= k {
+		return indices, aliases
+	}
+
+	// Instances are ranked by score; aliases are scored apart from indices
+	type candidate struct {
+		index int
+		alias string
+		score uint64
+	}
+	candidates := make([]candidate, 0, len(indices)+len(aliases))
+	for _, idx := range indices {
+		candidates = append(candidates, candidate{index: idx, score: e.sampleScore(discoveryPath, strconv.Itoa(idx))})
+	}
+	for _, alias := range aliases {
+		candidates = append(candidates, candidate{alias: alias, score: e.sampleScore(discoveryPath, "alias:"+alias)})
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(a.score, b.score)
+	})
+
+	pickedIndices := make(map[int]bool, k)
+	pickedAliases := make(map[string]bool)
+	for _, c := range candidates[:k] {
+		if c.alias != "" {
+			pickedAliases[c.alias] = true
+		} else {
+			pickedIndices[c.index] = true
+		}
+	}
+	indices = slices.DeleteFunc(slices.Clone(indices), func(idx int) bool {
+		return !pickedIndices[idx]
+	})
+	aliases = slices.DeleteFunc(slices.Clone(aliases), func(alias string) bool {
+		return !pickedAliases[alias]
+	})
+	return indices, aliases
+}
+
+// sampleScore hashes the seed, a discovery path and an instance segment
+func (e *Expander) sampleScore(discoveryPath, segment string) uint64 {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.BigEndian, e.config.sampleSeed)
+	h.Write([]byte(discoveryPath))
+	h.Write([]byte(segment))
+	return h.Sum64()
+}
@@ -0,0 +1,54 @@
+package expander_test
+
+import (
+	"slices"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Instance Sampling", func() {
+	const pattern = "Device.Hosts.Host.*.IPAddress"
+
+	// sample expands the pattern against a table of n hosts
+	sample := func(n int, opts ...expander.Option) []string {
+		exp := expander.Get(opts...)
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register(hostTable("Device.Hosts.Host.", n))).To(Succeed())
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		return paths
+	}
+
+	It("should expand k instances per table", func() {
+		paths := sample(50, expander.WithSampledInstances(5, 1))
+		Expect(paths).To(HaveLen(5))
+		Expect(sample(50, expander.WithSampledInstances(5, 1))).To(Equal(paths))
+	})
+
+	It("should draw other samples from other seeds", func() {
+		Expect(sample(50, expander.WithSampledInstances(5, 1))).NotTo(Equal(sample(50, expander.WithSampledInstances(5, 2))))
+	})
+
+	It("should keep most of the sample as the table grows", func() {
+		before := sample(50, expander.WithSampledInstances(5, 7))
+		after := sample(51, expander.WithSampledInstances(5, 7))
+
+		Expect(after).To(HaveLen(5))
+		kept := 0
+		for _, path := range before {
+			if slices.Contains(after, path) {
+				kept++
+			}
+		}
+		Expect(kept).To(BeNumerically(">=", 4))
+	})
+
+	It("should expand every instance of small tables", func() {
+		Expect(sample(3, expander.WithSampledInstances(5, 1))).To(HaveLen(3))
+	})
+})
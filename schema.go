@@ -0,0 +1,34 @@
+package expander
+
+import (
+	"github.com/metalgrid/tr069-path-expander/v2/datamodel"
+)
+
+// WithSchema makes the expander consult the data model of the device.
+// Tables the data model declares with a fixed number of instances, such as
+// single-instance tables or Device.DeviceInfo.ProcessStatus.Process.{i}.
+// on some models, are resolved to instances 1 to MaxEntries without a
+// discovery.
+func WithSchema(schema *datamodel.Schema) Option {
+	return func(e *Expander) {
+		e.config.schema = schema
+	}
+}
+
+// fixedInstances returns the instances of a discovery path whose table has
+// a fixed number of instances in the schema
+func (e *Expander) fixedInstances(discoveryPath string) ([]string, bool) {
+	if e.config.schema == nil {
+		return nil, false
+	}
+	table, ok := e.config.schema.Object(discoveryPath + datamodel.Instance)
+	if !ok || !table.Fixed() {
+		return nil, false
+	}
+
+	instances := make([]string, table.MaxEntries)
+	for i := range instances {
+		instances[i] = e.renderIndex(discoveryPath, i+1)
+	}
+	return instances, true
+}
@@ -0,0 +1,69 @@
+package expander_test
+
+import (
+	"strings"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	"github.com/metalgrid/tr069-path-expander/v2/datamodel"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testSchema is a slice of TR-181 with a fixed-size process table
+const testSchema = `<document>
+  <model name="Device:2.15">
+    <object name="Device.DeviceInfo.ProcessStatus." access="readOnly" minEntries="1" maxEntries="1">
+      <parameter name="CPUUsage" access="readOnly"><syntax><unsignedInt/></syntax></parameter>
+    </object>
+    <object name="Device.DeviceInfo.ProcessStatus.Process.{i}." access="readOnly" minEntries="3" maxEntries="3">
+      <parameter name="PID" access="readOnly"><syntax><unsignedInt/></syntax></parameter>
+      <parameter name="State" access="readOnly"><syntax><string/></syntax></parameter>
+    </object>
+    <object name="Device.WiFi.SSID.{i}." access="readWrite" minEntries="0" maxEntries="unbounded">
+      <parameter name="SSID" access="readWrite"><syntax><string/></syntax></parameter>
+    </object>
+  </model>
+</document>`
+
+// loadTestSchema parses testSchema
+func loadTestSchema() *datamodel.Schema {
+	schema, err := datamodel.Load(strings.NewReader(testSchema))
+	Expect(err).NotTo(HaveOccurred())
+	return schema
+}
+
+var _ = Describe("Schema", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should resolve fixed-size tables without discovery", func() {
+		exp = expander.Get(expander.WithSchema(loadTestSchema()))
+		Expect(exp.Add("Device.DeviceInfo.ProcessStatus.Process.*.PID", "Device.WiFi.SSID.*.SSID")).To(Succeed())
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.WiFi.SSID."))
+		Expect(exp.Register([]string{path + "1."})).To(Succeed())
+
+		_, hasMore = exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.DeviceInfo.ProcessStatus.Process.1.PID",
+			"Device.DeviceInfo.ProcessStatus.Process.2.PID",
+			"Device.DeviceInfo.ProcessStatus.Process.3.PID",
+			"Device.WiFi.SSID.1.SSID",
+		}))
+	})
+
+	It("should discover fixed-size tables without a schema", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.DeviceInfo.ProcessStatus.Process.*.PID")).To(Succeed())
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.DeviceInfo.ProcessStatus.Process."))
+	})
+})
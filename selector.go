@@ -0,0 +1,100 @@
+package expander
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseSelector converts an XPath-like selector used by some northbound
+// systems, such as "Device/WiFi/AccessPoint[*]/Enable", into a TR-069 path
+// pattern ("Device.WiFi.AccessPoint.*.Enable"). A trailing slash denotes a
+// partial path.
+func ParseSelector(selector string) (string, error) {
+	trimmed := strings.TrimPrefix(selector, "/")
+	if trimmed == "" {
+		return "", fmt.Errorf("selector %q: %w", selector, ErrInvalidPath)
+	}
+
+	partial := strings.HasSuffix(trimmed, "/")
+	trimmed = strings.TrimSuffix(trimmed, "/")
+
+	var b strings.Builder
+	for i, step := range strings.Split(trimmed, "/") {
+		name, instance, err := splitSelectorStep(step)
+		if err != nil {
+			return "", fmt.Errorf("selector %q: %w", selector, err)
+		}
+
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(name)
+		if instance != "" {
+			b.WriteByte('.')
+			b.WriteString(instance)
+		}
+	}
+
+	if partial {
+		b.WriteByte('.')
+	}
+	return b.String(), nil
+}
+
+// FormatSelector converts a TR-069 path or pattern into the XPath-like
+// selector syntax, e.g. "Device.WiFi.AccessPoint.*.Enable" becomes
+// "Device/WiFi/AccessPoint[*]/Enable".
+func FormatSelector(path string) string {
+	partial := strings.HasSuffix(path, ".")
+	segments := strings.Split(strings.TrimSuffix(path, "."), ".")
+
+	var b strings.Builder
+	for i, segment := range segments {
+		if i > 0 && isInstanceSegment(segment) {
+			b.WriteString("[" + segment + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		b.WriteString(segment)
+	}
+
+	if partial {
+		b.WriteByte('/')
+	}
+	return b.String()
+}
+
+// splitSelectorStep splits a selector step such as "AccessPoint[2]" into the
+// object name and the instance selector
+func splitSelectorStep(step string) (string, string, error) {
+	open := strings.IndexByte(step, '[')
+	if open == -1 {
+		if step == "" || strings.ContainsAny(step, "].") {
+			return "", "", ErrInvalidPath
+		}
+		return step, "", nil
+	}
+
+	name, instance := step[:open], step[open+1:]
+	if name == "" || !strings.HasSuffix(instance, "]") {
+		return "", "", ErrInvalidPath
+	}
+
+	instance = strings.TrimSuffix(instance, "]")
+	if !isInstanceSegment(instance) {
+		return "", "", ErrInvalidPath
+	}
+	return name, instance, nil
+}
+
+// isInstanceSegment reports whether a segment selects instances of a table
+func isInstanceSegment(segment string) bool {
+	if segment == "*" {
+		return true
+	}
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
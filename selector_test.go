@@ -0,0 +1,40 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("XPath-like Selectors", func() {
+	DescribeTable("converting selectors to patterns and back",
+		func(selector, pattern string) {
+			parsed, err := expander.ParseSelector(selector)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(pattern))
+			Expect(expander.FormatSelector(pattern)).To(Equal(selector))
+		},
+		Entry("wildcard", "Device/WiFi/AccessPoint[*]/Enable", "Device.WiFi.AccessPoint.*.Enable"),
+		Entry("instance", "Device/WiFi/AccessPoint[2]/Security/ModeEnabled", "Device.WiFi.AccessPoint.2.Security.ModeEnabled"),
+		Entry("nested", "InternetGatewayDevice/LANDevice[1]/WLANConfiguration[*]/SSID", "InternetGatewayDevice.LANDevice.1.WLANConfiguration.*.SSID"),
+		Entry("partial path", "Device/WiFi/SSID[*]/", "Device.WiFi.SSID.*."),
+	)
+
+	It("should accept a leading slash", func() {
+		parsed, err := expander.ParseSelector("/Device/DeviceInfo/UpTime")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal("Device.DeviceInfo.UpTime"))
+	})
+
+	DescribeTable("rejecting malformed selectors",
+		func(selector string) {
+			_, err := expander.ParseSelector(selector)
+			Expect(err).To(MatchError(expander.ErrInvalidPath))
+		},
+		Entry("empty", ""),
+		Entry("empty step", "Device//Enable"),
+		Entry("unterminated bracket", "Device/WiFi/AccessPoint[1/Enable"),
+		Entry("non-numeric instance", "Device/WiFi/AccessPoint[x]/Enable"),
+		Entry("dotted step", "Device.WiFi/Enable"),
+	)
+})
@@ -0,0 +1,74 @@
+package expander
+
+import "strings"
+
+// Classifier decides whether a parameter path is sensitive.
+type Classifier func(path string) bool
+
+// SensitiveMode selects how sensitive paths are treated at collect time.
+type SensitiveMode int
+
+// Sensitive path handling modes
+const (
+	// SensitiveExclude leaves sensitive paths out of the results
+	SensitiveExclude SensitiveMode = iota + 1
+
+	// SensitiveFlag keeps sensitive paths and reports them through Flagged
+	SensitiveFlag
+)
+
+// sensitiveNames are lowercase fragments of parameter names holding credentials
+var sensitiveNames = []string{"password", "passphrase", "presharedkey", "wepkey", "secret"}
+
+// DefaultSensitiveClassifier flags parameters whose name suggests a
+// credential: passwords, passphrases, pre-shared and WEP keys, secrets and PINs.
+func DefaultSensitiveClassifier(path string) bool {
+	name := strings.TrimSuffix(path, ".")
+	if i := strings.LastIndexByte(name, '.'); i != -1 {
+		name = name[i+1:]
+	}
+
+	if strings.HasSuffix(name, "PIN") {
+		return true
+	}
+
+	lower := strings.ToLower(name)
+	for _, fragment := range sensitiveNames {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flagged returns the expanded paths classified as sensitive when the
+// expander is configured with SensitiveFlag. It returns nil otherwise.
+func (e *Expander) Flagged() []string {
+	if e.config.sensitiveMode != SensitiveFlag {
+		return nil
+	}
+
+	var flagged []string
+	for _, path := range e.expandedPaths {
+		if e.config.classifySensitive(path) {
+			flagged = append(flagged, path)
+		}
+	}
+	return flagged
+}
+
+// visiblePaths returns a copy of paths without those excluded as sensitive
+func (e *Expander) visiblePaths(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if e.isVisible(path) {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// isVisible reports whether path is not excluded as sensitive
+func (e *Expander) isVisible(path string) bool {
+	return e.config.sensitiveMode != SensitiveExclude || !e.config.classifySensitive(path)
+}
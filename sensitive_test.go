@@ -0,0 +1,63 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sensitive Parameter Filter", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	add := func() {
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.1.Security.KeyPassphrase",
+			"Device.WiFi.AccessPoint.1.WPS.PIN",
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.IP.Diagnostics.IPPing.Host",
+		)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should exclude sensitive paths", func() {
+		exp = expander.Get(expander.WithSensitiveFilter(expander.SensitiveExclude, nil))
+		add()
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(ConsistOf(
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.IP.Diagnostics.IPPing.Host",
+		))
+		Expect(exp.Flagged()).To(BeNil())
+	})
+
+	It("should flag sensitive paths", func() {
+		exp = expander.Get(expander.WithSensitiveFilter(expander.SensitiveFlag, nil))
+		add()
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(4))
+		Expect(exp.Flagged()).To(ConsistOf(
+			"Device.WiFi.AccessPoint.1.Security.KeyPassphrase",
+			"Device.WiFi.AccessPoint.1.WPS.PIN",
+		))
+	})
+
+	It("should use a custom classifier", func() {
+		exp = expander.Get(expander.WithSensitiveFilter(expander.SensitiveExclude, func(path string) bool {
+			return path == "Device.WiFi.AccessPoint.1.Enable"
+		}))
+		add()
+
+		byPattern, err := exp.CollectByPattern()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(byPattern["Device.WiFi.AccessPoint.1.Enable"]).To(BeEmpty())
+		Expect(byPattern["Device.WiFi.AccessPoint.1.WPS.PIN"]).To(ConsistOf("Device.WiFi.AccessPoint.1.WPS.PIN"))
+	})
+})
@@ -0,0 +1,93 @@
+package expander
+
+import (
+	"context"
+	"fmt"
+)
+
+// Discoverer issues GetParameterNames calls (NextLevel=true) to a device,
+// or anything answering like one, such as an ACS client or a simulator.
+type Discoverer interface {
+	GetParameterNames(ctx context.Context, path string) ([]string, error)
+}
+
+// discoveryFunc adapts a Discoverer to the DiscoverySource of the driver loops
+func discoveryFunc(d Discoverer) DiscoveryFunc {
+	return func(ctx context.Context, path string) ([]string, error) {
+		return d.GetParameterNames(ctx, path)
+	}
+}
+
+// ValueReader issues GetParameterValues calls. A Discoverer implementing it
+// also resolves the values needed by filters and search expressions.
+type ValueReader interface {
+	GetParameterValues(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// Session drives the expansion of one device session: it owns an expander
+// from the default pool and answers its discoveries with a Discoverer.
+type Session struct {
+	exp        *Expander
+	discoverer Discoverer
+}
+
+// NewSession creates a session answering discoveries with d, backed by an
+// expander configured with opts. It must be closed after use.
+func NewSession(d Discoverer, opts ...Option) *Session {
+	return &Session{exp: Get(opts...), discoverer: d}
+}
+
+// Expander returns the expander of the session, to add patterns and read
+// the state of the expansion.
+func (s *Session) Expander() *Expander {
+	return s.exp
+}
+
+// Run discovers until the expansion completes and returns the expanded
+// paths. Values needed by filters and search expressions are read if the
+// Discoverer is a ValueReader. Errors are reported as in Expand; Run can be
+// called again to resume after a failure.
+func (s *Session) Run(ctx context.Context) ([]string, error) {
+	discover := discoveryFunc(s.discoverer)
+	reader, readsValues := s.discoverer.(ValueReader)
+
+	for {
+		if err := s.exp.discoverAll(ctx, discover); err != nil {
+			return nil, err
+		}
+
+		if !readsValues {
+			return s.exp.Collect()
+		}
+		query := s.exp.NextValueQuery()
+		if len(query) == 0 {
+			return s.exp.Collect()
+		}
+		if err := s.exp.answerValues(ctx, reader, query); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// answerValues reads the values of a value query from reader and registers
+// them. On failure the query is forgotten so it is issued again.
+func (e *Expander) answerValues(ctx context.Context, reader ValueReader, query []string) error {
+	values, err := reader.GetParameterValues(ctx, query)
+	if err == nil {
+		err = e.RegisterValues(values)
+	}
+	if err != nil {
+		e.requeueValues(query)
+		return fmt.Errorf("value query: %w", err)
+	}
+	return nil
+}
+
+// Close releases the expander of the session; the session must not be
+// used afterwards.
+func (s *Session) Close() {
+	if s.exp != nil {
+		Release(s.exp)
+		s.exp = nil
+	}
+}
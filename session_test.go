@@ -0,0 +1,104 @@
+package expander_test
+
+import (
+	"context"
+	"errors"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// simulator is a Discoverer and ValueReader backed by fixed parameter values
+type simulator struct {
+	values  map[string]string
+	queries int
+	fail    error
+}
+
+func (s *simulator) GetParameterNames(ctx context.Context, path string) ([]string, error) {
+	var names []string
+	for name := range s.values {
+		names = append(names, name)
+	}
+	return fakeDevice(names...)(ctx, path)
+}
+
+func (s *simulator) GetParameterValues(_ context.Context, names []string) (map[string]string, error) {
+	s.queries++
+	if s.fail != nil {
+		return nil, s.fail
+	}
+	values := make(map[string]string)
+	for _, name := range names {
+		if value, ok := s.values[name]; ok {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// namesOnly hides the ValueReader of a simulator
+type namesOnly struct{ device *simulator }
+
+func (n namesOnly) GetParameterNames(ctx context.Context, path string) ([]string, error) {
+	return n.device.GetParameterNames(ctx, path)
+}
+
+var _ = Describe("Session", func() {
+	var (
+		device  *simulator
+		session *expander.Session
+	)
+
+	BeforeEach(func() {
+		device = &simulator{values: map[string]string{
+			"Device.WiFi.SSID.1.SSID":   "home",
+			"Device.WiFi.SSID.1.Enable": "true",
+			"Device.WiFi.SSID.2.SSID":   "guest",
+			"Device.WiFi.SSID.2.Enable": "false",
+		}}
+	})
+
+	AfterEach(func() {
+		session.Close()
+	})
+
+	It("should run the expansion to completion", func() {
+		session = expander.NewSession(device)
+		Expect(session.Expander().Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+
+		Expect(session.Run(context.Background())).To(Equal([]string{
+			"Device.WiFi.SSID.1.SSID",
+			"Device.WiFi.SSID.2.SSID",
+		}))
+	})
+
+	It("should read the values of search expressions", func() {
+		session = expander.NewSession(device)
+		Expect(session.Expander().Add("Device.WiFi.SSID.[Enable==true].SSID")).To(Succeed())
+
+		Expect(session.Run(context.Background())).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+		Expect(device.queries).To(Equal(1))
+	})
+
+	It("should resume after a failed value query", func() {
+		device.fail = errors.New("session closed")
+		session = expander.NewSession(device)
+		Expect(session.Expander().Add("Device.WiFi.SSID.[Enable==true].SSID")).To(Succeed())
+
+		_, err := session.Run(context.Background())
+		Expect(err).To(MatchError(device.fail))
+
+		device.fail = nil
+		Expect(session.Run(context.Background())).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+	})
+
+	It("should fail when values are needed but cannot be read", func() {
+		session = expander.NewSession(namesOnly{device})
+		Expect(session.Expander().Add("Device.WiFi.SSID.[Enable==true].SSID")).To(Succeed())
+
+		_, err := session.Run(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("filter values are needed")))
+	})
+})
@@ -0,0 +1,79 @@
+package expander
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrBadSignature is returned when a signed payload is unsigned, malformed
+// or signed with another key
+var ErrBadSignature = errors.New("payload signature is missing or invalid")
+
+// signaturePrefix starts the signature line of a signed payload
+const signaturePrefix = "hmac-sha256:"
+
+// Sign prepends an HMAC-SHA256 signature of payload under key, on a line of
+// its own, so a payload kept in an external store, such as an exported
+// cache, a state or a transcript, can be checked with Verify when it is read
+// back. A tampered payload could otherwise steer which parameters are read or
+// set on devices.
+func Sign(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	signed := make([]byte, 0, len(signaturePrefix)+2*sha256.Size+1+len(payload))
+	signed = append(signed, signaturePrefix...)
+	signed = hex.AppendEncode(signed, mac.Sum(nil))
+	signed = append(signed, '\n')
+	return append(signed, payload...)
+}
+
+// Verify checks a payload signed by Sign under key and returns the payload
+// without its signature. It fails with ErrBadSignature if the signature is
+// missing or doesn't match.
+func Verify(key, signed []byte) ([]byte, error) {
+	line, payload, found := bytes.Cut(signed, []byte("\n"))
+	encoded, prefixed := bytes.CutPrefix(line, []byte(signaturePrefix))
+	if !found || !prefixed {
+		return nil, ErrBadSignature
+	}
+	signature := make([]byte, hex.DecodedLen(len(encoded)))
+	if _, err := hex.Decode(signature, encoded); err != nil {
+		return nil, ErrBadSignature
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrBadSignature
+	}
+	return payload, nil
+}
+
+// WithSigningKey signs the payloads of ExportCache and MarshalState with key
+// and makes ImportCache and UnmarshalState refuse payloads that are not
+// signed with it.
+func WithSigningKey(key []byte) Option {
+	return func(e *Expander) {
+		e.config.signingKey = key
+	}
+}
+
+// seal signs a serialized payload if a signing key is configured
+func (e *Expander) seal(payload []byte, err error) ([]byte, error) {
+	if err != nil || e.config.signingKey == nil {
+		return payload, err
+	}
+	return Sign(e.config.signingKey, payload), nil
+}
+
+// unseal verifies a serialized payload if a signing key is configured
+func (e *Expander) unseal(data []byte) ([]byte, error) {
+	if e.config.signingKey == nil {
+		return data, nil
+	}
+	return Verify(e.config.signingKey, data)
+}
@@ -0,0 +1,69 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Payload Signing", func() {
+	key := []byte("store-key")
+
+	It("should verify payloads signed with the same key", func() {
+		signed := expander.Sign(key, []byte(`{"a":1}`))
+		Expect(expander.Verify(key, signed)).To(Equal([]byte(`{"a":1}`)))
+	})
+
+	DescribeTable("rejected payloads",
+		func(signed []byte) {
+			_, err := expander.Verify(key, signed)
+			Expect(err).To(MatchError(expander.ErrBadSignature))
+		},
+		Entry("unsigned", []byte(`{"a":1}`)),
+		Entry("other key", expander.Sign([]byte("other"), []byte(`{"a":1}`))),
+		Entry("tampered", append(expander.Sign(key, []byte(`{"a":1}`)), ' ')),
+		Entry("malformed signature", []byte("hmac-sha256:zz\n{}")),
+	)
+
+	Context("with a signing key", func() {
+		var source, target *expander.Expander
+
+		BeforeEach(func() {
+			source = expander.Get(expander.WithSigningKey(key))
+			Expect(source.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+			drainDiscoveries(source)
+		})
+
+		AfterEach(func() {
+			expander.Release(source)
+			expander.Release(target)
+			target = nil
+		})
+
+		It("should sign exported caches and states", func() {
+			cache, err := source.ExportCache()
+			Expect(err).NotTo(HaveOccurred())
+			state, err := source.MarshalState()
+			Expect(err).NotTo(HaveOccurred())
+
+			target = expander.Get(expander.WithSigningKey(key))
+			Expect(target.ImportCache(cache)).To(Succeed())
+			Expect(target.UnmarshalState(state)).To(Succeed())
+			Expect(target.Collect()).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+		})
+
+		It("should refuse payloads that are not signed with the key", func() {
+			unsigned := expander.Get()
+			defer expander.Release(unsigned)
+			Expect(unsigned.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+			state, err := unsigned.MarshalState()
+			Expect(err).NotTo(HaveOccurred())
+			cache, err := unsigned.ExportCache()
+			Expect(err).NotTo(HaveOccurred())
+
+			target = expander.Get(expander.WithSigningKey(key))
+			Expect(target.UnmarshalState(state)).To(MatchError(expander.ErrBadSignature))
+			Expect(target.ImportCache(cache)).To(MatchError(expander.ErrBadSignature))
+		})
+	})
+})
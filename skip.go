@@ -0,0 +1,29 @@
+package expander
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Skip abandons a discovery path: it is taken off the pending queue, or no
+// longer awaited if it was handed out, and never queued again, so the
+// expansion completes without the branch below it. Paths not reached yet
+// can be skipped in advance. Skipped paths are left unresolved and listed
+// by Skipped.
+func (e *Expander) Skip(discoveryPath string) {
+	if !strings.HasSuffix(discoveryPath, ".") {
+		discoveryPath += "."
+	}
+	e.skipped[discoveryPath] = true
+	e.dequeue(discoveryPath)
+	delete(e.outstanding, discoveryPath)
+	if e.lastDiscoveryPath == discoveryPath {
+		e.lastDiscoveryPath = ""
+	}
+}
+
+// Skipped returns the discovery paths abandoned with Skip, sorted.
+func (e *Expander) Skipped() []string {
+	return slices.Sorted(maps.Keys(e.skipped))
+}
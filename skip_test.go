@@ -0,0 +1,79 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Skipping Discoveries", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		Expect(exp.Add(
+			"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.SSID",
+			"InternetGatewayDevice.WANDevice.*.WANConnectionDevice.*.",
+		)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should complete with partial results when a discovery is skipped", func() {
+		batch := exp.NextBatch(2)
+		Expect(batch).To(ConsistOf("InternetGatewayDevice.LANDevice.", "InternetGatewayDevice.WANDevice."))
+
+		exp.Skip("InternetGatewayDevice.WANDevice.")
+		Expect(exp.RegisterFor("InternetGatewayDevice.LANDevice.", []string{"InternetGatewayDevice.LANDevice.1."})).To(Succeed())
+		path, _ := exp.Next()
+		Expect(path).To(Equal("InternetGatewayDevice.LANDevice.1.WLANConfiguration."))
+		Expect(exp.Register([]string{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1."})).To(Succeed())
+
+		_, hasMore := exp.Next()
+		Expect(hasMore).To(BeFalse())
+		Expect(exp.Collect()).To(Equal([]string{"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.SSID"}))
+		Expect(exp.Skipped()).To(Equal([]string{"InternetGatewayDevice.WANDevice."}))
+	})
+
+	It("should reject late answers for a skipped discovery", func() {
+		path, _ := exp.Next()
+		exp.Skip(path)
+		Expect(exp.Register([]string{path + "1."})).To(MatchError(expander.ErrNoDiscovery))
+		Expect(exp.RegisterFor(path, []string{path + "1."})).To(MatchError(expander.ErrNotOutstanding))
+	})
+
+	It("should skip discoveries not reached yet", func() {
+		exp.Skip("InternetGatewayDevice.LANDevice.1.WLANConfiguration")
+
+		var discovered []string
+		for path, ok := exp.Next(); ok; path, ok = exp.Next() {
+			discovered = append(discovered, path)
+			switch path {
+			case "InternetGatewayDevice.LANDevice.":
+				Expect(exp.Register([]string{path + "1.", path + "2."})).To(Succeed())
+			default:
+				Expect(exp.Register([]string{path + "1."})).To(Succeed())
+			}
+		}
+		Expect(discovered).NotTo(ContainElement("InternetGatewayDevice.LANDevice.1.WLANConfiguration."))
+		Expect(exp.Collect()).To(ContainElement("InternetGatewayDevice.LANDevice.2.WLANConfiguration.1.SSID"))
+		Expect(exp.Collect()).NotTo(ContainElement(HavePrefix("InternetGatewayDevice.LANDevice.1.")))
+	})
+
+	It("should keep skipped discoveries across a state round trip", func() {
+		path, _ := exp.Next()
+		exp.Skip(path)
+
+		data, err := exp.MarshalState()
+		Expect(err).NotTo(HaveOccurred())
+		restored := expander.Get()
+		defer expander.Release(restored)
+		Expect(restored.UnmarshalState(data)).To(Succeed())
+		Expect(restored.Skipped()).To(Equal([]string{path}))
+
+		next, _ := restored.Next()
+		Expect(next).NotTo(Equal(path))
+	})
+})
@@ -0,0 +1,144 @@
+package expander
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Snapshot captures the outcome of an expansion so it can be compared with
+// a later expansion of the same pattern set, e.g. between periodic Informs.
+// It can be stored as JSON.
+type Snapshot struct {
+	// Paths holds every expanded path, sorted
+	Paths []string `json:"paths"`
+
+	// Instances holds every discovered object instance as a partial path,
+	// e.g. "Device.Hosts.Host.3.", sorted
+	Instances []string `json:"instances"`
+}
+
+// SnapshotDiff lists what appeared and disappeared between two snapshots.
+type SnapshotDiff struct {
+	AddedPaths       []string
+	RemovedPaths     []string
+	AddedInstances   []string
+	RemovedInstances []string
+}
+
+// Empty reports whether nothing changed between the snapshots.
+func (d SnapshotDiff) Empty() bool {
+	return len(d.AddedPaths) == 0 && len(d.RemovedPaths) == 0 &&
+		len(d.AddedInstances) == 0 && len(d.RemovedInstances) == 0
+}
+
+// Snapshot captures the expanded paths and discovered instances.
+// This should be called after Next() returns false.
+func (e *Expander) Snapshot() (Snapshot, error) {
+	paths, err := e.Collect()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var instances []string
+	for discoveryPath := range e.processedDiscoveries {
+		segments, _ := e.instances(discoveryPath)
+		for _, segment := range segments {
+			instances = append(instances, discoveryPath+segment+".")
+		}
+	}
+	sort.Strings(instances)
+
+	return Snapshot{Paths: paths, Instances: instances}, nil
+}
+
+// Diff compares the current expansion with an earlier snapshot of the same
+// pattern set and reports the paths and instances that appeared and
+// disappeared since. This should be called after Next() returns false.
+func (e *Expander) Diff(previous Snapshot) (SnapshotDiff, error) {
+	current, err := e.Snapshot()
+	if err != nil {
+		return SnapshotDiff{}, err
+	}
+	return previous.Diff(current), nil
+}
+
+// Diff reports what appeared and disappeared from s to next.
+func (s Snapshot) Diff(next Snapshot) SnapshotDiff {
+	var diff SnapshotDiff
+	diff.AddedPaths, diff.RemovedPaths = diffSorted(sortedCopy(s.Paths), sortedCopy(next.Paths))
+	diff.AddedInstances, diff.RemovedInstances = diffSorted(sortedCopy(s.Instances), sortedCopy(next.Instances))
+	return diff
+}
+
+// sortedCopy returns a sorted copy of values, which may have been edited
+// since they were captured
+func sortedCopy(values []string) []string {
+	sorted := slices.Clone(values)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// diffSorted returns the values only present in next, then those only
+// present in prev. Both inputs must be sorted.
+func diffSorted(prev, next []string) ([]string, []string) {
+	var added, removed []string
+	for len(prev) > 0 || len(next) > 0 {
+		switch {
+		case len(prev) == 0 || len(next) > 0 && next[0] < prev[0]:
+			added = append(added, next[0])
+			next = next[1:]
+		case len(next) == 0 || prev[0] < next[0]:
+			removed = append(removed, prev[0])
+			prev = prev[1:]
+		default:
+			prev, next = prev[1:], next[1:]
+		}
+	}
+	return added, removed
+}
+
+// DiffSnapshots expands patterns against two full parameter name snapshots
+// of a device, e.g. taken before and after a firmware upgrade, and reports
+// the expanded paths only present after and those only present before.
+// Expanded paths a snapshot doesn't list, such as a literal parameter the
+// firmware dropped, count as absent from it.
+func DiffSnapshots(patterns []string, before, after []string) (added, removed []string, err error) {
+	expandedBefore, err := expandSnapshot(patterns, before)
+	if err != nil {
+		return nil, nil, err
+	}
+	expandedAfter, err := expandSnapshot(patterns, after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	added, removed = diffSorted(expandedBefore, expandedAfter)
+	return added, removed, nil
+}
+
+// expandSnapshot expands patterns against a full snapshot of parameter
+// names and keeps the paths the snapshot lists
+func expandSnapshot(patterns []string, names []string) ([]string, error) {
+	exp := Get()
+	defer Release(exp)
+	if err := exp.Add(patterns...); err != nil {
+		return nil, err
+	}
+
+	sorted := sortedCopy(names)
+	paths, err := exp.ExpandAgainst(sorted)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(paths, func(path string) bool {
+		return !listsPath(sorted, path)
+	}), nil
+}
+
+// listsPath reports whether sorted parameter names list a parameter path,
+// or any name below an object path
+func listsPath(sorted []string, path string) bool {
+	i, found := slices.BinarySearch(sorted, path)
+	return found || strings.HasSuffix(path, ".") && i < len(sorted) && strings.HasPrefix(sorted[i], path)
+}
@@ -0,0 +1,101 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot Diff", func() {
+	const pattern = "Device.Hosts.Host.*.HostName"
+
+	expand := func(hosts ...string) *expander.Expander {
+		exp := expander.Get()
+		Expect(exp.Add(pattern)).To(Succeed())
+		_, _ = exp.Next()
+		Expect(exp.Register(hosts)).To(Succeed())
+		return exp
+	}
+
+	It("should report appeared and disappeared hosts", func() {
+		before := expand("Device.Hosts.Host.1.", "Device.Hosts.Host.2.")
+		snapshot, err := before.Snapshot()
+		expander.Release(before)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Instances).To(Equal([]string{"Device.Hosts.Host.1.", "Device.Hosts.Host.2."}))
+
+		after := expand("Device.Hosts.Host.2.", "Device.Hosts.Host.3.")
+		defer expander.Release(after)
+
+		diff, err := after.Diff(snapshot)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff).To(Equal(expander.SnapshotDiff{
+			AddedPaths:       []string{"Device.Hosts.Host.3.HostName"},
+			RemovedPaths:     []string{"Device.Hosts.Host.1.HostName"},
+			AddedInstances:   []string{"Device.Hosts.Host.3."},
+			RemovedInstances: []string{"Device.Hosts.Host.1."},
+		}))
+		Expect(diff.Empty()).To(BeFalse())
+	})
+
+	It("should report no changes for identical expansions", func() {
+		exp := expand("Device.Hosts.Host.1.")
+		defer expander.Release(exp)
+
+		snapshot, err := exp.Snapshot()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Diff(snapshot).Empty()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Offline Snapshot Diff", func() {
+	It("should report path differences between two parameter snapshots", func() {
+		before := []string{
+			"Device.WiFi.SSID.1.SSID",
+			"Device.WiFi.SSID.1.Enable",
+			"Device.WiFi.SSID.2.SSID",
+			"Device.WiFi.SSID.2.Enable",
+			"Device.DeviceInfo.SoftwareVersion",
+		}
+		after := []string{
+			"Device.WiFi.SSID.1.SSID",
+			"Device.WiFi.SSID.1.Enable",
+			"Device.WiFi.SSID.3.SSID",
+			"Device.WiFi.SSID.3.Enable",
+			"Device.WiFi.SSID.3.X_ACME_Band",
+			"Device.DeviceInfo.SoftwareVersion",
+		}
+
+		added, removed, err := expander.DiffSnapshots([]string{"Device.WiFi.SSID.*.SSID", "Device.DeviceInfo.SoftwareVersion"}, before, after)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(added).To(Equal([]string{"Device.WiFi.SSID.3.SSID"}))
+		Expect(removed).To(Equal([]string{"Device.WiFi.SSID.2.SSID"}))
+	})
+
+	It("should check literal paths for presence in each snapshot", func() {
+		before := []string{
+			"Device.DeviceInfo.SoftwareVersion",
+			"Device.DeviceInfo.X_ACME_Debug",
+			"Device.Hosts.Host.1.IPAddress",
+		}
+		after := []string{
+			"Device.DeviceInfo.SoftwareVersion",
+			"Device.DeviceInfo.X_ACME_Telemetry.Enable",
+		}
+
+		added, removed, err := expander.DiffSnapshots([]string{
+			"Device.DeviceInfo.SoftwareVersion",
+			"Device.DeviceInfo.X_ACME_Debug",
+			"Device.DeviceInfo.X_ACME_Telemetry.",
+			"Device.Hosts.Host.1.IPAddress",
+		}, before, after)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(added).To(Equal([]string{"Device.DeviceInfo.X_ACME_Telemetry."}))
+		Expect(removed).To(Equal([]string{"Device.DeviceInfo.X_ACME_Debug", "Device.Hosts.Host.1.IPAddress"}))
+	})
+
+	It("should report invalid patterns", func() {
+		_, _, err := expander.DiffSnapshots([]string{"Device.Access*Point.*.Enable"}, nil, nil)
+		Expect(err).To(MatchError(expander.ErrEmbeddedWildcard))
+	})
+})
@@ -0,0 +1,171 @@
+package expander
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// stateVersion is the version of the serialized state format
+const stateVersion = 2
+
+// state is the serialized form of an expansion in progress
+type state struct {
+	Version     int                       `json:"version"`
+	DeviceID    string                    `json:"deviceId,omitempty"`
+	Patterns    []string                  `json:"patterns"`
+	Provenance  map[string][]Attribution  `json:"provenance,omitempty"`
+	Owners      map[string][]string       `json:"owners,omitempty"`
+	Cache       map[string][]int          `json:"cache"`
+	Aliases     map[string][]string       `json:"aliases,omitempty"`
+	AliasValues map[string]map[int]string `json:"aliasValues,omitempty"`
+	Names       map[string][]string       `json:"names,omitempty"`
+	Writable    map[string]bool           `json:"writable,omitempty"`
+	Filters     map[string][]Filter       `json:"filters,omitempty"`
+	Values      map[string]string         `json:"values,omitempty"`
+	Faults      map[string]int            `json:"faults,omitempty"`
+	Skipped     []string                  `json:"skipped,omitempty"`
+	Priorities  map[string]int            `json:"priorities,omitempty"`
+	Pending     []string                  `json:"pending"`
+	Processed   []string                  `json:"processed"`
+	Expanded    []string                  `json:"expanded"`
+	Complete    bool                      `json:"complete"`
+}
+
+// MarshalState serializes the patterns, the discovered instances, the
+// discovery queue and the expanded paths so that an expansion interrupted by
+// the end of a CWMP session can resume in the next one. Discoveries handed
+// out but not registered yet are queued again in front of the pending ones.
+// Options are not part of the state. The state is signed if a key is set
+// with WithSigningKey.
+func (e *Expander) MarshalState() ([]byte, error) {
+	outstanding := slices.Sorted(maps.Keys(e.outstanding))
+	processed := slices.Sorted(maps.Keys(e.processedDiscoveries))
+
+	cache := make(map[string][]int, len(processed))
+	for _, path := range processed {
+		if indices, cached := e.cache.Get(path); cached {
+			cache[path] = indices
+		}
+	}
+
+	return e.seal(json.Marshal(state{
+		Version:     stateVersion,
+		DeviceID:    e.deviceID,
+		Patterns:    e.patterns,
+		Provenance:  e.provenance,
+		Owners:      e.owners,
+		Cache:       cache,
+		Aliases:     e.aliases,
+		AliasValues: e.aliasValues,
+		Names:       e.names,
+		Writable:    e.writable,
+		Filters:     e.filters,
+		Values:      e.values,
+		Faults:      e.faults,
+		Skipped:     e.Skipped(),
+		Priorities:  e.priorities,
+		Pending:     append(outstanding, e.pendingDiscoveries...),
+		Processed:   processed,
+		Expanded:    e.expandedPaths,
+		Complete:    e.isComplete,
+	}))
+}
+
+// UnmarshalState replaces the state of the expander with one produced by
+// MarshalState. The options of the expander are kept. The restored state is
+// verified with Check; on failure the expander is left reset.
+func (e *Expander) UnmarshalState(data []byte) error {
+	data, err := e.unseal(data)
+	if err != nil {
+		return err
+	}
+
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("failed to decode state: %w", err)
+	}
+	if s.Version != stateVersion {
+		return fmt.Errorf("unsupported state version %d", s.Version)
+	}
+
+	cfg := e.config
+	e.Reset()
+	e.config = cfg
+
+	if err := e.restore(s); err != nil {
+		e.Reset()
+		e.config = cfg
+		return err
+	}
+	return nil
+}
+
+// restore loads a decoded state into a reset expander
+func (e *Expander) restore(s state) error {
+	e.deviceID = s.DeviceID
+	e.projected = -1
+
+	for _, pattern := range s.Patterns {
+		if err := e.addSearches(pattern); err != nil {
+			return err
+		}
+		if err := e.paths.addPathAs(e.treePath(pattern), pattern); err != nil {
+			return fmt.Errorf("failed to add path %s: %w", pattern, err)
+		}
+		if _, known := e.patternPaths[pattern]; !known {
+			e.patterns = append(e.patterns, pattern)
+			e.patternPaths[pattern] = nil
+		}
+	}
+	for pattern, attributions := range s.Provenance {
+		e.provenance[pattern] = slices.Clone(attributions)
+	}
+	for owner, patterns := range s.Owners {
+		e.owners[owner] = slices.Clone(patterns)
+	}
+
+	for path, indices := range s.Cache {
+		e.cache.Put(path, slices.Clone(indices))
+	}
+	for path, aliases := range s.Aliases {
+		e.aliases[path] = slices.Clone(aliases)
+	}
+	for table, values := range s.AliasValues {
+		e.aliasValues[table] = maps.Clone(values)
+	}
+	for path, names := range s.Names {
+		e.names[path] = slices.Clone(names)
+	}
+	maps.Copy(e.writable, s.Writable)
+	for pattern, filters := range s.Filters {
+		e.filters[pattern] = slices.Clone(filters)
+	}
+	maps.Copy(e.values, s.Values)
+	maps.Copy(e.faults, s.Faults)
+	maps.Copy(e.priorities, s.Priorities)
+	e.forgetPriorities()
+	for _, path := range s.Skipped {
+		e.skipped[path] = true
+	}
+	for _, path := range s.Processed {
+		e.processedDiscoveries[path] = true
+	}
+
+	e.enqueue(false, s.Pending...)
+	for _, path := range s.Expanded {
+		if !e.expandedSet[path] {
+			e.expandedPaths = append(e.expandedPaths, path)
+			e.expandedSet[path] = true
+		}
+	}
+	e.sortPaths(e.expandedPaths)
+
+	if s.Complete {
+		e.isComplete = true
+		e.generateExpandedPaths()
+	}
+
+	return e.Check()
+}
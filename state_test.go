@@ -0,0 +1,89 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("State Snapshots", func() {
+	var exp, resumed *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		resumed = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+		expander.Release(resumed)
+	})
+
+	It("should resume an interrupted expansion without redoing discoveries", func() {
+		Expect(exp.Bind("CPE-0001")).To(Succeed())
+		err := exp.Add("InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.SSID")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.Register([]string{"InternetGatewayDevice.LANDevice.1", "InternetGatewayDevice.LANDevice.2"})
+		Expect(err).NotTo(HaveOccurred())
+
+		// The session ends while this discovery is in flight
+		interrupted, _ := exp.Next()
+
+		data, err := exp.MarshalState()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resumed.UnmarshalState(data)).To(Succeed())
+		Expect(resumed.DeviceID()).To(Equal("CPE-0001"))
+
+		var discovered []string
+		for {
+			path, hasMore := resumed.Next()
+			if !hasMore {
+				break
+			}
+			discovered = append(discovered, path)
+			Expect(resumed.Register([]string{path + "1"})).To(Succeed())
+		}
+		Expect(discovered).To(ConsistOf(
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.",
+			"InternetGatewayDevice.LANDevice.2.WLANConfiguration.",
+		))
+		Expect(discovered[0]).To(Equal(interrupted))
+
+		paths, err := resumed.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.SSID",
+			"InternetGatewayDevice.LANDevice.2.WLANConfiguration.1.SSID",
+		}))
+	})
+
+	It("should restore a completed expansion", func() {
+		err := exp.Add("Device.WiFi.SSID.*.SSID")
+		Expect(err).NotTo(HaveOccurred())
+		_, _ = exp.Next()
+		Expect(exp.Register([]string{"Device.WiFi.SSID.1"})).To(Succeed())
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := exp.MarshalState()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resumed.UnmarshalState(data)).To(Succeed())
+
+		Expect(resumed.Collect()).To(Equal(paths))
+		Expect(resumed.Summary()).To(Equal(exp.Summary()))
+	})
+
+	It("should refuse inconsistent state", func() {
+		err := resumed.UnmarshalState([]byte(`{
+			"version": 2,
+			"patterns": ["Device.WiFi.SSID.*.SSID"],
+			"pending": ["Device.Ethernet.Interface."]
+		}`))
+		Expect(err).To(MatchError(expander.ErrCorruptState))
+
+		Expect(resumed.UnmarshalState([]byte(`{"version": 99}`))).To(MatchError(ContainSubstring("unsupported state version")))
+		Expect(resumed.UnmarshalState([]byte(`not json`))).To(HaveOccurred())
+	})
+})
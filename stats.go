@@ -0,0 +1,65 @@
+package expander
+
+// PatternStat describes how many concrete paths a single pattern produced
+// on the last expansion.
+type PatternStat struct {
+	Pattern string
+	Yield   int
+}
+
+// Summary describes the outcome of the last expansion.
+type Summary struct {
+	// Patterns lists every added pattern in the order it was first added
+	Patterns []PatternStat
+
+	// Discoveries is the number of discovery paths resolved so far
+	Discoveries int
+
+	// Paths is the number of distinct expanded paths
+	Paths int
+}
+
+// DeadPatterns returns the patterns that produced no paths on the last expansion.
+func (s Summary) DeadPatterns() []string {
+	var dead []string
+	for _, stat := range s.Patterns {
+		if stat.Yield == 0 {
+			dead = append(dead, stat.Pattern)
+		}
+	}
+	return dead
+}
+
+// Summary returns per-pattern yield statistics for the last expansion.
+// Patterns that have not been expanded yet report a yield of zero.
+func (e *Expander) Summary() Summary {
+	summary := Summary{
+		Patterns:    make([]PatternStat, 0, len(e.patterns)),
+		Discoveries: len(e.processedDiscoveries),
+		Paths:       len(e.expandedPaths),
+	}
+
+	for _, pattern := range e.patterns {
+		summary.Patterns = append(summary.Patterns, PatternStat{
+			Pattern: pattern,
+			Yield:   len(e.patternPaths[pattern]),
+		})
+	}
+
+	return summary
+}
+
+// CollectByPattern returns the expanded paths grouped by the pattern that
+// produced them. A path matched by several patterns appears under each of them.
+// This should be called after Next() returns false.
+func (e *Expander) CollectByPattern() (map[string][]string, error) {
+	if err := e.ensureComplete(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]string, len(e.patternPaths))
+	for pattern, paths := range e.patternPaths {
+		result[pattern] = e.visiblePaths(paths)
+	}
+	return result, nil
+}
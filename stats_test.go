@@ -0,0 +1,82 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pattern Statistics", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should report the yield of each pattern", func() {
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.Ethernet.Interface.*.Status",
+			"Device.DeviceInfo.UpTime",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		for {
+			path, hasMore := exp.Next()
+			if !hasMore {
+				break
+			}
+			if path == "Device.WiFi.AccessPoint." {
+				err = exp.Register([]string{
+					"Device.WiFi.AccessPoint.1",
+					"Device.WiFi.AccessPoint.2",
+				})
+			} else {
+				err = exp.Register([]string{})
+			}
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		summary := exp.Summary()
+		Expect(summary.Patterns).To(Equal([]expander.PatternStat{
+			{Pattern: "Device.WiFi.AccessPoint.*.Enable", Yield: 2},
+			{Pattern: "Device.Ethernet.Interface.*.Status", Yield: 0},
+			{Pattern: "Device.DeviceInfo.UpTime", Yield: 1},
+		}))
+		Expect(summary.Discoveries).To(Equal(2))
+		Expect(summary.Paths).To(Equal(3))
+		Expect(summary.DeadPatterns()).To(ConsistOf("Device.Ethernet.Interface.*.Status"))
+	})
+
+	It("should group expanded paths by pattern", func() {
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.WiFi.AccessPoint.*.Status",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _ = exp.Next()
+		err = exp.Register([]string{"Device.WiFi.AccessPoint.1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		byPattern, err := exp.CollectByPattern()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(byPattern).To(Equal(map[string][]string{
+			"Device.WiFi.AccessPoint.*.Enable": {"Device.WiFi.AccessPoint.1.Enable"},
+			"Device.WiFi.AccessPoint.*.Status": {"Device.WiFi.AccessPoint.1.Status"},
+		}))
+	})
+
+	It("should refuse to group before expansion completes", func() {
+		err := exp.Add("Device.WiFi.AccessPoint.*.Enable")
+		Expect(err).NotTo(HaveOccurred())
+
+		byPattern, err := exp.CollectByPattern()
+		Expect(err).To(HaveOccurred())
+		Expect(byPattern).To(BeNil())
+	})
+})
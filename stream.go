@@ -0,0 +1,99 @@
+package expander
+
+import "context"
+
+// PathStream delivers expanded paths as soon as the branch producing them is
+// resolved. C is closed once the expansion completes or fails; Err then
+// reports the outcome.
+type PathStream struct {
+	C   <-chan string
+	err error
+}
+
+// Err returns the error that ended the stream, or nil if the expansion
+// completed. It must only be called after C is closed.
+func (s *PathStream) Err() error {
+	return s.err
+}
+
+// Stream runs the discovery loop against src in a new goroutine and sends
+// every expanded path on the stream as soon as no pending discovery can
+// affect it, so value retrieval for completed branches can start while deeper
+// discoveries are still in flight. Paths arrive in resolution order, each
+// exactly once; Collect returns the same paths sorted once the stream is
+// closed. Discoveries are issued as in Run, rate limited, retried and
+// dispatched concurrently as configured, and errors are reported as in
+// Expand. Values needed by filters and search expressions are read if src
+// is a ValueReader, as in Session.Run.
+//
+// The expander must not be used until the stream is closed. Buffer sets the
+// channel capacity; a slow reader stalls discovery once the buffer is full.
+func (e *Expander) Stream(ctx context.Context, src DiscoverySource, buffer int) *PathStream {
+	paths := make(chan string, buffer)
+	stream := &PathStream{C: paths}
+
+	go func() {
+		defer close(paths)
+		stream.err = e.stream(ctx, src, paths)
+	}()
+	return stream
+}
+
+// stream runs the discovery loop, emitting resolved paths after every registration
+func (e *Expander) stream(ctx context.Context, src DiscoverySource, paths chan<- string) error {
+	emitted := make(map[string]bool)
+	emit := func() error {
+		for _, path := range e.resolvedPaths() {
+			if emitted[path] {
+				continue
+			}
+			emitted[path] = true
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+
+	reader, readsValues := src.(ValueReader)
+	for {
+		if err := emit(); err != nil {
+			return err
+		}
+		if err := e.discoverEach(ctx, src, emit); err != nil {
+			return err
+		}
+
+		if !readsValues {
+			break
+		}
+		query := e.NextValueQuery()
+		if len(query) == 0 {
+			break
+		}
+		if err := e.answerValues(ctx, reader, query); err != nil {
+			return err
+		}
+	}
+
+	if err := e.ensureComplete(); err != nil {
+		return err
+	}
+	return emit()
+}
+
+// resolvedPaths returns the visible expanded paths reachable with the
+// discoveries resolved so far, in tree order
+func (e *Expander) resolvedPaths() []string {
+	var resolved []string
+	e.walk(treeVisitor{
+		leaf: func(path, pattern string) {
+			if e.keepsPath(path, pattern) && e.isVisible(path) {
+				resolved = append(resolved, path)
+			}
+		},
+	})
+	return resolved
+}
@@ -0,0 +1,285 @@
+package expander_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path Streaming", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+		err := exp.Add(
+			"Device.DeviceInfo.UpTime",
+			"Device.WiFi.SSID.*.SSID",
+			"Device.Hosts.Host.*.IPv4Address.*.IPAddress",
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should emit paths before deeper discoveries are answered", func() {
+		var emittedBeforeHosts []string
+		var stream *expander.PathStream
+		started, hostsAsked := make(chan struct{}), make(chan struct{})
+		src := expander.DiscoveryFunc(func(ctx context.Context, path string) ([]string, error) {
+			if path == "Device.Hosts.Host.1.IPv4Address." {
+				// Everything resolved so far is waiting in the buffer
+				<-started
+				for len(stream.C) > 0 {
+					emittedBeforeHosts = append(emittedBeforeHosts, <-stream.C)
+				}
+				close(hostsAsked)
+			}
+			return fakeDevice(
+				"Device.WiFi.SSID.1.",
+				"Device.Hosts.Host.1.",
+				"Device.Hosts.Host.1.IPv4Address.1.",
+			)(ctx, path)
+		})
+
+		stream = exp.Stream(context.Background(), src, 8)
+		close(started)
+		<-hostsAsked
+		var rest []string
+		for path := range stream.C {
+			rest = append(rest, path)
+		}
+		Expect(stream.Err()).NotTo(HaveOccurred())
+
+		Expect(emittedBeforeHosts).To(ConsistOf("Device.DeviceInfo.UpTime", "Device.WiFi.SSID.1.SSID"))
+		Expect(rest).To(Equal([]string{"Device.Hosts.Host.1.IPv4Address.1.IPAddress"}))
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(ConsistOf(append(emittedBeforeHosts, rest...)))
+	})
+
+	It("should report discovery failures after closing the stream", func() {
+		failure := errors.New("session closed")
+		stream := exp.Stream(context.Background(), expander.DiscoveryFunc(
+			func(context.Context, string) ([]string, error) {
+				return nil, failure
+			},
+		), 4)
+
+		var paths []string
+		for path := range stream.C {
+			paths = append(paths, path)
+		}
+		Expect(paths).To(Equal([]string{"Device.DeviceInfo.UpTime"}))
+		Expect(stream.Err()).To(MatchError(failure))
+
+		var discoveryErr *expander.DiscoveryError
+		Expect(errors.As(stream.Err(), &discoveryErr)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Path Streaming Restrictions", func() {
+	device := fakeDevice(
+		"Device.Hosts.Host.1.",
+		"Device.Hosts.Host.2.",
+		"Device.WiFi.AccessPoint.1.",
+		"Device.WiFi.AccessPoint.2.",
+	)
+
+	// streamed streams the expansion to completion and returns the paths
+	// emitted along with those collected afterwards
+	streamed := func(exp *expander.Expander) ([]string, []string) {
+		stream := exp.Stream(context.Background(), device, 16)
+		var paths []string
+		for path := range stream.C {
+			paths = append(paths, path)
+		}
+		Expect(stream.Err()).NotTo(HaveOccurred())
+
+		collected, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		return paths, collected
+	}
+
+	It("should not emit paths in blocked subtrees", func() {
+		exp := expander.Get(expander.WithBlockedSubtrees([]string{"Device.Hosts.Host.2."}))
+		defer expander.Release(exp)
+		Expect(exp.Add("Device.Hosts.Host.*.HostName")).To(Succeed())
+
+		paths, collected := streamed(exp)
+		Expect(paths).To(Equal([]string{"Device.Hosts.Host.1.HostName"}))
+		Expect(paths).To(ConsistOf(collected))
+	})
+
+	It("should only emit paths within the allowed subtrees", func() {
+		exp := expander.Get(expander.WithAllowedSubtrees([]string{"Device.WiFi.AccessPoint.1."}))
+		defer expander.Release(exp)
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable", "Device.DeviceInfo.SerialNumber")).To(Succeed())
+
+		paths, collected := streamed(exp)
+		Expect(paths).To(Equal([]string{"Device.WiFi.AccessPoint.1.Enable"}))
+		Expect(paths).To(ConsistOf(collected))
+	})
+
+	It("should not emit paths of instances failing their filter", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.AddFiltered("Device.WiFi.AccessPoint.*.SSIDReference",
+			expander.Filter{Param: "Enable", Equals: "true"})).To(Succeed())
+		Expect(exp.RegisterValues(map[string]string{
+			"Device.WiFi.AccessPoint.1.Enable": "true",
+			"Device.WiFi.AccessPoint.2.Enable": "false",
+		})).To(Succeed())
+
+		paths, collected := streamed(exp)
+		Expect(paths).To(Equal([]string{"Device.WiFi.AccessPoint.1.SSIDReference"}))
+		Expect(paths).To(ConsistOf(collected))
+	})
+})
+
+// valueDevice answers discoveries and value queries
+type valueDevice struct {
+	expander.DiscoveryFunc
+	values map[string]string
+}
+
+func (d valueDevice) GetParameterValues(_ context.Context, names []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, name := range names {
+		if value, ok := d.values[name]; ok {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+var _ = Describe("Path Streaming Discoveries", func() {
+	device := fakeDevice(
+		"Device.Hosts.Host.1.",
+		"Device.Hosts.Host.1.IPv4Address.1.",
+		"Device.Hosts.Host.2.",
+		"Device.Hosts.Host.2.IPv4Address.1.",
+	)
+	pattern := "Device.Hosts.Host.*.IPv4Address.*.IPAddress"
+
+	// drain reads the stream until it is closed and returns the emitted paths
+	drain := func(stream *expander.PathStream) []string {
+		var paths []string
+		for path := range stream.C {
+			paths = append(paths, path)
+		}
+		return paths
+	}
+
+	It("should wait for the limiter before every discovery", func() {
+		limiter := &countingLimiter{budget: 10}
+		exp := expander.Get(expander.WithLimiter(limiter))
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		stream := exp.Stream(context.Background(), device, 4)
+		Expect(drain(stream)).To(HaveLen(2))
+		Expect(stream.Err()).NotTo(HaveOccurred())
+		Expect(limiter.waits).To(Equal(3))
+	})
+
+	It("should retry transient failures and report the attempts", func() {
+		exp := expander.Get(expander.WithRetry(2, time.Millisecond))
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		calls := 0
+		src := expander.DiscoveryFunc(func(ctx context.Context, path string) ([]string, error) {
+			if path == "Device.Hosts.Host.2.IPv4Address." {
+				calls++
+				return nil, cwmpFault(expander.FaultInternalError)
+			}
+			return device(ctx, path)
+		})
+
+		stream := exp.Stream(context.Background(), src, 4)
+		Expect(drain(stream)).To(Equal([]string{"Device.Hosts.Host.1.IPv4Address.1.IPAddress"}))
+
+		var discoveryErr *expander.DiscoveryError
+		Expect(errors.As(stream.Err(), &discoveryErr)).To(BeTrue())
+		Expect(discoveryErr.Path).To(Equal("Device.Hosts.Host.2.IPv4Address."))
+		Expect(discoveryErr.Attempts).To(Equal(3))
+		Expect(calls).To(Equal(3))
+	})
+
+	It("should prune the branch of a discovery faulting with 9005", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		src := expander.DiscoveryFunc(func(ctx context.Context, path string) ([]string, error) {
+			if path == "Device.Hosts.Host.1.IPv4Address." {
+				return nil, cwmpFault(expander.FaultInvalidParameterName)
+			}
+			return device(ctx, path)
+		})
+
+		stream := exp.Stream(context.Background(), src, 4)
+		Expect(drain(stream)).To(Equal([]string{"Device.Hosts.Host.2.IPv4Address.1.IPAddress"}))
+		Expect(stream.Err()).NotTo(HaveOccurred())
+		Expect(exp.Faults()).To(Equal(map[string]int{
+			"Device.Hosts.Host.1.IPv4Address.": expander.FaultInvalidParameterName,
+		}))
+	})
+
+	It("should read the values needed by filters from a value reader", func() {
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.AddFiltered("Device.Hosts.Host.*.IPv4Address.*.IPAddress",
+			expander.Filter{Param: "Active", Equals: "true"})).To(Succeed())
+
+		src := valueDevice{DiscoveryFunc: device, values: map[string]string{
+			"Device.Hosts.Host.1.IPv4Address.1.Active": "false",
+			"Device.Hosts.Host.2.IPv4Address.1.Active": "true",
+		}}
+		stream := exp.Stream(context.Background(), src, 4)
+		Expect(drain(stream)).To(Equal([]string{"Device.Hosts.Host.2.IPv4Address.1.IPAddress"}))
+		Expect(stream.Err()).NotTo(HaveOccurred())
+	})
+
+	It("should issue discoveries concurrently as the driver allows", func() {
+		exp := expander.Get(expander.WithDriverCapabilities(expander.DriverCapabilities{ParallelRequests: 2}))
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		var arrived sync.WaitGroup
+		arrived.Add(2)
+		src := expander.DiscoveryFunc(func(ctx context.Context, path string) ([]string, error) {
+			if strings.HasSuffix(path, ".IPv4Address.") {
+				// Both discoveries below the hosts must be in flight at once
+				arrived.Done()
+				both := make(chan struct{})
+				go func() {
+					arrived.Wait()
+					close(both)
+				}()
+				select {
+				case <-both:
+				case <-time.After(time.Second):
+					return nil, errors.New("discoveries issued one at a time")
+				}
+			}
+			return device(ctx, path)
+		})
+
+		stream := exp.Stream(context.Background(), src, 4)
+		Expect(drain(stream)).To(ConsistOf(
+			"Device.Hosts.Host.1.IPv4Address.1.IPAddress",
+			"Device.Hosts.Host.2.IPv4Address.1.IPAddress",
+		))
+		Expect(stream.Err()).NotTo(HaveOccurred())
+	})
+})
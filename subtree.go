@@ -0,0 +1,23 @@
+package expander
+
+import "strings"
+
+// WithSubtreeExpansion makes a partial path pattern, such as "Device.WiFi."
+// or "Device.WiFi.SSID.*.", stand for every parameter below the object
+// instead of the partial path itself. The subtree is discovered level by
+// level with GetParameterNames until the leaves are reached, as with a
+// trailing "**".
+func WithSubtreeExpansion() Option {
+	return func(e *Expander) {
+		e.config.subtrees = true
+	}
+}
+
+// treePath returns the path a pattern is added to the tree as
+func (e *Expander) treePath(pattern string) string {
+	pattern = normalizePattern(pattern)
+	if e.config.subtrees && strings.HasSuffix(pattern, ".") {
+		return pattern + "**"
+	}
+	return pattern
+}
@@ -0,0 +1,54 @@
+package expander_test
+
+import (
+	"context"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Subtree Expansion", func() {
+	device := nextLevelDevice(
+		"Device.WiFi.RadioNumberOfEntries",
+		"Device.WiFi.Radio.1.Enable",
+		"Device.WiFi.Radio.1.Stats.Noise",
+		"Device.WiFi.SSID.1.SSID",
+		"Device.WiFi.SSID.2.SSID",
+		"Device.WiFi.SSID.2.Enable",
+	)
+
+	It("should expand partial paths to every parameter below them", func() {
+		paths, err := expander.ExpandAll(context.Background(), []string{"Device.WiFi."}, device,
+			expander.WithSubtreeExpansion())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			"Device.WiFi.Radio.1.Enable",
+			"Device.WiFi.Radio.1.Stats.Noise",
+			"Device.WiFi.RadioNumberOfEntries",
+			"Device.WiFi.SSID.1.SSID",
+			"Device.WiFi.SSID.2.Enable",
+			"Device.WiFi.SSID.2.SSID",
+		}))
+	})
+
+	It("should expand partial paths below wildcards", func() {
+		exp := expander.Get(expander.WithSubtreeExpansion())
+		defer expander.Release(exp)
+		Expect(exp.Add("Device.WiFi.SSID.*.")).To(Succeed())
+
+		paths, err := exp.Expand(context.Background(), device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(HaveLen(3))
+
+		byPattern, err := exp.CollectByPattern()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(byPattern).To(HaveKeyWithValue("Device.WiFi.SSID.*.", HaveLen(3)))
+	})
+
+	It("should keep partial paths as they are by default", func() {
+		paths, err := expander.ExpandAll(context.Background(), []string{"Device.WiFi.SSID.*."}, device)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{"Device.WiFi.SSID.1.", "Device.WiFi.SSID.2."}))
+	})
+})
@@ -0,0 +1,93 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trailing Dot Tolerance", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should extract instances from mixed dotted and undotted names", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.Enable")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{
+			"Device.WiFi.AccessPoint",
+			"Device.WiFi.AccessPoint.1.",
+			"Device.WiFi.AccessPoint.2",
+			"Device.WiFi.AccessPoint.3.Enable",
+			"Device.WiFi.AccessPoint.1",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.2.Enable",
+			"Device.WiFi.AccessPoint.3.Enable",
+		}))
+	})
+
+	It("should validate literal indices against undotted instances", func() {
+		exp = expander.Get(expander.WithLiteralPolicy(expander.LiteralValidate))
+		Expect(exp.Add("Device.WiFi.SSID.*.Enable", "Device.WiFi.SSID.2.SSID", "Device.WiFi.SSID.5.SSID")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{"Device.WiFi.SSID.1", "Device.WiFi.SSID.2"})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.WiFi.SSID.1.Enable",
+			"Device.WiFi.SSID.2.Enable",
+			"Device.WiFi.SSID.2.SSID",
+		}))
+	})
+
+	It("should take a child listed with and without its trailing dot as an object", func() {
+		exp = expander.Get(expander.WithGlobSegments())
+		Expect(exp.Add("Device.Services.*Service.*.Enable", "Device.Services.*")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{
+			"Device.Services.VoiceService",
+			"Device.Services.VoiceService.",
+			"Device.Services.ServiceNumberOfEntries",
+		})).To(Succeed())
+		path, _ := exp.Next()
+		Expect(path).To(Equal("Device.Services.VoiceService."))
+		Expect(exp.Register([]string{"Device.Services.VoiceService.1"})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{
+			"Device.Services.ServiceNumberOfEntries",
+			"Device.Services.VoiceService.1.Enable",
+		}))
+	})
+
+	It("should accept the root object without its trailing dot", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.*.")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{"Device", "Device.1."})).To(Succeed())
+		Expect(exp.Collect()).To(Equal([]string{"Device.1."}))
+	})
+
+	It("should find writable tables reported without their trailing dot", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.RegisterInfo([]expander.ParameterInfo{
+			{Name: "Device.Hosts.Host", Writable: true},
+			{Name: "Device.Hosts.Host.1", Writable: false},
+		})).To(Succeed())
+
+		objects, err := exp.CollectCreatableObjects()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects).To(Equal([]expander.CreatableObject{{Path: "Device.Hosts.Host.", Instances: []int{1}}}))
+	})
+})
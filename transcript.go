@@ -0,0 +1,148 @@
+package expander
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// ErrNotRecorded is returned when replaying a discovery missing from a transcript
+var ErrNotRecorded = errors.New("discovery not recorded in transcript")
+
+// Transcript is a captured discovery session: the answers the device gave to
+// each discovery and the expansion the library produced at the time. It is
+// stored as JSON to replay field captures against newer library versions.
+// Provenance holds the attributions of the patterns, when recorded with
+// Recorder.Attribute.
+type Transcript struct {
+	DeviceID    string                   `json:"deviceId,omitempty"`
+	Patterns    []string                 `json:"patterns"`
+	Provenance  map[string][]Attribution `json:"provenance,omitempty"`
+	Discoveries []RecordedAnswer         `json:"discoveries"`
+	Expanded    []string                 `json:"expanded"`
+}
+
+// RecordedAnswer is the answer of a device to a single discovery: the
+// names listed, or the CWMP fault or error it failed with.
+type RecordedAnswer struct {
+	Path      string   `json:"path"`
+	Names     []string `json:"names"`
+	FaultCode int      `json:"faultCode,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// err returns the error equivalent to the recorded failure, if any
+func (a RecordedAnswer) err() error {
+	switch {
+	case a.FaultCode != 0:
+		return &FaultError{Code: a.FaultCode}
+	case a.Error != "":
+		return errors.New(a.Error)
+	default:
+		return nil
+	}
+}
+
+// Recorder is a DiscoverySource recording the answers of another source
+// into a transcript. It is safe for concurrent use.
+type Recorder struct {
+	src DiscoverySource
+
+	mu         sync.Mutex
+	transcript Transcript
+}
+
+// NewRecorder records the answers src gives for the expansion of patterns.
+func NewRecorder(src DiscoverySource, deviceID string, patterns []string) *Recorder {
+	return &Recorder{
+		src: src,
+		transcript: Transcript{
+			DeviceID: deviceID,
+			Patterns: patterns,
+		},
+	}
+}
+
+// Discover forwards the discovery to the recorded source and records its
+// answer, or the fault or error it failed with.
+func (r *Recorder) Discover(ctx context.Context, path string) ([]string, error) {
+	names, err := r.src.Discover(ctx, path)
+	answer := RecordedAnswer{Path: path, Names: names}
+	var fault interface{ FaultCode() int }
+	switch {
+	case errors.As(err, &fault):
+		answer.Names, answer.FaultCode = nil, fault.FaultCode()
+	case err != nil:
+		answer.Names, answer.Error = nil, err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transcript.Discoveries = append(r.transcript.Discoveries, answer)
+	return answer.Names, err
+}
+
+// Attribute records the attributions of the recorded patterns, as reported
+// by the Provenance of the expander the session ran on.
+func (r *Recorder) Attribute(exp *Expander) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, pattern := range r.transcript.Patterns {
+		attributions := exp.Provenance(pattern)
+		if len(attributions) == 0 {
+			continue
+		}
+		if r.transcript.Provenance == nil {
+			r.transcript.Provenance = make(map[string][]Attribution)
+		}
+		r.transcript.Provenance[pattern] = attributions
+	}
+}
+
+// Transcript returns the session recorded so far along with the expansion
+// it produced.
+func (r *Recorder) Transcript(expanded []string) Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transcript := r.transcript
+	transcript.Provenance = maps.Clone(r.transcript.Provenance)
+	transcript.Discoveries = append([]RecordedAnswer(nil), r.transcript.Discoveries...)
+	transcript.Expanded = expanded
+	return transcript
+}
+
+// Replay expands patterns, or the recorded patterns if none are given,
+// answering every discovery from the transcript. A discovery recorded more
+// than once, such as one retried, is answered in the recorded order, the
+// last answer repeating. Recorded faults are answered as a *FaultError,
+// other failures as an error with the recorded message. Discoveries the
+// transcript has no answer for fail with ErrNotRecorded.
+func (t Transcript) Replay(ctx context.Context, patterns []string, opts ...Option) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = t.Patterns
+	}
+
+	answers := make(map[string][]RecordedAnswer, len(t.Discoveries))
+	for _, answer := range t.Discoveries {
+		answers[answer.Path] = append(answers[answer.Path], answer)
+	}
+
+	var mu sync.Mutex
+	return ExpandAll(ctx, patterns, func(_ context.Context, path string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		recorded, ok := answers[path]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotRecorded, path)
+		}
+		if len(recorded) > 1 {
+			answers[path] = recorded[1:]
+		}
+		return recorded[0].Names, recorded[0].err()
+	}, opts...)
+}
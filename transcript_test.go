@@ -0,0 +1,115 @@
+package expander_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session Transcripts", func() {
+	patterns := []string{"Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress"}
+
+	record := func() expander.Transcript {
+		recorder := expander.NewRecorder(staticSource{
+			"Device.WiFi.AccessPoint.1.",
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.4.",
+		}, "CPE-0001", patterns)
+
+		exp := expander.Get()
+		defer expander.Release(exp)
+		Expect(exp.Add(patterns...)).To(Succeed())
+
+		sink := &expander.SliceSink{}
+		Expect(exp.Run(context.Background(), recorder, sink)).To(Succeed())
+		return recorder.Transcript(sink.Paths)
+	}
+
+	It("should replay a recorded session to the same expansion", func() {
+		transcript := record()
+		Expect(transcript.Discoveries).To(HaveLen(2))
+
+		data, err := json.Marshal(transcript)
+		Expect(err).NotTo(HaveOccurred())
+		var loaded expander.Transcript
+		Expect(json.Unmarshal(data, &loaded)).To(Succeed())
+
+		paths, err := loaded.Replay(context.Background(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal(transcript.Expanded))
+		Expect(paths).To(Equal([]string{"Device.WiFi.AccessPoint.1.AssociatedDevice.4.MACAddress"}))
+	})
+
+	It("should replay recorded faults and retried failures", func() {
+		failures := 0
+		recorder := expander.NewRecorder(expander.DiscoveryFunc(func(ctx context.Context, path string) ([]string, error) {
+			switch path {
+			case "Device.WiFi.AccessPoint.1.AssociatedDevice.":
+				return nil, &expander.FaultError{Code: expander.FaultInvalidParameterName}
+			case "Device.WiFi.AccessPoint.2.AssociatedDevice.":
+				if failures++; failures == 1 {
+					return nil, &expander.FaultError{Code: expander.FaultInternalError}
+				}
+			}
+			return staticSource{
+				"Device.WiFi.AccessPoint.1.",
+				"Device.WiFi.AccessPoint.2.",
+				"Device.WiFi.AccessPoint.2.AssociatedDevice.3.",
+			}.Discover(ctx, path)
+		}), "CPE-0002", patterns)
+		retry := expander.WithRetry(1, time.Millisecond)
+
+		paths, err := expander.ExpandAll(context.Background(), patterns, recorder.Discover, retry)
+		Expect(err).NotTo(HaveOccurred())
+		transcript := recorder.Transcript(paths)
+		Expect(transcript.Discoveries).To(HaveLen(4))
+
+		replayed, err := transcript.Replay(context.Background(), nil, retry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replayed).To(Equal([]string{"Device.WiFi.AccessPoint.2.AssociatedDevice.3.MACAddress"}))
+	})
+
+	It("should replay recorded errors", func() {
+		recorder := expander.NewRecorder(expander.DiscoveryFunc(func(context.Context, string) ([]string, error) {
+			return nil, errors.New("session closed")
+		}), "CPE-0003", patterns)
+		_, err := expander.ExpandAll(context.Background(), patterns, recorder.Discover)
+		Expect(err).To(MatchError(ContainSubstring("session closed")))
+
+		_, err = recorder.Transcript(nil).Replay(context.Background(), nil)
+		Expect(err).To(MatchError(ContainSubstring("session closed")))
+		Expect(err).NotTo(MatchError(expander.ErrNotRecorded))
+	})
+
+	It("should record who added the patterns", func() {
+		recorder := expander.NewRecorder(staticSource{"Device.WiFi.AccessPoint.1."}, "CPE-0004", patterns)
+		exp := expander.Get(expander.WithCallerCapture())
+		defer expander.Release(exp)
+		Expect(exp.AddLabeled("wifi-audit", patterns...)).To(Succeed())
+
+		sink := &expander.SliceSink{}
+		Expect(exp.Run(context.Background(), recorder, sink)).To(Succeed())
+		recorder.Attribute(exp)
+
+		data, err := json.Marshal(recorder.Transcript(sink.Paths))
+		Expect(err).NotTo(HaveOccurred())
+		var loaded expander.Transcript
+		Expect(json.Unmarshal(data, &loaded)).To(Succeed())
+
+		Expect(loaded.Provenance).To(HaveKey(patterns[0]))
+		attributions := loaded.Provenance[patterns[0]]
+		Expect(attributions).To(HaveLen(1))
+		Expect(attributions[0].Label).To(Equal("wifi-audit"))
+		Expect(attributions[0].CallSite).To(ContainSubstring("transcript_test.go:"))
+	})
+
+	It("should fail on discoveries missing from the transcript", func() {
+		transcript := record()
+		_, err := transcript.Replay(context.Background(), []string{"Device.Hosts.Host.*.HostName"})
+		Expect(err).To(MatchError(expander.ErrNotRecorded))
+	})
+})
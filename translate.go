@@ -0,0 +1,142 @@
+package expander
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoTranslation is returned when no translation maps a path to the
+// requested data model
+var ErrNoTranslation = errors.New("no translation for path")
+
+// Translation maps a path of the InternetGatewayDevice data model (TR-098)
+// to its equivalent in the Device:2 data model (TR-181) and back. Paths
+// ending with a dot map every path below them; others map a single
+// parameter. Both sides must have as many "*" segments: the instances
+// matched by the wildcards of one side are substituted for the wildcards of
+// the other, in order.
+type Translation struct {
+	IGD    string
+	Device string
+}
+
+// lanDevice, wlan and wan are the TR-098 objects most translations start
+// from; CPEs have a single LAN and WAN device in practice
+const (
+	lanDevice = "InternetGatewayDevice.LANDevice.1."
+	wlan      = lanDevice + "WLANConfiguration.*."
+	wan       = "InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1."
+)
+
+// DefaultTranslations covers the objects and parameters commonly read from
+// both data models. More specific translations take precedence over the
+// objects containing them.
+var DefaultTranslations = []Translation{
+	{IGD: "InternetGatewayDevice.DeviceInfo.", Device: "Device.DeviceInfo."},
+	{IGD: "InternetGatewayDevice.ManagementServer.", Device: "Device.ManagementServer."},
+	{IGD: "InternetGatewayDevice.Time.", Device: "Device.Time."},
+	{IGD: "InternetGatewayDevice.UserInterface.", Device: "Device.UserInterface."},
+	{IGD: "InternetGatewayDevice.Services.VoiceService.*.", Device: "Device.Services.VoiceService.*."},
+	{IGD: "InternetGatewayDevice.IPPingDiagnostics.", Device: "Device.IP.Diagnostics.IPPing."},
+	{IGD: "InternetGatewayDevice.TraceRouteDiagnostics.", Device: "Device.IP.Diagnostics.TraceRoute."},
+	{IGD: "InternetGatewayDevice.DownloadDiagnostics.", Device: "Device.IP.Diagnostics.DownloadDiagnostics."},
+	{IGD: "InternetGatewayDevice.UploadDiagnostics.", Device: "Device.IP.Diagnostics.UploadDiagnostics."},
+	{IGD: "InternetGatewayDevice.Layer3Forwarding.Forwarding.*.", Device: "Device.Routing.Router.1.IPv4Forwarding.*."},
+
+	{IGD: lanDevice + "Hosts.Host.*.", Device: "Device.Hosts.Host.*."},
+	{IGD: lanDevice + "Hosts.HostNumberOfEntries", Device: "Device.Hosts.HostNumberOfEntries"},
+	{IGD: lanDevice + "LANEthernetInterfaceConfig.*.", Device: "Device.Ethernet.Interface.*."},
+	{IGD: lanDevice + "LANHostConfigManagement.DHCPServerEnable", Device: "Device.DHCPv4.Server.Enable"},
+
+	{IGD: wlan, Device: "Device.WiFi.SSID.*."},
+	{IGD: wlan + "Channel", Device: "Device.WiFi.Radio.*.Channel"},
+	{IGD: wlan + "AutoChannelEnable", Device: "Device.WiFi.Radio.*.AutoChannelEnable"},
+	{IGD: wlan + "SSIDAdvertisementEnabled", Device: "Device.WiFi.AccessPoint.*.SSIDAdvertisementEnabled"},
+	{IGD: wlan + "KeyPassphrase", Device: "Device.WiFi.AccessPoint.*.Security.KeyPassphrase"},
+	{IGD: wlan + "TotalAssociations", Device: "Device.WiFi.AccessPoint.*.AssociatedDeviceNumberOfEntries"},
+	{IGD: wlan + "AssociatedDevice.*.", Device: "Device.WiFi.AccessPoint.*.AssociatedDevice.*."},
+
+	{IGD: wan + "WANIPConnection.*.ExternalIPAddress", Device: "Device.IP.Interface.*.IPv4Address.1.IPAddress"},
+	{IGD: wan + "WANIPConnection.*.SubnetMask", Device: "Device.IP.Interface.*.IPv4Address.1.SubnetMask"},
+	{IGD: wan + "WANPPPConnection.*.", Device: "Device.PPP.Interface.*."},
+}
+
+// Translate maps a path or pattern to the data model rooted at targetRoot,
+// RootIGD or RootDevice, with DefaultTranslations. Wildcards, named
+// wildcards, index selectors and search expressions are carried over like
+// instance numbers. Paths already under targetRoot are returned unchanged;
+// paths no translation covers fail with ErrNoTranslation.
+func Translate(path, targetRoot string) (string, error) {
+	return TranslateWith(DefaultTranslations, path, targetRoot)
+}
+
+// TranslateWith is Translate with a custom translation table.
+func TranslateWith(translations []Translation, path, targetRoot string) (string, error) {
+	if !strings.HasSuffix(targetRoot, ".") {
+		targetRoot += "."
+	}
+	if targetRoot != RootIGD && targetRoot != RootDevice {
+		return "", fmt.Errorf("%w: unknown data model root %s", ErrNoTranslation, targetRoot)
+	}
+	if IsUnderRoot(path, targetRoot) {
+		return path, nil
+	}
+
+	segments := splitSegments(path)
+	best, bestLen := "", -1
+	var bestBindings []string
+	for _, t := range translations {
+		from, to := t.IGD, t.Device
+		if targetRoot == RootIGD {
+			from, to = to, from
+		}
+		bindings, rest, ok := matchTranslation(splitSegments(from), segments)
+		if !ok {
+			continue
+		}
+		// Parameter translations outrank the object holding them
+		length := len(splitSegments(from))
+		if !strings.HasSuffix(from, ".") {
+			length++
+		}
+		if length > bestLen {
+			best, bestLen, bestBindings = to+rest, length, bindings
+		}
+	}
+	if bestLen == -1 {
+		return "", fmt.Errorf("%w: %s to %s", ErrNoTranslation, path, targetRoot)
+	}
+
+	translated := splitSegments(best)
+	for i, segment := range translated {
+		if segment == "*" && len(bestBindings) > 0 {
+			translated[i], bestBindings = bestBindings[0], bestBindings[1:]
+		}
+	}
+	return strings.Join(translated, "."), nil
+}
+
+// matchTranslation matches the segments of a path against one side of a
+// translation. It returns the path segments bound to its wildcards and, for
+// object translations, the rest of the path below the object.
+func matchTranslation(from, path []string) ([]string, string, bool) {
+	object := from[len(from)-1] == ""
+	if object {
+		from = from[:len(from)-1]
+	}
+	if len(path) < len(from) || (!object && len(path) != len(from)) {
+		return nil, "", false
+	}
+
+	var bindings []string
+	for i, segment := range from {
+		switch {
+		case segment == "*":
+			bindings = append(bindings, path[i])
+		case segment != path[i]:
+			return nil, "", false
+		}
+	}
+	return bindings, strings.Join(path[len(from):], "."), true
+}
@@ -0,0 +1,45 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Translate", func() {
+	DescribeTable("should translate between data models",
+		func(path, root, expected string) {
+			Expect(expander.Translate(path, root)).To(Equal(expected))
+		},
+		Entry("object to TR-181", "InternetGatewayDevice.DeviceInfo.SoftwareVersion", expander.RootDevice,
+			"Device.DeviceInfo.SoftwareVersion"),
+		Entry("object to TR-098", "Device.DeviceInfo.", "InternetGatewayDevice",
+			"InternetGatewayDevice.DeviceInfo."),
+		Entry("wildcards to TR-181", "InternetGatewayDevice.LANDevice.1.WLANConfiguration.*.SSID", expander.RootDevice,
+			"Device.WiFi.SSID.*.SSID"),
+		Entry("parameters before their object", "InternetGatewayDevice.LANDevice.1.WLANConfiguration.{ssid}.Channel", expander.RootDevice,
+			"Device.WiFi.Radio.{ssid}.Channel"),
+		Entry("nested wildcards to TR-098", "Device.WiFi.AccessPoint.2.AssociatedDevice.*.MACAddress", expander.RootIGD,
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.2.AssociatedDevice.*.MACAddress"),
+		Entry("wildcards below the translation", "Device.Hosts.Host.*.IPv4Address.*.IPAddress", expander.RootIGD,
+			"InternetGatewayDevice.LANDevice.1.Hosts.Host.*.IPv4Address.*.IPAddress"),
+		Entry("search expressions", `Device.IP.Interface.[Name=="wan.1"].IPv4Address.1.IPAddress`, expander.RootIGD,
+			`InternetGatewayDevice.WANDevice.1.WANConnectionDevice.1.WANIPConnection.[Name=="wan.1"].ExternalIPAddress`),
+		Entry("paths already in the data model", "Device.WiFi.SSID.*.SSID", expander.RootDevice,
+			"Device.WiFi.SSID.*.SSID"),
+	)
+
+	It("should fail on paths without a translation", func() {
+		_, err := expander.Translate("Device.Bridging.Bridge.*.Port.*.Enable", expander.RootIGD)
+		Expect(err).To(MatchError(expander.ErrNoTranslation))
+
+		_, err = expander.Translate("Device.DeviceInfo.", "Vendor.")
+		Expect(err).To(MatchError(expander.ErrNoTranslation))
+	})
+
+	It("should translate with a custom table", func() {
+		table := []expander.Translation{{IGD: "InternetGatewayDevice.X_ACME_Mesh.Node.*.", Device: "Device.X_ACME_Mesh.Node.*."}}
+		Expect(expander.TranslateWith(table, "Device.X_ACME_Mesh.Node.3.Uptime", expander.RootIGD)).
+			To(Equal("InternetGatewayDevice.X_ACME_Mesh.Node.3.Uptime"))
+	})
+})
@@ -0,0 +1,54 @@
+package expander_test
+
+import (
+	"context"
+
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Traversal Order", func() {
+	const pattern = "Device.X.*.Y.*.Z.*.Enable"
+
+	// descend registers two instances of Device.X. and one instance below
+	// the first of them, then returns that branch and the next discovery
+	descend := func(opts ...expander.Option) (string, string) {
+		exp := expander.Get(opts...)
+		defer expander.Release(exp)
+		Expect(exp.Add(pattern)).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{"Device.X.1.", "Device.X.2."})).To(Succeed())
+		branch, _ := exp.Next()
+		Expect(exp.Register([]string{branch + "1."})).To(Succeed())
+		next, _ := exp.Next()
+		return branch, next
+	}
+
+	It("should finish a level before descending by default", func() {
+		branch, next := descend()
+		Expect(branch).To(Equal("Device.X.1.Y."))
+		Expect(next).To(Equal("Device.X.2.Y."))
+	})
+
+	It("should finish a branch first when depth-first", func() {
+		branch, next := descend(expander.WithTraversalOrder(expander.DepthFirst))
+		Expect(next).To(Equal(branch + "1.Z."))
+	})
+
+	It("should expand the same paths in either order", func() {
+		expand := func(opts ...expander.Option) []string {
+			exp := expander.Get(opts...)
+			defer expander.Release(exp)
+			Expect(exp.Add(pattern)).To(Succeed())
+
+			paths, err := exp.Expand(context.Background(), func(_ context.Context, path string) ([]string, error) {
+				return []string{path + "1.", path + "2."}, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return paths
+		}
+		Expect(expand(expander.WithTraversalOrder(expander.DepthFirst))).To(Equal(expand()))
+	})
+})
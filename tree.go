@@ -1,12 +1,18 @@
 package expander
 
 import (
-	"strconv"
+	"slices"
 	"strings"
 )
 
 // addPath adds a path to the tree structure
 func (t *pathTree) addPath(path string) error {
+	return t.addPathAs(path, path)
+}
+
+// addPathAs adds a path to the tree structure on behalf of a pattern written
+// differently, such as a partial path expanded as a subtree
+func (t *pathTree) addPathAs(path, pattern string) error {
 	if t.root == nil {
 		t.root = &pathNode{
 			children: make(map[string]*pathNode),
@@ -14,6 +20,11 @@ func (t *pathTree) addPath(path string) error {
 	}
 
 	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if _, err := parseIndexSelector(segment); err != nil {
+			return err
+		}
+	}
 	current := t.root
 
 	for i, segment := range segments {
@@ -23,18 +34,28 @@ func (t *pathTree) addPath(path string) error {
 
 		child, exists := current.children[segment]
 		if !exists {
+			selector, _ := parseIndexSelector(segment)
 			child = &pathNode{
-				segment:    segment,
-				children:   make(map[string]*pathNode),
-				isWildcard: segment == "*",
-				isLeaf:     i == len(segments)-1,
+				segment:     segment,
+				children:    make(map[string]*pathNode),
+				isWildcard:  segment == "*",
+				isRecursive: segment == "**",
+				isGlob:      isGlobSegment(segment),
+				isLeaf:      i == len(segments)-1,
+				selector:    selector,
 			}
 			current.children[segment] = child
+			current.order = append(current.order, segment)
+		}
+
+		if child.isRecursive || (child.isWildcard && i == len(segments)-1) {
+			t.matchesNames = true
 		}
 
 		// Mark as leaf if this is the last segment
 		if i == len(segments)-1 {
 			child.isLeaf = true
+			child.pattern = pattern
 		}
 
 		current = child
@@ -43,241 +64,297 @@ func (t *pathTree) addPath(path string) error {
 	return nil
 }
 
-// getDiscoveryPaths returns all discovery paths needed for wildcards in the tree
-func (t *pathTree) getDiscoveryPaths() []string {
-	if t.root == nil {
-		return nil
-	}
+// instanceLookup returns the instance segments discovered for a discovery path,
+// or false if the discovery path has not been resolved yet
+type instanceLookup func(discoveryPath string) ([]string, bool)
+
+// treeVisitor receives the results of a tree walk
+type treeVisitor struct {
+	// discover is called for every reachable discovery path that is not resolved yet
+	discover func(discoveryPath string)
+
+	// leaf is called for every fully expanded path along with its pattern
+	leaf func(path, pattern string)
+
+	// validateLiterals only follows literal indices next to a wildcard when
+	// discovery lists them
+	validateLiterals bool
 
-	var paths []string
-	t.collectDiscoveryPaths(t.root, "", &paths)
-	return paths
+	// within restricts the walk to the branches leading to or lying below a
+	// discovery path, when set
+	within string
 }
 
-// collectDiscoveryPaths recursively collects discovery paths for wildcards
-func (t *pathTree) collectDiscoveryPaths(node *pathNode, currentPath string, paths *[]string) {
-	// Build the current path
-	if node.segment != "" {
-		if currentPath != "" {
-			currentPath += "."
-		}
-		currentPath += node.segment
+// reaches reports whether the walk continues through the child segment of
+// parentPath, i.e. the child leads to or lies below the discovery path the
+// walk is restricted to. It compares without building the child path, as it
+// is called for every instance of a table.
+func (v treeVisitor) reaches(parentPath, segment string) bool {
+	if v.within == "" {
+		return true
 	}
 
-	// If this is a wildcard, we need to discover at this level
-	if node.isWildcard {
-		// The discovery path is everything before the wildcard, with a trailing dot
-		discoveryPath := ""
-		segments := strings.Split(currentPath, ".")
-		for i := 0; i < len(segments)-1; i++ {
-			if i > 0 {
-				discoveryPath += "."
-			}
-			discoveryPath += segments[i]
-		}
-		if discoveryPath != "" {
-			discoveryPath += "."
-		}
-		// Only add if not already present
-		found := false
-		for _, p := range *paths {
-			if p == discoveryPath {
-				found = true
-				break
-			}
+	// Either the child path with a trailing dot starts with within or the
+	// other way round, so the shorter one is a prefix of the longer one
+	rest := v.within[:min(len(v.within), len(parentPath)+len(segment)+1)]
+	for _, part := range []string{parentPath, segment} {
+		if len(rest) <= len(part) {
+			return part[:len(rest)] == rest
 		}
-		if !found {
-			*paths = append(*paths, discoveryPath)
+		if rest[:len(part)] != part {
+			return false
 		}
-		// Don't recurse further - we need to resolve this wildcard first
-		return
+		rest = rest[len(part):]
 	}
+	return rest == "" || rest == "."
+}
 
-	// Recurse to children
-	for _, child := range node.children {
-		t.collectDiscoveryPaths(child, currentPath, paths)
+// below returns the segment following discoveryPath in the discovery path
+// the walk is restricted to, if that lies strictly below it
+func (v treeVisitor) below(discoveryPath string) (string, bool) {
+	rest, ok := strings.CutPrefix(v.within, discoveryPath)
+	if !ok || rest == "" {
+		return "", false
 	}
+	segment, _, _ := strings.Cut(rest, ".")
+	return segment, true
 }
 
-// getNextLevelPaths generates discovery paths for the next wildcard level
-// based on discovered indices at the current level
-func (t *pathTree) getNextLevelPaths(discoveryPath string, indices []int) []string {
-	if len(indices) == 0 {
-		return nil
-	}
+// treeSource supplies the discoveries resolved so far to a tree walk
+type treeSource struct {
+	// instances returns the instances substituted for wildcards
+	instances instanceLookup
 
-	var nextPaths []string
-	pathWithoutDot := strings.TrimSuffix(discoveryPath, ".")
+	// instance reports whether a single instance is among them, and whether
+	// the discovery path is resolved, as instances would without listing
+	// them all; nil looks the instance up in instances
+	instance func(discoveryPath, segment string) (listed, resolved bool)
 
-	// For each index, build the expanded path and find next wildcards
-	for _, idx := range indices {
-		expandedPath := pathWithoutDot + "." + strconv.Itoa(idx)
+	// names returns the child names matched by glob segments
+	names instanceLookup
 
-		// Find the next wildcard level from this expanded path
-		nextWildcard := t.findNextWildcard(expandedPath)
-		if nextWildcard != "" {
-			// Each index gets its own discovery path
-			nextPaths = append(nextPaths, nextWildcard)
-		}
+	// literal rewrites a literal segment below parentPath, e.g. to resolve
+	// an alias to its instance number; nil keeps literals as they are
+	literal func(parentPath, segment string) string
+}
+
+// walk traverses the tree, substituting resolved instances for wildcards and
+// matching child names for glob segments. Branches below unresolved
+// wildcards and glob segments are reported through visit.discover and not
+// descended into. Children are visited in the order their patterns were
+// added, instances in ascending order.
+func (t *pathTree) walk(source treeSource, visit treeVisitor) {
+	if t.root == nil {
+		return
 	}
 
-	return nextPaths
+	t.walkChildren(t.root, "", source, visit)
 }
 
-// findNextWildcard finds the next discovery path after the given expanded path
-func (t *pathTree) findNextWildcard(expandedPath string) string {
-	// We need to traverse the tree following the expanded path and find the next wildcard
-	segments := strings.Split(expandedPath, ".")
-	current := t.root
-
-	// First, navigate to where we are in the tree
-	// We need to match indices with wildcards
-	for _, segment := range segments {
-		if current.children == nil {
-			return ""
-		}
+// walkChildren walks the children of a node that expanded to currentPath
+func (t *pathTree) walkChildren(node *pathNode, currentPath string, source treeSource, visit treeVisitor) {
+	_, hasWildcard := node.children["*"]
 
-		found := false
-		// Try exact match first
-		if child, exists := current.children[segment]; exists {
-			current = child
-			found = true
-		} else {
-			// Check if this is a number that should match a wildcard
-			if _, err := strconv.Atoi(segment); err == nil {
-				if wildcardChild, exists := current.children["*"]; exists {
-					current = wildcardChild
-					found = true
-				}
-			}
+	for _, segment := range node.order {
+		child := node.children[segment]
+		if hasWildcard && visit.validateLiterals && !child.isWildcard && isInstanceSegment(segment) &&
+			!literalDiscovered(segment, currentPath, source.instances) {
+			continue
 		}
+		t.walkNode(child, currentPath, source, visit)
+	}
+}
 
-		if !found {
-			return ""
+// walkInstance walks the children of a wildcard node for the single instance
+// a restricted walk leads through
+func (t *pathTree) walkInstance(node *pathNode, discoveryPath, segment string, source treeSource, visit treeVisitor) {
+	listed, resolved := source.instance(discoveryPath, segment)
+	if !resolved {
+		if visit.discover != nil {
+			visit.discover(discoveryPath)
 		}
+		return
+	}
+	if !listed {
+		return
 	}
 
-	// Now look for the next wildcard in the subtree
-	// Pass the expanded path so it includes the actual indices
-	return t.findNextWildcardFrom(current, expandedPath)
+	if node.isLeaf && visit.leaf != nil {
+		visit.leaf(discoveryPath+segment+".", node.pattern)
+	}
+	t.walkChildren(node, discoveryPath+segment, source, visit)
 }
 
-// findNextWildcardFrom finds the next wildcard path from a given node
-func (t *pathTree) findNextWildcardFrom(node *pathNode, basePath string) string {
-	// Look through children to find the path to the next wildcard
-	for segment, child := range node.children {
-		// Skip wildcard at this level - we're looking for concrete paths
-		if segment == "*" {
-			continue
+// literalDiscovered reports whether a literal segment next to a wildcard was
+// listed by the discovery of the wildcard. Until the discovery is resolved the
+// literal is withheld; the wildcard sibling requests the discovery.
+func literalDiscovered(segment, parentPath string, lookup instanceLookup) bool {
+	instances, resolved := lookup(parentPath + ".")
+	if !resolved {
+		return false
+	}
+	for _, instance := range instances {
+		if instance == segment {
+			return true
 		}
+	}
+	return false
+}
 
-		// This is a concrete segment (like "WLANConfiguration")
-		// Build the path including this segment
-		nextPath := basePath + "." + segment
+// walkNode recursively walks a node whose parent expanded to currentPath
+func (t *pathTree) walkNode(node *pathNode, currentPath string, source treeSource, visit treeVisitor) {
+	// Handle wildcard nodes
+	if node.isWildcard {
+		// The discovery path is the parent path with a trailing dot
+		discoveryPath := currentPath + "."
 
-		// Check if this child has a wildcard child
-		if _, hasWildcard := child.children["*"]; hasWildcard {
-			// Found the next wildcard level!
-			// Return the discovery path for this level
-			return nextPath + "."
+		// A restricted walk only follows the instance leading to its path
+		if segment, ok := visit.below(discoveryPath); ok && source.instance != nil {
+			t.walkInstance(node, discoveryPath, segment, source, visit)
+			return
 		}
 
-		// If no immediate wildcard, search deeper
-		if !child.isLeaf {
-			result := t.findNextWildcardFrom(child, nextPath)
-			if result != "" {
-				return result
+		instances, resolved := source.instances(discoveryPath)
+		if !resolved {
+			if visit.discover != nil {
+				visit.discover(discoveryPath)
 			}
+			return
 		}
-	}
-
-	// Check if there's a wildcard at this immediate level
-	if _, exists := node.children["*"]; exists {
-		// This means we have a wildcard right here
-		// This shouldn't happen if we properly expanded the previous level
-		return basePath + "."
-	}
 
-	return ""
-}
+		// A trailing wildcard stands for the instances of a table, reported
+		// as object paths, and for the parameters of an object; parameter
+		// names never start with a digit, unlike instances
+		if node.isLeaf && visit.leaf != nil {
+			for _, instance := range instances {
+				visit.leaf(discoveryPath+instance+".", node.pattern)
+			}
+			names, _ := source.names(discoveryPath)
+			for _, name := range names {
+				if !strings.HasSuffix(name, ".") && !isInstanceSegment(name) {
+					visit.leaf(discoveryPath+name, node.pattern)
+				}
+			}
+		}
 
-// generateExpandedPaths generates all fully expanded paths using the cache
-func (t *pathTree) generateExpandedPaths(cache map[string][]int) []string {
-	if t.root == nil {
-		return nil
+		// Continue with children for each instance
+		for _, instance := range instances {
+			if visit.reaches(discoveryPath, instance) {
+				t.walkChildren(node, discoveryPath+instance, source, visit)
+			}
+		}
+		return
 	}
 
-	var paths []string
-	t.expandPaths(t.root, "", cache, &paths)
-	return paths
-}
+	// Index selectors stand for the selected instances. A table that is
+	// discovered anyway, or must be validated, narrows them to the instances
+	// actually present; otherwise they are addressed without discovery.
+	if node.selector != nil {
+		discoveryPath := currentPath + "."
+
+		instances, resolved := source.instances(discoveryPath)
+		if !resolved {
+			literal, ok := node.selector.enumerate()
+			if !ok || visit.validateLiterals {
+				if visit.discover != nil {
+					visit.discover(discoveryPath)
+				}
+				return
+			}
+			instances = literal
+		}
 
-// expandPaths recursively expands paths in the tree using cached indices
-func (t *pathTree) expandPaths(node *pathNode, currentPath string, cache map[string][]int, result *[]string) {
-	// Handle the root node
-	if node.segment == "" && node == t.root {
-		// Start expansion from children
-		for _, child := range node.children {
-			t.expandPaths(child, "", cache, result)
+		for _, instance := range instances {
+			if node.selector.matches(instance) && visit.reaches(discoveryPath, instance) {
+				t.walkChildren(node, discoveryPath+instance, source, visit)
+			}
 		}
 		return
 	}
 
-	// Handle wildcard nodes
-	if node.isWildcard {
-		// Get the discovery path (parent path with trailing dot)
-		discoveryPath := currentPath
-		if currentPath != "" {
-			discoveryPath += "."
-		}
+	// Glob nodes stand for every discovered child name they match
+	if node.isGlob {
+		discoveryPath := currentPath + "."
 
-		// Look up indices in cache
-		indices, exists := cache[discoveryPath]
-		if !exists || len(indices) == 0 {
-			// No indices found, can't expand this branch
+		names, resolved := source.names(discoveryPath)
+		if !resolved {
+			if visit.discover != nil {
+				visit.discover(discoveryPath)
+			}
 			return
 		}
 
-		// Expand for each index
-		for _, idx := range indices {
-			indexPath := currentPath
-			if indexPath != "" {
-				indexPath += "."
-			}
-			indexPath += strconv.Itoa(idx)
-
-			// Continue with children
-			for _, child := range node.children {
-				t.expandPaths(child, indexPath, cache, result)
+		for _, name := range names {
+			name = strings.TrimSuffix(name, ".")
+			if matchGlob(node.segment, name) && visit.reaches(discoveryPath, name) {
+				t.enterNode(node, discoveryPath+name, source, visit)
 			}
 		}
 		return
 	}
 
+	if node.isRecursive {
+		t.walkRecursive(node, currentPath, source, visit)
+		return
+	}
+
 	// Handle regular nodes
+	segment := node.segment
+	if source.literal != nil {
+		segment = source.literal(currentPath, segment)
+	}
 	if currentPath != "" {
 		currentPath += "."
 	}
-	currentPath += node.segment
-
-	// If this is a leaf, add to results
-	if node.isLeaf {
-		*result = append(*result, currentPath)
+	if !visit.reaches(currentPath, segment) {
 		return
 	}
+	t.enterNode(node, currentPath+segment, source, visit)
+}
 
-	// Continue with children
-	for _, child := range node.children {
-		t.expandPaths(child, currentPath, cache, result)
+// enterNode reports a node expanded to path if it is a leaf and walks its children
+func (t *pathTree) enterNode(node *pathNode, path string, source treeSource, visit treeVisitor) {
+	// If this is a leaf, report it; longer patterns may still continue below
+	if node.isLeaf && visit.leaf != nil {
+		visit.leaf(path, node.pattern)
 	}
+
+	t.walkChildren(node, path, source, visit)
 }
 
-// contains checks if a string slice contains a value
-func contains(slice []string, value string) bool {
-	for _, v := range slice {
-		if v == value {
-			return true
+// walkRecursive walks a "**" node standing for currentPath and for every
+// object discovered below it. Segments following "**" are matched at each of
+// these levels; literal ones only where discovery lists them. A trailing "**"
+// stands for every parameter of the subtree.
+func (t *pathTree) walkRecursive(node *pathNode, currentPath string, source treeSource, visit treeVisitor) {
+	discoveryPath := currentPath + "."
+
+	names, resolved := source.names(discoveryPath)
+	if !resolved {
+		if visit.discover != nil {
+			visit.discover(discoveryPath)
+		}
+		return
+	}
+
+	for _, name := range names {
+		if object, isObject := strings.CutSuffix(name, "."); isObject {
+			if visit.reaches(discoveryPath, object) {
+				t.walkRecursive(node, discoveryPath+object, source, visit)
+			}
+		} else if node.isLeaf && visit.leaf != nil {
+			visit.leaf(discoveryPath+name, node.pattern)
+		}
+	}
+
+	for _, segment := range node.order {
+		child := node.children[segment]
+		if child.isWildcard || child.isRecursive || child.isGlob || child.selector != nil {
+			t.walkNode(child, currentPath, source, visit)
+			continue
+		}
+		if visit.reaches(discoveryPath, segment) && (slices.Contains(names, segment) || slices.Contains(names, segment+".")) {
+			t.enterNode(child, discoveryPath+segment, source, visit)
 		}
 	}
-	return false
 }
@@ -0,0 +1,30 @@
+package expander
+
+// CommonUnreadableParameters lists parameters that commonly fault when read
+// through GetParameterValues, for use with WithUnreadable.
+var CommonUnreadableParameters = []string{
+	"Device.ManagementServer.Password",
+	"Device.ManagementServer.ConnectionRequestPassword",
+	"Device.WiFi.AccessPoint.*.Security.KeyPassphrase",
+	"Device.WiFi.AccessPoint.*.Security.PreSharedKey",
+	"Device.WiFi.AccessPoint.*.Security.WEPKey",
+	"Device.Users.User.*.Password",
+	"InternetGatewayDevice.ManagementServer.Password",
+	"InternetGatewayDevice.ManagementServer.ConnectionRequestPassword",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.KeyPassphrase",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.PreSharedKey.",
+	"InternetGatewayDevice.LANDevice.*.WLANConfiguration.*.WEPKey.",
+}
+
+// isUnreadable reports whether a path is marked unreadable and must be left out
+func (e *Expander) isUnreadable(path string) bool {
+	if e.config.includeUnreadable {
+		return false
+	}
+	for _, pattern := range e.config.unreadable {
+		if _, ok := matchPattern(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Unreadable Subtrees", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	expand := func() []string {
+		err := exp.Add(
+			"Device.WiFi.AccessPoint.*.Enable",
+			"Device.WiFi.AccessPoint.*.Security.KeyPassphrase",
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.*.PreSharedKey.*.PreSharedKey",
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		for {
+			path, hasMore := exp.Next()
+			if !hasMore {
+				break
+			}
+			Expect(exp.Register([]string{path + "1"})).To(Succeed())
+		}
+
+		paths, err := exp.Collect()
+		Expect(err).NotTo(HaveOccurred())
+		return paths
+	}
+
+	It("should exclude unreadable paths and skip their discoveries", func() {
+		exp = expander.Get(expander.WithUnreadable(expander.CommonUnreadableParameters...))
+		Expect(expand()).To(ConsistOf("Device.WiFi.AccessPoint.1.Enable"))
+		Expect(exp.Summary().Discoveries).To(Equal(2))
+	})
+
+	It("should include unreadable paths when asked to", func() {
+		exp = expander.Get(
+			expander.WithUnreadable(expander.CommonUnreadableParameters...),
+			expander.WithIncludeUnreadable(),
+		)
+		Expect(expand()).To(ConsistOf(
+			"Device.WiFi.AccessPoint.1.Enable",
+			"Device.WiFi.AccessPoint.1.Security.KeyPassphrase",
+			"InternetGatewayDevice.LANDevice.1.WLANConfiguration.1.PreSharedKey.1.PreSharedKey",
+		))
+	})
+})
@@ -0,0 +1,161 @@
+package expander
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// searchOperators are the comparison operators of USP search expressions,
+// two-character ones first so "<=" is not taken for "<"
+var searchOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// splitSegments splits a pattern into segments, keeping the dots inside USP
+// search expressions such as [SSIDReference=="Device.WiFi.SSID.1."] and
+// inside quoted values
+func splitSegments(pattern string) []string {
+	var segments []string
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case c == '.' && depth == 0:
+			segments = append(segments, pattern[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, pattern[start:])
+}
+
+// isSearchSegment reports whether a segment is a USP search expression
+func isSearchSegment(segment string) bool {
+	if len(segment) < 2 || segment[0] != '[' || segment[len(segment)-1] != ']' {
+		return false
+	}
+	for _, op := range searchOperators {
+		if strings.Contains(segment, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSearch parses the expressions of a USP search segment such as
+// [Enable==true&&Stats.Noise<-80], joined with "&&", as conditions on the
+// instances bound at depth
+func parseSearch(segment string, depth int) ([]condition, error) {
+	var conditions []condition
+	for _, expr := range splitClauses(segment[1 : len(segment)-1]) {
+		c, err := parseExpression(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: search expression %s: %v", ErrInvalidPath, segment, err)
+		}
+		c.depth = depth
+		conditions = append(conditions, c)
+	}
+	return conditions, nil
+}
+
+// splitClauses splits a search expression on the "&&" outside quoted values
+func splitClauses(expr string) []string {
+	var clauses []string
+	quoted, start := false, 0
+	for i := 0; i < len(expr); i++ {
+		if expr[i] == '"' {
+			quoted = !quoted
+		} else if !quoted && strings.HasPrefix(expr[i:], "&&") {
+			clauses = append(clauses, expr[start:i])
+			start = i + 2
+			i++
+		}
+	}
+	return append(clauses, expr[start:])
+}
+
+// parseExpression parses a single comparison such as SSIDReference=="x".
+// The first operator outside quotes splits the parameter from the value.
+func parseExpression(expr string) (condition, error) {
+	at, op := -1, ""
+	quoted := false
+	for i := 0; i < len(expr) && at == -1; i++ {
+		if expr[i] == '"' {
+			quoted = !quoted
+			continue
+		}
+		for _, candidate := range searchOperators {
+			if !quoted && strings.HasPrefix(expr[i:], candidate) {
+				at, op = i, candidate
+				break
+			}
+		}
+	}
+	if at == -1 {
+		return condition{}, fmt.Errorf("no comparison in %q", expr)
+	}
+
+	param, value := strings.TrimSpace(expr[:at]), strings.TrimSpace(expr[at+len(op):])
+	if param == "" || value == "" || strings.ContainsAny(param, `"[]*{}`) {
+		return condition{}, fmt.Errorf("malformed comparison %q", expr)
+	}
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return condition{}, fmt.Errorf("malformed value %s", value)
+		}
+		value = unquoted
+	}
+	return condition{param: param, op: op, value: value}, nil
+}
+
+// addSearches records the conditions of the search expressions of a pattern
+func (e *Expander) addSearches(pattern string) error {
+	conditions, err := searchConditions(pattern)
+	if err != nil {
+		return err
+	}
+	if len(conditions) > 0 {
+		e.searches[pattern] = conditions
+	}
+	return nil
+}
+
+// searchConditions returns the conditions of every search expression of a pattern
+func searchConditions(pattern string) ([]condition, error) {
+	if strings.Count(pattern, `"`)%2 != 0 {
+		return nil, fmt.Errorf("%w: unterminated quote in %s", ErrInvalidPath, pattern)
+	}
+
+	var conditions []condition
+	for i, segment := range splitSegments(pattern) {
+		if !isSearchSegment(segment) {
+			continue
+		}
+		parsed, err := parseSearch(segment, i)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, parsed...)
+	}
+	return conditions, nil
+}
+
+// normalizePattern turns the named wildcards and search expressions of a
+// pattern into plain wildcards
+func normalizePattern(pattern string) string {
+	if !strings.ContainsAny(pattern, "{[") {
+		return pattern
+	}
+	segments := splitSegments(pattern)
+	for i, segment := range segments {
+		if _, ok := captureName(segment); ok || isSearchSegment(segment) {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, ".")
+}
@@ -0,0 +1,96 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("USP Search Expressions", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should expand the instances matching a search expression", func() {
+		Expect(exp.Add(`Device.WiFi.AccessPoint.[SSIDReference=="Device.WiFi.SSID.1."].Enable`)).To(Succeed())
+
+		path, hasMore := exp.Next()
+		Expect(hasMore).To(BeTrue())
+		Expect(path).To(Equal("Device.WiFi.AccessPoint."))
+		Expect(exp.Register([]string{path + "1.", path + "2."})).To(Succeed())
+
+		Expect(exp.NextValueQuery()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.SSIDReference",
+			"Device.WiFi.AccessPoint.2.SSIDReference",
+		}))
+		Expect(exp.RegisterValues(map[string]string{
+			"Device.WiFi.AccessPoint.1.SSIDReference": "Device.WiFi.SSID.2.",
+			"Device.WiFi.AccessPoint.2.SSIDReference": "Device.WiFi.SSID.1.",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.AccessPoint.2.Enable"}))
+	})
+
+	It("should combine clauses and compare numbers", func() {
+		Expect(exp.Add("Device.WiFi.AccessPoint.*.AssociatedDevice.[SignalStrength>-70&&Active==true].MACAddress")).To(Succeed())
+
+		drainDiscoveries(exp)
+		Expect(exp.NextValueQuery()).To(Equal([]string{
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.1.Active",
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.1.SignalStrength",
+		}))
+		Expect(exp.RegisterValues(map[string]string{
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.1.Active":         "true",
+			"Device.WiFi.AccessPoint.1.AssociatedDevice.1.SignalStrength": "-65",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.AccessPoint.1.AssociatedDevice.1.MACAddress"}))
+	})
+
+	It("should leave out instances failing a comparison", func() {
+		Expect(exp.Add("Device.Hosts.Host.[Active!=false].HostName")).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1.", path + "2."})).To(Succeed())
+		Expect(exp.RegisterValues(map[string]string{
+			"Device.Hosts.Host.1.Active": "false",
+			"Device.Hosts.Host.2.Active": "true",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.Hosts.Host.2.HostName"}))
+	})
+
+	It("should keep operators and separators inside quoted values", func() {
+		Expect(exp.Add(`Device.Hosts.Host.[HostName=="a&&b<c"].IPAddress`)).To(Succeed())
+
+		path, _ := exp.Next()
+		Expect(exp.Register([]string{path + "1."})).To(Succeed())
+		Expect(exp.RegisterValues(map[string]string{"Device.Hosts.Host.1.HostName": "a&&b<c"})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.Hosts.Host.1.IPAddress"}))
+	})
+
+	It("should bind search segments like wildcards", func() {
+		Expect(exp.Add(`Device.WiFi.AccessPoint.[Enable==true].AssociatedDevice.{sta}.MACAddress`)).To(Succeed())
+
+		drainDiscoveries(exp)
+		Expect(exp.RegisterValues(map[string]string{"Device.WiFi.AccessPoint.1.Enable": "true"})).To(Succeed())
+
+		bindings, err := exp.CollectBindings()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bindings).To(HaveLen(1))
+		Expect(bindings[0].Captures).To(Equal(map[string]string{"sta": "1"}))
+	})
+
+	It("should reject malformed search expressions", func() {
+		Expect(exp.Add("Device.Hosts.Host.[==1].HostName")).To(MatchError(expander.ErrInvalidPath))
+		Expect(exp.Add(`Device.Hosts.Host.[HostName=="open].IPAddress`)).To(MatchError(expander.ErrInvalidPath))
+		Expect(exp.Add("Device.Hosts.Host.[Active==true&&].HostName")).To(MatchError(expander.ErrInvalidPath))
+	})
+})
@@ -0,0 +1,116 @@
+package expander
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// ErrNotExpressible is returned for patterns USP paths cannot express
+var ErrNotExpressible = errors.New("pattern cannot be expressed as a USP path")
+
+// USPGet holds the fields of a TR-369 USP Get request, to be copied into
+// the protobuf message of the USP stack in use.
+type USPGet struct {
+	// ParamPaths are the paths to read, in USP path syntax
+	ParamPaths []string `json:"param_paths"`
+
+	// MaxDepth limits the depth of the objects returned for partial paths;
+	// zero returns whole subtrees
+	MaxDepth uint32 `json:"max_depth,omitempty"`
+}
+
+// USPGetPatterns builds a USP Get request reading the added patterns from a
+// USP agent, which resolves the wildcards itself. Named wildcards become
+// "*", index selectors become the instance numbers they select, alias
+// segments and filters become search expressions, and patterns ending with
+// "**" or "*" become partial paths. Glob segments and exclusion selectors
+// cannot be expressed and fail with ErrNotExpressible.
+func (e *Expander) USPGetPatterns(maxDepth uint32) (USPGet, error) {
+	get := USPGet{MaxDepth: maxDepth}
+	for _, pattern := range e.patterns {
+		paths, err := e.uspPaths(pattern)
+		if err != nil {
+			return USPGet{}, err
+		}
+		for _, path := range paths {
+			if !slices.Contains(get.ParamPaths, path) {
+				get.ParamPaths = append(get.ParamPaths, path)
+			}
+		}
+	}
+	return get, nil
+}
+
+// USPGetExpanded builds a USP Get request reading the expanded paths, for
+// agents addressed with the instances discovered over CWMP.
+// This should be called after Next() returns false.
+func (e *Expander) USPGetExpanded(maxDepth uint32) (USPGet, error) {
+	paths, err := e.Collect()
+	if err != nil {
+		return USPGet{}, err
+	}
+	return USPGet{ParamPaths: paths, MaxDepth: maxDepth}, nil
+}
+
+// uspPaths returns the USP paths expressing a pattern, one per combination
+// of the instances its index selectors select
+func (e *Expander) uspPaths(pattern string) ([]string, error) {
+	unsupported := func(segment string) error {
+		return fmt.Errorf("%w: segment %q of %s", ErrNotExpressible, segment, pattern)
+	}
+
+	filters := e.filters[pattern]
+	filterDepth := -1
+	if len(filters) > 0 {
+		filterDepth = wildcardDepth(pattern)
+	}
+
+	paths := []string{""}
+	segments := splitSegments(pattern)
+	for i, segment := range segments {
+		var alternatives []string
+		selector, _ := parseIndexSelector(segment)
+		_, captured := captureName(segment)
+		alias, aliased := e.patternCodec().Decode(segment)
+		switch {
+		case i == len(segments)-1 && (segment == "*" || segment == "**"):
+			alternatives = []string{""}
+		case i == filterDepth && (segment == "*" || captured):
+			for _, filter := range filters {
+				alternatives = append(alternatives, "["+filter.Param+"=="+strconv.Quote(filter.Equals)+"]")
+			}
+		case i == filterDepth:
+			return nil, unsupported(segment)
+		case segment == "*" || captured:
+			alternatives = []string{"*"}
+		case isSearchSegment(segment):
+			alternatives = []string{segment}
+		case selector != nil:
+			instances, ok := selector.enumerate()
+			if !ok {
+				return nil, unsupported(segment)
+			}
+			alternatives = instances
+		case aliased:
+			alternatives = []string{"[Alias==" + strconv.Quote(alias) + "]"}
+		case segment == "**" || isGlobSegment(segment):
+			return nil, unsupported(segment)
+		default:
+			alternatives = []string{segment}
+		}
+
+		combined := make([]string, 0, len(paths)*len(alternatives))
+		for _, path := range paths {
+			if i > 0 {
+				path += "."
+			}
+			for _, alternative := range alternatives {
+				combined = append(combined, path+alternative)
+			}
+		}
+		paths = combined
+	}
+	return paths, nil
+}
@@ -0,0 +1,63 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("USP Get Requests", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get()
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should express patterns as USP paths", func() {
+		Expect(exp.Add(
+			"Device.WiFi.SSID.*.SSID",
+			"Device.WiFi.AccessPoint.{ap}.AssociatedDevice.*.MACAddress",
+			"Device.WiFi.Radio.[1,3].Channel",
+			"Device.IP.Interface.[wan].Status",
+			`Device.Hosts.Host.[Active==true].HostName`,
+			"Device.DeviceInfo.**",
+			"Device.Ethernet.Interface.1.*",
+			"Device.WiFi.SSID.*.SSID",
+		)).To(Succeed())
+		Expect(exp.AddFiltered("Device.NAT.PortMapping.*.InternalClient", expander.Filter{Param: "Enable", Equals: "true"})).To(Succeed())
+
+		Expect(exp.USPGetPatterns(2)).To(Equal(expander.USPGet{
+			ParamPaths: []string{
+				"Device.WiFi.SSID.*.SSID",
+				"Device.WiFi.AccessPoint.*.AssociatedDevice.*.MACAddress",
+				"Device.WiFi.Radio.1.Channel",
+				"Device.WiFi.Radio.3.Channel",
+				`Device.IP.Interface.[Alias=="wan"].Status`,
+				`Device.Hosts.Host.[Active==true].HostName`,
+				"Device.DeviceInfo.",
+				"Device.Ethernet.Interface.1.",
+				`Device.NAT.PortMapping.[Enable=="true"].InternalClient`,
+			},
+			MaxDepth: 2,
+		}))
+	})
+
+	It("should reject patterns USP paths cannot express", func() {
+		Expect(exp.Add("Device.WiFi.SSID.[*-2].SSID")).To(Succeed())
+		_, err := exp.USPGetPatterns(0)
+		Expect(err).To(MatchError(expander.ErrNotExpressible))
+	})
+
+	It("should read the expanded paths", func() {
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+		drainDiscoveries(exp)
+		Expect(exp.USPGetExpanded(1)).To(Equal(expander.USPGet{
+			ParamPaths: []string{"Device.WiFi.SSID.1.SSID"},
+			MaxDepth:   1,
+		}))
+	})
+})
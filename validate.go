@@ -0,0 +1,171 @@
+package expander
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/metalgrid/tr069-path-expander/v2/datamodel"
+)
+
+// IssueKind classifies the problems Validate finds in patterns.
+type IssueKind int
+
+// Issue kinds
+const (
+	// IssueInvalidPattern reports a pattern Add would reject
+	IssueInvalidPattern IssueKind = iota + 1
+
+	// IssueUnknownObject reports an object missing from the data model
+	IssueUnknownObject
+
+	// IssueUnknownParameter reports a parameter missing from the data model
+	IssueUnknownParameter
+
+	// IssueNotMultiInstance reports a wildcard or instance below an object
+	// that is not a table
+	IssueNotMultiInstance
+)
+
+// String returns a short name for the kind.
+func (k IssueKind) String() string {
+	switch k {
+	case IssueInvalidPattern:
+		return "invalid-pattern"
+	case IssueUnknownObject:
+		return "unknown-object"
+	case IssueUnknownParameter:
+		return "unknown-parameter"
+	case IssueNotMultiInstance:
+		return "not-multi-instance"
+	default:
+		return fmt.Sprintf("IssueKind(%d)", int(k))
+	}
+}
+
+// ValidationIssue is a problem found in a pattern by Validate.
+type ValidationIssue struct {
+	Kind IssueKind
+
+	// Pattern is the pattern the issue is about
+	Pattern string
+
+	// Segment is the offending segment of the pattern, if any
+	Segment string
+
+	// Detail describes the issue
+	Detail string
+}
+
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Kind, i.Pattern, i.Detail)
+}
+
+// Validate checks patterns against the schema set with WithSchema, before
+// any device session is spent on them: wildcards and instances must stand
+// below tables, and every object and parameter name must exist in the data
+// model. Issues are reported in pattern order, at most one per pattern.
+// Patterns Add would reject are reported whether or not a schema is set;
+// without a schema nothing else is checked. Segments matching several
+// names, such as "**" and glob segments, end the check of their pattern.
+func (e *Expander) Validate(paths []string) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, path := range paths {
+		if issue, ok := e.validate(path); !ok {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// validate checks a single pattern
+func (e *Expander) validate(pattern string) (ValidationIssue, bool) {
+	invalid := func(err error) ValidationIssue {
+		return ValidationIssue{Kind: IssueInvalidPattern, Pattern: pattern, Detail: err.Error()}
+	}
+
+	normalized := normalizePattern(pattern)
+	if pattern == "" {
+		return invalid(ErrInvalidPath), false
+	}
+	if err := checkWildcards(normalized, e.config.globSegments); err != nil {
+		return invalid(err), false
+	}
+	if err := checkCaptures(pattern); err != nil {
+		return invalid(err), false
+	}
+	if _, err := searchConditions(pattern); err != nil {
+		return invalid(err), false
+	}
+	for segment := range strings.SplitSeq(normalized, ".") {
+		if _, err := parseIndexSelector(segment); err != nil {
+			return invalid(err), false
+		}
+	}
+
+	if e.config.schema == nil {
+		return ValidationIssue{}, true
+	}
+
+	segments := strings.Split(normalized, ".")
+	original := splitSegments(pattern)
+	object := ""
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if segment == "" || segment == "**" || isGlobSegment(segment) {
+			break
+		}
+
+		issue := ValidationIssue{Pattern: pattern, Segment: original[i]}
+		children := e.config.schema.Children(object)
+		switch {
+		case segment == "*" && last:
+			// A trailing wildcard also stands for the parameters of an object
+			if len(children) == 0 {
+				issue.Kind = IssueUnknownObject
+				issue.Detail = fmt.Sprintf("%s is not in the data model", object)
+				return issue, false
+			}
+			if !slices.Contains(children, datamodel.Instance+".") && !hasParameters(children) {
+				issue.Kind = IssueNotMultiInstance
+				issue.Detail = fmt.Sprintf("%s is neither a table nor has parameters", object)
+				return issue, false
+			}
+		case isBindingSegment(segment) || isInstanceSegment(segment) || e.isAliasSegment(segment):
+			if !slices.Contains(children, datamodel.Instance+".") {
+				issue.Kind = IssueNotMultiInstance
+				issue.Detail = fmt.Sprintf("%s is not a table", object)
+				return issue, false
+			}
+			segment = datamodel.Instance
+		case slices.Contains(children, segment+"."):
+		case last && slices.Contains(children, segment):
+		case last:
+			issue.Kind = IssueUnknownParameter
+			issue.Detail = fmt.Sprintf("%s%s is not in the data model", object, segment)
+			return issue, false
+		default:
+			issue.Kind = IssueUnknownObject
+			issue.Detail = fmt.Sprintf("%s%s. is not in the data model", object, segment)
+			return issue, false
+		}
+		object += segment + "."
+	}
+	return ValidationIssue{}, true
+}
+
+// isAliasSegment reports whether a pattern segment addresses an instance by alias
+func (e *Expander) isAliasSegment(segment string) bool {
+	_, ok := e.patternCodec().Decode(segment)
+	return ok
+}
+
+// hasParameters reports whether a list of children names parameters
+func hasParameters(children []string) bool {
+	for _, child := range children {
+		if !strings.HasSuffix(child, ".") {
+			return true
+		}
+	}
+	return false
+}
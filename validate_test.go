@@ -0,0 +1,83 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	var exp *expander.Expander
+
+	BeforeEach(func() {
+		exp = expander.Get(expander.WithSchema(loadTestSchema()))
+	})
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should accept patterns matching the data model", func() {
+		Expect(exp.Validate([]string{
+			"Device.WiFi.SSID.*.SSID",
+			"Device.WiFi.SSID.{ssid}.SSID",
+			"Device.WiFi.SSID.[1-4].SSID",
+			"Device.WiFi.SSID.[cpe-1].SSID",
+			`Device.WiFi.SSID.[SSID=="guest"].SSID`,
+			"Device.WiFi.SSID.2.SSID",
+			"Device.WiFi.SSID.*",
+			"Device.WiFi.SSID.*.",
+			"Device.DeviceInfo.ProcessStatus.*",
+			"Device.DeviceInfo.**",
+		})).To(BeEmpty())
+	})
+
+	It("should report unknown names and misplaced wildcards", func() {
+		issues := exp.Validate([]string{
+			"Device.WiFi.SSID.*.SSID",
+			"Device.WiFi.SSID.*.SSIDName",
+			"Device.WLANConfigration.*.SSID",
+			"Device.DeviceInfo.*.CPUUsage",
+			"Device.DeviceInfo.ProcessStatus.Process.1.PID.*",
+		})
+		Expect(issues).To(Equal([]expander.ValidationIssue{
+			{
+				Kind:    expander.IssueUnknownParameter,
+				Pattern: "Device.WiFi.SSID.*.SSIDName",
+				Segment: "SSIDName",
+				Detail:  "Device.WiFi.SSID.{i}.SSIDName is not in the data model",
+			},
+			{
+				Kind:    expander.IssueUnknownObject,
+				Pattern: "Device.WLANConfigration.*.SSID",
+				Segment: "WLANConfigration",
+				Detail:  "Device.WLANConfigration. is not in the data model",
+			},
+			{
+				Kind:    expander.IssueNotMultiInstance,
+				Pattern: "Device.DeviceInfo.*.CPUUsage",
+				Segment: "*",
+				Detail:  "Device.DeviceInfo. is not a table",
+			},
+			{
+				Kind:    expander.IssueUnknownObject,
+				Pattern: "Device.DeviceInfo.ProcessStatus.Process.1.PID.*",
+				Segment: "PID",
+				Detail:  "Device.DeviceInfo.ProcessStatus.Process.{i}.PID. is not in the data model",
+			},
+		}))
+		Expect(issues[1].String()).To(Equal("unknown-object: Device.WLANConfigration.*.SSID: Device.WLANConfigration. is not in the data model"))
+	})
+
+	It("should report invalid patterns without a schema", func() {
+		plain := expander.Get()
+		defer expander.Release(plain)
+
+		Expect(plain.Validate([]string{"Device.WLANConfigration.*.SSID"})).To(BeEmpty())
+
+		issues := plain.Validate([]string{"Device.WiFi.SSID.{a}.AssociatedDevice.{a}.MACAddress", "Device.WiFi.SSID.[4-1].SSID"})
+		Expect(issues).To(HaveLen(2))
+		Expect(issues[0].Kind).To(Equal(expander.IssueInvalidPattern))
+		Expect(issues[1].Kind).To(Equal(expander.IssueInvalidPattern))
+	})
+})
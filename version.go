@@ -0,0 +1,87 @@
+package expander
+
+import "slices"
+
+// version is the release of the library
+const version = "2.0.0-dev"
+
+// features lists the capabilities of this build, one stable name per
+// pattern syntax or protocol feature, in the order they were introduced
+var features = []string{
+	"multi-wildcard",
+	"batch-discovery",
+	"alias-instances",
+	"literal-indices",
+	"device-binding",
+	"sensitive-filter",
+	"unreadable-skip",
+	"discovery-driver",
+	"stream",
+	"state-snapshot",
+	"pluggable-cache",
+	"glob-segments",
+	"alias-patterns",
+	"index-ranges",
+	"index-sets",
+	"index-exclusions",
+	"value-filters",
+	"recursive-wildcard",
+	"driver-capabilities",
+	"parameter-wildcard",
+	"subtree-expansion",
+	"instance-wildcard",
+	"named-captures",
+	"usp-search",
+	"datamodel-schema",
+	"schema-fixed-tables",
+	"schema-validation",
+	"schema-suggestions",
+	"data-model-translation",
+	"register-tree",
+	"expand-against",
+	"collect-writable",
+	"cwmp-soap",
+	"usp-get",
+	"sessions",
+	"genieacs-discoverer",
+	"expand-parallel",
+	"discovery-retry",
+	"rate-limit",
+	"register-fault",
+	"skip",
+	"requeue",
+	"expansion-report",
+	"lenient-subtrees",
+	"case-insensitive",
+	"prefix-repair",
+	"response-warnings",
+	"max-expanded-paths",
+	"instance-cap",
+	"discovery-budget",
+	"max-wildcards",
+	"blocked-subtrees",
+	"allowed-subtrees",
+	"instance-sampling",
+	"chunked-expansion",
+	"pattern-priorities",
+	"traversal-order",
+	"deterministic-order",
+	"path-order",
+}
+
+// Version returns the release of the library.
+func Version() string {
+	return version
+}
+
+// Features returns the names of the capabilities supported by this build, so
+// services sharing patterns can assert compatibility at startup rather than
+// failing mid-expansion on unsupported syntax.
+func Features() []string {
+	return slices.Clone(features)
+}
+
+// HasFeature reports whether the named capability is supported by this build.
+func HasFeature(name string) bool {
+	return slices.Contains(features, name)
+}
@@ -0,0 +1,24 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version And Features", func() {
+	It("should report a semantic version", func() {
+		Expect(expander.Version()).To(MatchRegexp(`^\d+\.\d+\.\d+(-[0-9A-Za-z.]+)?$`))
+	})
+
+	It("should report the supported capabilities", func() {
+		features := expander.Features()
+		Expect(features).To(ContainElements("multi-wildcard", "alias-instances", "stream"))
+		Expect(expander.HasFeature("alias-instances")).To(BeTrue())
+		Expect(expander.HasFeature("time-travel")).To(BeFalse())
+
+		// The returned list is a copy
+		features[0] = "tampered"
+		Expect(expander.Features()).NotTo(ContainElement("tampered"))
+	})
+})
@@ -0,0 +1,96 @@
+package expander
+
+import "fmt"
+
+// WarningKind classifies the data an expander dropped or altered.
+type WarningKind int
+
+// Warning kinds
+const (
+	// WarningOversizedResponse reports a discovery answered with more names
+	// than the limit set with WithResponseLimit
+	WarningOversizedResponse WarningKind = iota + 1
+
+	// WarningRepairedPrefix reports a discovery answered with names relative
+	// to the discovery path, repaired as set with WithPrefixRepair
+	WarningRepairedPrefix
+
+	// WarningForeignName reports names in a discovery response that are not
+	// below the discovery path, which are ignored
+	WarningForeignName
+
+	// WarningNonNumericInstance reports child segments of a table discovery
+	// that are neither instance numbers nor aliases, which are ignored
+	WarningNonNumericInstance
+
+	// WarningDuplicateInstance reports instances listed more than once in a
+	// discovery response
+	WarningDuplicateInstance
+
+	// WarningDeadPattern reports a pattern that expanded to no paths once
+	// the expansion completed
+	WarningDeadPattern
+
+	// WarningInstanceCap reports a discovery listing more instances than
+	// the cap set with WithInstanceCap, of which only the first are used
+	WarningInstanceCap
+)
+
+// String returns a short name for the kind.
+func (k WarningKind) String() string {
+	switch k {
+	case WarningOversizedResponse:
+		return "oversized-response"
+	case WarningRepairedPrefix:
+		return "repaired-prefix"
+	case WarningForeignName:
+		return "foreign-name"
+	case WarningNonNumericInstance:
+		return "non-numeric-instance"
+	case WarningDuplicateInstance:
+		return "duplicate-instance"
+	case WarningDeadPattern:
+		return "dead-pattern"
+	case WarningInstanceCap:
+		return "instance-cap"
+	default:
+		return fmt.Sprintf("WarningKind(%d)", int(k))
+	}
+}
+
+// Warning reports data the expander dropped or altered while expanding,
+// which may explain an expansion smaller than expected.
+type Warning struct {
+	Kind WarningKind
+
+	// Path is the discovery path or parameter name the warning is about
+	Path string
+
+	// Detail describes what happened
+	Detail string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Kind, w.Path, w.Detail)
+}
+
+// Warnings returns the warnings raised since the expander was obtained, in
+// the order they were raised.
+func (e *Expander) Warnings() []Warning {
+	return append([]Warning(nil), e.warnings...)
+}
+
+// warn records a warning
+func (e *Expander) warn(kind WarningKind, path, format string, args ...any) {
+	e.warnings = append(e.warnings, Warning{Kind: kind, Path: path, Detail: fmt.Sprintf(format, args...)})
+}
+
+// warnOnce records a warning unless one of the same kind was raised for path
+func (e *Expander) warnOnce(kind WarningKind, path, format string, args ...any) {
+	for _, w := range e.warnings {
+		if w.Kind == kind && w.Path == path {
+			return
+		}
+	}
+	e.warn(kind, path, format, args...)
+}
@@ -0,0 +1,83 @@
+package expander_test
+
+import (
+	expander "github.com/metalgrid/tr069-path-expander/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Warnings", func() {
+	var exp *expander.Expander
+
+	AfterEach(func() {
+		expander.Release(exp)
+	})
+
+	It("should report ignored and redundant names of a response", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{
+			"Device.WiFi.SSID",
+			"Device.WiFi.SSID.1.",
+			"Device.WiFi.SSID.1.",
+			"Device.WiFi.SSID.Foo.",
+			"Device.WiFi.Radio.1.",
+		})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.WiFi.SSID.1.SSID"}))
+		Expect(exp.Warnings()).To(Equal([]expander.Warning{
+			{
+				Kind:   expander.WarningForeignName,
+				Path:   "Device.WiFi.SSID.",
+				Detail: "1 names outside the discovery path ignored, e.g. Device.WiFi.Radio.1.",
+			},
+			{
+				Kind:   expander.WarningNonNumericInstance,
+				Path:   "Device.WiFi.SSID.",
+				Detail: "segments ignored: Foo",
+			},
+			{
+				Kind:   expander.WarningDuplicateInstance,
+				Path:   "Device.WiFi.SSID.",
+				Detail: "instances listed more than once: 1",
+			},
+		}))
+	})
+
+	It("should not report child names matched by glob segments or aliases", func() {
+		exp = expander.Get(expander.WithGlobSegments(), expander.WithAliasCodec(expander.BracketAliases))
+		Expect(exp.Add("Device.Services.*Service.*.Enable")).To(Succeed())
+
+		exp.Next()
+		Expect(exp.Register([]string{"Device.Services.VoiceService.", "Device.Services.Other"})).To(Succeed())
+		exp.Next()
+		Expect(exp.Register([]string{"Device.Services.VoiceService.[cpe-1]."})).To(Succeed())
+
+		Expect(exp.Collect()).To(Equal([]string{"Device.Services.VoiceService.[cpe-1].Enable"}))
+		Expect(exp.Warnings()).To(BeEmpty())
+	})
+
+	It("should report patterns expanding to no paths once", func() {
+		exp = expander.Get()
+		Expect(exp.Add("Device.WiFi.SSID.*.SSID", "Device.Hosts.Host.*.IPAddress")).To(Succeed())
+
+		for path, ok := exp.Next(); ok; path, ok = exp.Next() {
+			if path == "Device.WiFi.SSID." {
+				Expect(exp.Register([]string{path + "1."})).To(Succeed())
+			} else {
+				Expect(exp.RegisterFor(path, nil)).To(Succeed())
+			}
+		}
+		Expect(exp.Collect()).To(HaveLen(1))
+		Expect(exp.Collect()).To(HaveLen(1))
+
+		Expect(exp.Warnings()).To(Equal([]expander.Warning{{
+			Kind:   expander.WarningDeadPattern,
+			Path:   "Device.Hosts.Host.*.IPAddress",
+			Detail: "no paths expanded",
+		}}))
+		Expect(exp.Warnings()[0].String()).To(Equal("dead-pattern: Device.Hosts.Host.*.IPAddress: no paths expanded"))
+	})
+})